@@ -1,141 +1,467 @@
 package cert
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/flily/go-ssl/common/clicontext"
 	"github.com/flily/go-ssl/common/encoder"
 	"github.com/flily/go-ssl/common/prettyprint"
+	asn1decode "github.com/flily/go-ssl/modules/asn1"
 )
 
-func showPublicKey(publicKey any) {
+// publicKeyDocument is subject public key of requests and certificates.
+type publicKeyDocument struct {
+	Algorithm string          `json:"algorithm"`
+	Bits      int             `json:"bits,omitempty"`
+	Modulus   prettyprint.Hex `json:"modulus,omitempty"`
+	Exponent  int             `json:"exponent,omitempty"`
+	Point     prettyprint.Hex `json:"point,omitempty"`
+	Curve     string          `json:"curve,omitempty"`
+}
+
+func newPublicKeyDocument(algorithm x509.PublicKeyAlgorithm, publicKey any) *publicKeyDocument {
+	doc := &publicKeyDocument{
+		Algorithm: algorithm.String(),
+	}
+
 	if key, ok := publicKey.(*rsa.PublicKey); ok {
-		fmt.Printf("      Public-Key: (%d bit)\n", key.N.BitLen())
-		prettyprint.PrintBinaryWithIndent("Modulus", "      ", key.N.Bytes())
-		fmt.Printf("      Exponent: %d (0x%x)\n", key.E, key.E)
+		doc.Bits = key.N.BitLen()
+		doc.Modulus = key.N.Bytes()
+		doc.Exponent = key.E
 	}
 
 	if key, ok := publicKey.(*ecdsa.PublicKey); ok {
-		fmt.Printf("      Public-Key: (%d bit)\n", key.Curve.Params().BitSize)
-		prettyprint.PrintBinariesWithIndent("pub", "      ",
-			[]byte{0x04}, key.X.Bytes(), key.Y.Bytes())
-		fmt.Printf("      ASN1 OID: %s\n", key.Curve.Params().Name)
+		params := key.Curve.Params()
+		size := (params.BitSize + 7) / 8
+		doc.Bits = params.BitSize
+		point := make([]byte, 1+2*size)
+		point[0] = 0x04
+		key.X.FillBytes(point[1 : 1+size])
+		key.Y.FillBytes(point[1+size:])
+		doc.Point = point
+		doc.Curve = params.Name
 	}
+
+	return doc
+}
+
+func (d *publicKeyDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinter(w)
+	d.write(p.Indent("      "))
+	return p.Err()
 }
 
-func showCSR(filename string) error {
-	chain, err := encoder.ParseContainerChainFromFile(filename)
+func (d *publicKeyDocument) write(p *prettyprint.Printer) {
+	p.Printf("Public Key Algorithm: %s\n", d.Algorithm)
+	if d.Modulus != nil {
+		p.Printf("Public-Key: (%d bit)\n", d.Bits)
+		p.Binary("Modulus", d.Modulus)
+		p.Printf("Exponent: %d (0x%x)\n", d.Exponent, d.Exponent)
+	}
+
+	if d.Point != nil {
+		p.Printf("Public-Key: (%d bit)\n", d.Bits)
+		p.Binary("pub", d.Point)
+		p.Printf("ASN1 OID: %s\n", d.Curve)
+	}
+}
+
+func loadCSR(cio *clicontext.Context) (*x509.CertificateRequest, error) {
+	chain, err := cio.LoadContainer()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if chain.KeyType() != encoder.KeyTypeCertificateRequest {
-		return fmt.Errorf("Not a CSR file")
+		return nil, clicontext.InputFormatErrorf("Not a CSR file")
 	}
 
-	request := chain.CertificateRequest()
-	fmt.Printf("Certificate Request:\n")
-	fmt.Printf("  Data:\n")
-	fmt.Printf("    Version: %d (0x%x)\n", request.Version, request.Version)
-	fmt.Printf("    Subject: %s\n", request.Subject.String())
-	fmt.Printf("    Subject Public Key Info:\n")
-	fmt.Printf("      Public Key Algorithm: %s\n", request.PublicKeyAlgorithm)
-	showPublicKey(request.PublicKey)
+	return chain.CertificateRequest(), nil
+}
+
+type attributeDocument struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// requestDocument is the certificate request shown by cert csr -in.
+type requestDocument struct {
+	Version            int                 `json:"version"`
+	Subject            string              `json:"subject"`
+	PublicKey          *publicKeyDocument  `json:"public_key"`
+	Attributes         []attributeDocument `json:"attributes"`
+	SignatureAlgorithm string              `json:"signature_algorithm"`
+	Signature          prettyprint.Hex     `json:"signature"`
+}
+
+func newRequestDocument(request *x509.CertificateRequest) *requestDocument {
+	doc := &requestDocument{
+		Version:            request.Version,
+		Subject:            request.Subject.String(),
+		PublicKey:          newPublicKeyDocument(request.PublicKeyAlgorithm, request.PublicKey),
+		Attributes:         []attributeDocument{},
+		SignatureAlgorithm: request.SignatureAlgorithm.String(),
+		Signature:          request.Signature,
+	}
 
-	fmt.Printf("    Attributes:\n")
 	for _, attr := range request.Attributes {
-		fmt.Printf("      %s: %s\n", attr.Type, attr.Value)
+		doc.Attributes = append(doc.Attributes, attributeDocument{
+			Type:  attr.Type.String(),
+			Value: attributeValueString(attr.Value),
+		})
+	}
+
+	return doc
+}
+
+// attributeValueString formats values of an attribute as type=value, binary values in hex.
+func attributeValueString(values [][]pkix.AttributeTypeAndValue) string {
+	parts := make([]string, 0, len(values))
+	for _, set := range values {
+		for _, value := range set {
+			var s string
+			switch v := value.Value.(type) {
+			case string:
+				s = v
+			case []byte:
+				s = hex.EncodeToString(v)
+			default:
+				s = fmt.Sprint(v)
+			}
+
+			parts = append(parts, value.Type.String()+"="+s)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (d *requestDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinter(w)
+	p.Printf("Certificate Request:\n")
+	p.Printf("  Data:\n")
+	data := p.Indent("    ")
+	data.Printf("Version: %d (0x%x)\n", d.Version, d.Version)
+	data.Printf("Subject: %s\n", d.Subject)
+	data.Printf("Subject Public Key Info:\n")
+	d.PublicKey.write(p.Indent("      "))
+
+	data.Printf("Attributes:\n")
+	for _, attr := range d.Attributes {
+		data.Printf("  %s: %s\n", attr.Type, attr.Value)
 	}
 
-	fmt.Printf("  Signature Algorithm: %s\n", request.SignatureAlgorithm)
-	prettyprint.PrintBinaryWithIndent("Signature", "  ", request.Signature)
-	return nil
+	p.Printf("  Signature Algorithm: %s\n", d.SignatureAlgorithm)
+	p.Indent("  ").Binary("Signature", d.Signature)
+	return p.Err()
 }
 
 func certCommandCSR(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("csr", flag.ExitOnError)
-	inFile := set.String("in", "", "Input file")
-	key := set.String("key", "", "Private key file")
+	cio := ctx.IO()
+	if ctx.IsSet("in") {
+		request, err := loadCSR(cio)
+		if err != nil {
+			return err
+		}
+
+		if cio.OutForm == clicontext.FormatPEM || cio.OutForm == clicontext.FormatDER {
+			return cio.WriteObject("CERTIFICATE REQUEST", request.Raw, false)
+		}
+
+		return cio.RenderDocument(newRequestDocument(request))
+	}
 
-	_ = ctx.Parse(set)
+	key := ctx.String("key")
+	if len(key) <= 0 {
+		return clicontext.UsageErrorf("Either -in or -key is required")
+	}
+
+	if cio.IsDocument() {
+		return clicontext.UsageErrorf("output format %s is only supported with -in", cio.OutForm)
+	}
+
+	signer, err := loadSigner(key, ctx.String("keyform"))
+	if err != nil {
+		return err
+	}
 
-	if len(*inFile) > 0 {
-		return showCSR(*inFile)
+	conf := ctx.Config()
+	section := ctx.String("section")
+	subject, err := subjectOf(ctx, conf, section)
+	if err != nil {
+		return err
 	}
 
-	if len(*key) <= 0 {
-		return fmt.Errorf("Private key file is required")
+	md := ctx.String("md")
+	if len(md) <= 0 {
+		md, _ = conf.Get(section, "default_md")
 	}
 
-	keyContainer, err := encoder.ParseContainerChainFromFile(*key)
+	algorithm, err := signatureAlgorithm(signer, md)
 	if err != nil {
 		return err
 	}
 
+	if ctx.Bool("x509") {
+		return selfSign(ctx, cio, signer, subject, algorithm)
+	}
+
 	template := &x509.CertificateRequest{
-		PublicKey: keyContainer.FirstPublicKey(),
+		Subject:            subject,
+		SignatureAlgorithm: algorithm,
 	}
 
-	csr, err := x509.CreateCertificateRequest(rand.Reader, template, keyContainer.FirstPrivateKey())
+	extensions, err := extensionsOf(ctx, conf, section, "req_extensions")
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s", encoder.PEMEncode("CERTIFICATE REQUEST", csr))
-	return nil
+	if extensions != nil {
+		if err := extensions.ApplyRequest(template); err != nil {
+			return err
+		}
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, signer)
+	if err != nil {
+		return err
+	}
+
+	return cio.WriteObject("CERTIFICATE REQUEST", csr, false)
 }
 
-func showCert(filename string) error {
-	chain, err := encoder.ParseContainerChainFromFile(filename)
+// selfSign creates a self-signed certificate with x509_extensions of config section.
+func selfSign(ctx *clicontext.CommandContext, cio *clicontext.Context, signer crypto.Signer,
+	subject pkix.Name, algorithm x509.SignatureAlgorithm) error {
+	conf := ctx.Config()
+	days := ctx.Int("days")
+	if days <= 0 {
+		return clicontext.UsageErrorf("invalid days %d", days)
+	}
+
+	serial, err := randomSerialNumber()
 	if err != nil {
 		return err
 	}
 
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:       serial,
+		Subject:            subject,
+		NotBefore:          now,
+		NotAfter:           now.AddDate(0, 0, days),
+		SignatureAlgorithm: algorithm,
+	}
+
+	extensions, err := extensionsOf(ctx, conf, ctx.String("section"), "x509_extensions")
+	if err != nil {
+		return err
+	}
+
+	if extensions != nil {
+		extensions.ApplyCertificate(template)
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		return err
+	}
+
+	return cio.WriteObject("CERTIFICATE", cert, false)
+}
+
+func loadCert(cio *clicontext.Context) (*x509.Certificate, error) {
+	chain, err := cio.LoadContainer()
+	if err != nil {
+		return nil, err
+	}
+
 	if chain.KeyType() != encoder.KeyTypeCertificate {
-		return fmt.Errorf("Not a certificate file")
+		return nil, clicontext.InputFormatErrorf("Not a certificate file")
 	}
 
-	cert := chain.Certificate()
-	fmt.Printf("Certificate:\n")
-	fmt.Printf("  Data:\n")
-	fmt.Printf("    Version: %d (0x%x)\n", cert.Version, cert.Version)
-	prettyprint.PrintBinaryWithIndent("Serial Number", "    ", cert.SerialNumber.Bytes())
-	fmt.Printf("    Signature Algorithm: %s\n", cert.SignatureAlgorithm)
-	fmt.Printf("    Issuer: %s\n", cert.Issuer)
-	fmt.Printf("    Validity\n")
-	fmt.Printf("      Not Before: %s\n", cert.NotBefore)
-	fmt.Printf("      Not After: %s\n", cert.NotAfter)
-	fmt.Printf("    Subject: %s\n", cert.Subject)
-	fmt.Printf("    Subject Public Key Info:\n")
-	fmt.Printf("      Public Key Algorithm: %s\n", cert.PublicKeyAlgorithm)
-	showPublicKey(cert.PublicKey)
+	return chain.Certificate(), nil
+}
+
+// expiringDays is number of days before expiry that certificates are highlighted as expiring.
+const expiringDays = 30
 
-	return nil
+// extensionDocument is an extension of certificates, name is given for known extensions.
+type extensionDocument struct {
+	OID      string `json:"oid"`
+	Name     string `json:"name,omitempty"`
+	Critical bool   `json:"critical"`
 }
 
-func certCommandShow(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("cert", flag.ExitOnError)
-	inFile := set.String("in", "", "Input file")
+func newExtensionDocument(ext pkix.Extension) extensionDocument {
+	oid := make(asn1decode.ASN1ObjectIdentifier, len(ext.Id))
+	for i, n := range ext.Id {
+		oid[i] = uint64(n)
+	}
+
+	doc := extensionDocument{
+		OID:      ext.Id.String(),
+		Critical: ext.Critical,
+	}
+
+	if name, found := asn1decode.GetKnownOIDName(&oid); found {
+		doc.Name = name
+	}
+
+	return doc
+}
+
+func (d *extensionDocument) write(p *prettyprint.Printer) {
+	name := p.Colorize(prettyprint.StyleOID, d.OID)
+	if len(d.Name) > 0 {
+		name = fmt.Sprintf("%s (%s)", d.Name, name)
+	}
+
+	if d.Critical {
+		p.Printf("%s: %s\n", name, p.Colorize(prettyprint.StyleCritical, "critical"))
+	} else {
+		p.Printf("%s\n", name)
+	}
+}
+
+// certificateDocument is the certificate shown by cert show. Times are in RFC 3339.
+type certificateDocument struct {
+	Version            int                 `json:"version"`
+	SerialNumber       prettyprint.Hex     `json:"serial_number"`
+	SignatureAlgorithm string              `json:"signature_algorithm"`
+	Issuer             string              `json:"issuer"`
+	NotBefore          time.Time           `json:"not_before"`
+	NotAfter           time.Time           `json:"not_after"`
+	Subject            string              `json:"subject"`
+	PublicKey          *publicKeyDocument  `json:"public_key"`
+	Extensions         []extensionDocument `json:"extensions,omitempty"`
+}
+
+func newCertificateDocument(cert *x509.Certificate) *certificateDocument {
+	doc := &certificateDocument{
+		Version:            cert.Version,
+		SerialNumber:       cert.SerialNumber.Bytes(),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		Issuer:             cert.Issuer.String(),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		Subject:            cert.Subject.String(),
+		PublicKey:          newPublicKeyDocument(cert.PublicKeyAlgorithm, cert.PublicKey),
+	}
+
+	for _, ext := range cert.Extensions {
+		doc.Extensions = append(doc.Extensions, newExtensionDocument(ext))
+	}
+
+	return doc
+}
 
-	_ = ctx.Parse(set)
+// validityStyles returns styles of not before and not after, times out of validity are
+// errors, and not after in expiringDays is a warning.
+func (d *certificateDocument) validityStyles(now time.Time) (prettyprint.Style, prettyprint.Style) {
+	notBefore, notAfter := prettyprint.StyleNone, prettyprint.StyleNone
+	if now.Before(d.NotBefore) {
+		notBefore = prettyprint.StyleError
+	}
 
-	if len(*inFile) > 0 {
-		return showCert(*inFile)
+	if now.After(d.NotAfter) {
+		notAfter = prettyprint.StyleError
+	} else if now.AddDate(0, 0, expiringDays).After(d.NotAfter) {
+		notAfter = prettyprint.StyleWarning
 	}
 
-	return nil
+	return notBefore, notAfter
 }
 
-var certCommands = map[string]clicontext.CommandEntryFunc{
-	"csr":  certCommandCSR,
-	"show": certCommandShow,
+func (d *certificateDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinter(w)
+	p.Printf("Certificate:\n")
+	p.Printf("  Data:\n")
+	data := p.Indent("    ")
+	data.Printf("Version: %d (0x%x)\n", d.Version, d.Version)
+	data.Binary("Serial Number", d.SerialNumber)
+	data.Printf("Signature Algorithm: %s\n", d.SignatureAlgorithm)
+	data.Printf("Issuer: %s\n", d.Issuer)
+	notBefore, notAfter := d.validityStyles(time.Now())
+	data.Printf("Validity\n")
+	data.Printf("  Not Before: %s\n", p.Colorize(notBefore, d.NotBefore.String()))
+	data.Printf("  Not After: %s\n", p.Colorize(notAfter, d.NotAfter.String()))
+	data.Printf("Subject: %s\n", d.Subject)
+	data.Printf("Subject Public Key Info:\n")
+	d.PublicKey.write(p.Indent("      "))
+	if len(d.Extensions) > 0 {
+		data.Printf("X509v3 extensions:\n")
+		for _, ext := range d.Extensions {
+			ext.write(p.Indent("      "))
+		}
+	}
+
+	return p.Err()
+}
+
+func certCommandShow(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	cert, err := loadCert(cio)
+	if err != nil {
+		return err
+	}
+
+	if !cio.IsDocument() {
+		return cio.WriteObject("CERTIFICATE", cert.Raw, false)
+	}
+
+	return cio.RenderDocument(newCertificateDocument(cert))
 }
 
-func MainCert(ctx *clicontext.CommandContext) error {
-	return ctx.Invoke(certCommands)
+var Command = &clicontext.Command{
+	Name:  "cert",
+	Short: "Certificate and certificate request utilities",
+	Subcommands: []*clicontext.Command{
+		{
+			Name:  "csr",
+			Short: "Show a certificate request, or create one with a private key",
+			Long: "Show a certificate request with -in, or create one with -key.\n\n" +
+				"Subject and extensions of new requests are taken from the req section of\n" +
+				"configuration file, i.e. distinguished_name, prompt, req_extensions,\n" +
+				"x509_extensions and default_md, as openssl.cnf does.",
+			Flags: func(set *flag.FlagSet) {
+				clicontext.InputFlags(set)
+				clicontext.OutputFlags(set, "", clicontext.FormatText, clicontext.FormatJSON,
+					clicontext.FormatPEM, clicontext.FormatDER)
+				set.String("key", "", "Private key file to create a request")
+				clicontext.Choice(set, "keyform", clicontext.FormatAuto, "Private key format",
+					clicontext.FormatAuto, clicontext.FormatPEM, clicontext.FormatDER)
+				clicontext.ConfigFlags(set)
+				set.String("section", "req", "Config section of request options")
+				set.String("subj", "", "Subject, e.g. /C=US/O=Org/CN=name, distinguished_name of config if not given")
+				set.String("extensions", "", "Config section of extensions, req_extensions or x509_extensions of config if not given")
+				set.String("md", "", "Message digest of signature, default_md of config if not given")
+				set.Bool("x509", false, "Create a self-signed certificate instead of a request")
+				set.Int("days", 30, "Days of validity of self-signed certificate")
+			},
+			Run: certCommandCSR,
+		},
+		{
+			Name:  "show",
+			Short: "Show a certificate",
+			Flags: func(set *flag.FlagSet) {
+				clicontext.InputFlags(set)
+				clicontext.OutputFlags(set, clicontext.FormatText,
+					clicontext.FormatText, clicontext.FormatJSON, clicontext.FormatPEM, clicontext.FormatDER)
+			},
+			Run: certCommandShow,
+		},
+		signCommand,
+	},
 }
@@ -0,0 +1,25 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+)
+
+func TestECPublicKeyDocumentPoint(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatalf("generate key failed: %s", err)
+		}
+
+		doc := newPublicKeyDocument(x509.ECDSA, &key.PublicKey)
+		expected := elliptic.Marshal(curve, key.X, key.Y) //nolint:staticcheck
+		if !bytes.Equal(doc.Point, expected) {
+			t.Errorf("point of %s is %x, expected %x", curve.Params().Name, []byte(doc.Point), expected)
+		}
+	}
+}
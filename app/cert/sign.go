@@ -0,0 +1,168 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"flag"
+	"time"
+
+	"github.com/flily/go-ssl/common/clicontext"
+)
+
+const defaultSignDays = 365
+
+// caSection returns the CA section given by -name, or default_ca of ca section.
+func caSection(ctx *clicontext.CommandContext) string {
+	if name := ctx.String("name"); len(name) > 0 {
+		return name
+	}
+
+	return ctx.Config().GetString("ca", "default_ca", "ca")
+}
+
+// copyExtensions copies alternative names of request into template according to
+// copy_extensions of CA section, copy only if template has no alternative name.
+func copyExtensions(template *x509.Certificate, request *x509.CertificateRequest, mode string) error {
+	hasNames := len(template.DNSNames) > 0 || len(template.EmailAddresses) > 0 ||
+		len(template.IPAddresses) > 0 || len(template.URIs) > 0
+
+	switch mode {
+	case "", "none":
+		return nil
+
+	case "copy":
+		if hasNames {
+			return nil
+		}
+
+	case "copyall":
+
+	default:
+		return clicontext.InputFormatErrorf("invalid copy_extensions %s", mode)
+	}
+
+	template.DNSNames = request.DNSNames
+	template.EmailAddresses = request.EmailAddresses
+	template.IPAddresses = request.IPAddresses
+	template.URIs = request.URIs
+	return nil
+}
+
+func certCommandSign(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	request, err := loadCSR(cio)
+	if err != nil {
+		return err
+	}
+
+	if err := request.CheckSignature(); err != nil {
+		return clicontext.VerificationErrorf("signature of request is invalid: %s", err)
+	}
+
+	caFile := ctx.String("cacert")
+	caKeyFile := ctx.String("cakey")
+	if len(caFile) <= 0 || len(caKeyFile) <= 0 {
+		return clicontext.UsageErrorf("-cacert and -cakey are required")
+	}
+
+	caContainer, err := clicontext.LoadContainer(caFile, clicontext.FormatAuto)
+	if err != nil {
+		return err
+	}
+
+	caCert := caContainer.Certificate()
+	if caCert == nil {
+		return clicontext.InputFormatErrorf("%s: not a certificate file", caFile)
+	}
+
+	signer, err := loadSigner(caKeyFile, ctx.String("keyform"))
+	if err != nil {
+		return err
+	}
+
+	conf := ctx.Config()
+	section := caSection(ctx)
+	days := ctx.Int("days")
+	if days <= 0 {
+		days, err = configDays(conf, section, "default_days", defaultSignDays)
+		if err != nil {
+			return err
+		}
+	}
+
+	md := ctx.String("md")
+	if len(md) <= 0 {
+		md, _ = conf.Get(section, "default_md")
+	}
+
+	algorithm, err := signatureAlgorithm(signer, md)
+	if err != nil {
+		return err
+	}
+
+	subject := request.Subject
+	if ctx.IsSet("subj") {
+		subject, err = subjectOf(ctx, conf, section)
+		if err != nil {
+			return err
+		}
+	}
+
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:       serial,
+		Subject:            subject,
+		NotBefore:          now,
+		NotAfter:           now.AddDate(0, 0, days),
+		SignatureAlgorithm: algorithm,
+	}
+
+	extensions, err := extensionsOf(ctx, conf, section, "x509_extensions")
+	if err != nil {
+		return err
+	}
+
+	if extensions != nil {
+		extensions.ApplyCertificate(template)
+	}
+
+	if err := copyExtensions(template, request, conf.GetString(section, "copy_extensions", "")); err != nil {
+		return err
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, template, caCert, request.PublicKey, signer)
+	if err != nil {
+		return err
+	}
+
+	return cio.WriteObject("CERTIFICATE", cert, false)
+}
+
+var signCommand = &clicontext.Command{
+	Name:  "sign",
+	Short: "Sign a certificate request with a CA certificate and key",
+	Long: "Sign a certificate request with a CA certificate and key.\n\n" +
+		"CA options are taken from the section named by default_ca of the ca section in\n" +
+		"configuration file, i.e. default_days, default_md, x509_extensions and\n" +
+		"copy_extensions, as openssl.cnf does.",
+	Flags: func(set *flag.FlagSet) {
+		clicontext.InputFlags(set)
+		clicontext.OutputFlags(set, clicontext.FormatPEM, clicontext.FormatPEM, clicontext.FormatDER)
+		set.String("cacert", "", "CA certificate file")
+		set.String("cakey", "", "CA private key file")
+		clicontext.Choice(set, "keyform", clicontext.FormatAuto, "CA private key format",
+			clicontext.FormatAuto, clicontext.FormatPEM, clicontext.FormatDER)
+		clicontext.ConfigFlags(set)
+		set.String("name", "", "Config section of CA options, default_ca of ca section if not given")
+		set.String("subj", "", "Subject, subject of request if not given")
+		set.String("extensions", "", "Config section of extensions, x509_extensions of CA section if not given")
+		set.String("md", "", "Message digest of signature, default_md of CA section if not given")
+		set.Int("days", 0, "Days of validity, default_days of CA section or 365 if not given")
+	},
+	Run: certCommandSign,
+}
@@ -0,0 +1,135 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/config"
+)
+
+var signatureAlgorithms = map[string][2]x509.SignatureAlgorithm{
+	"sha1":   {x509.SHA1WithRSA, x509.ECDSAWithSHA1},
+	"sha256": {x509.SHA256WithRSA, x509.ECDSAWithSHA256},
+	"sha384": {x509.SHA384WithRSA, x509.ECDSAWithSHA384},
+	"sha512": {x509.SHA512WithRSA, x509.ECDSAWithSHA512},
+}
+
+// signatureAlgorithm returns signature algorithm of key with digest md, or
+// x509.UnknownSignatureAlgorithm to use default of the key if md is empty or "default".
+func signatureAlgorithm(key crypto.PrivateKey, md string) (x509.SignatureAlgorithm, error) {
+	if len(md) <= 0 || md == "default" {
+		return x509.UnknownSignatureAlgorithm, nil
+	}
+
+	algorithms, found := signatureAlgorithms[strings.ToLower(md)]
+	if !found {
+		return 0, clicontext.UsageErrorf("unsupported message digest %s", md)
+	}
+
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		return algorithms[0], nil
+	case *ecdsa.PrivateKey:
+		return algorithms[1], nil
+	case ed25519.PrivateKey:
+		return x509.PureEd25519, nil
+	}
+
+	return 0, clicontext.UsageErrorf("key does not support signing certificates")
+}
+
+// loadSigner loads private key from filename, for signing requests and certificates.
+func loadSigner(filename string, form string) (crypto.Signer, error) {
+	container, err := clicontext.LoadContainer(filename, form)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := container.FirstPrivateKey().(crypto.Signer)
+	if !ok {
+		return nil, clicontext.InputFormatErrorf("%s: no private key for signing found", filename)
+	}
+
+	return signer, nil
+}
+
+// subjectOf returns subject given by -subj, or distinguished_name of config section.
+func subjectOf(ctx *clicontext.CommandContext, conf *config.Config, section string) (pkix.Name, error) {
+	if subj := ctx.String("subj"); len(subj) > 0 {
+		name, err := config.ParseSubject(subj)
+		if err != nil {
+			return name, clicontext.UsageErrorf("%s", err)
+		}
+
+		return name, nil
+	}
+
+	dnSection, found := conf.Get(section, "distinguished_name")
+	if !found {
+		return pkix.Name{}, nil
+	}
+
+	prompt := conf.GetString(section, "prompt", "yes") != "no"
+	name, err := conf.DistinguishedName(dnSection, prompt)
+	if err != nil {
+		return name, clicontext.InputFormatErrorf("%s: %s", conf.Filename, err)
+	}
+
+	return name, nil
+}
+
+// extensionsOf reads extensions from section given by -extensions, or the config entry name
+// of section, e.g. req_extensions of req. It returns nil if no extensions are defined.
+func extensionsOf(ctx *clicontext.CommandContext, conf *config.Config, section string,
+	name string) (*config.Extensions, error) {
+	extSection := ctx.String("extensions")
+	if len(extSection) <= 0 {
+		extSection, _ = conf.Get(section, name)
+	}
+
+	if len(extSection) <= 0 {
+		return nil, nil
+	}
+
+	extensions, err := conf.Extensions(extSection)
+	if err != nil {
+		return nil, clicontext.InputFormatErrorf("%s: %s", conf.Filename, err)
+	}
+
+	return extensions, nil
+}
+
+// randomSerialNumber returns a random positive 128 bits serial number.
+func randomSerialNumber() (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 127)
+	serial, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return serial.Add(serial, big.NewInt(1)), nil
+}
+
+// configDays returns days of validity of config entry name, or value if not given.
+func configDays(conf *config.Config, section string, name string, value int) (int, error) {
+	s, found := conf.Get(section, name)
+	if !found {
+		return value, nil
+	}
+
+	days, err := strconv.Atoi(s)
+	if err != nil || days <= 0 {
+		return 0, clicontext.InputFormatErrorf("%s: invalid %s %s", conf.Filename, name, s)
+	}
+
+	return days, nil
+}
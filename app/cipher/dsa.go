@@ -0,0 +1,148 @@
+package cipher
+
+import (
+	"crypto/dsa" //nolint:all
+	"flag"
+	"io"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/encoder"
+	"github.com/flily/go-ssl/common/prettyprint"
+	"github.com/flily/go-ssl/modules/cipher"
+)
+
+func showDSAParameters(p *prettyprint.Printer, params *dsa.Parameters) {
+	p.Integer("P", params.P.Bytes())
+	p.Integer("Q", params.Q.Bytes())
+	p.Integer("G", params.G.Bytes())
+}
+
+func showDSAPublicKey(p *prettyprint.Printer, publicKey *dsa.PublicKey) {
+	p.Printf("DSA Public-Key: (%d bit)\n", publicKey.P.BitLen())
+	p.Integer("Y (public)", publicKey.Y.Bytes())
+	showDSAParameters(p, &publicKey.Parameters)
+}
+
+func showDSAPrivateKey(p *prettyprint.Printer, privateKey *dsa.PrivateKey) {
+	p.Printf("DSA Private-Key: (%d bit)\n", privateKey.P.BitLen())
+	p.Integer("X (private)", privateKey.X.Bytes())
+	p.Integer("Y (public)", privateKey.Y.Bytes())
+	showDSAParameters(p, &privateKey.Parameters)
+}
+
+func loadDSAKey(filename string, form string) (*dsa.PrivateKey, *dsa.PublicKey, error) {
+	container, err := clicontext.LoadContainer(filename, form)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := container
+	for c != nil {
+		switch c.KeyType() {
+		case encoder.KeyTypeDSAPrivateKey:
+			key := c.DSAPrivateKey()
+			return key, &key.PublicKey, nil
+
+		case encoder.KeyTypeDSAPublicKey:
+			return nil, c.DSAPublicKey(), nil
+		}
+
+		c = c.Next()
+	}
+
+	return nil, nil, clicontext.InputFormatErrorf("No DSA key found")
+}
+
+func dsaCommandShow(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	privateKey, publicKey, err := loadDSAKey(cio.InFilename, cio.InForm)
+	if err != nil {
+		return err
+	}
+
+	showPublic := ctx.Bool("public") || privateKey == nil
+	if !cio.IsDocument() {
+		if showPublic {
+			der, err := encoder.MarshalPKIXDSAPublicKey(publicKey)
+			if err != nil {
+				return err
+			}
+
+			return cio.WriteObject("PUBLIC KEY", der, false)
+		}
+
+		der, err := encoder.MarshalPKCS8DSAPrivateKey(privateKey)
+		if err != nil {
+			return err
+		}
+
+		return cio.WriteObject("PRIVATE KEY", der, true)
+	}
+
+	// Text of private keys is written in a file only accessible by owner.
+	return cio.Render(!showPublic, func(w io.Writer) error {
+		p := prettyprint.NewPrinter(w)
+		if showPublic {
+			showDSAPublicKey(p, publicKey)
+		} else {
+			showDSAPrivateKey(p, privateKey)
+		}
+
+		return p.Err()
+	})
+}
+
+func dsaCommandConvert(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	privateKey, publicKey, err := loadDSAKey(cio.InFilename, cio.InForm)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("pubout") || privateKey == nil {
+		der, err := encoder.MarshalPKIXDSAPublicKey(publicKey)
+		if err != nil {
+			return err
+		}
+
+		return cio.WriteObject("PUBLIC KEY", der, false)
+	}
+
+	key := &cipher.DSAPrivateKey{PrivateKey: *privateKey}
+	if ctx.String("format") == "traditional" {
+		return cio.WriteObject("DSA PRIVATE KEY", key.TraditionalPrivateKey(), true)
+	}
+
+	return cio.WriteObject("PRIVATE KEY", key.PKCS8PrivateKey(), true)
+}
+
+var DSACommand = &clicontext.Command{
+	Name:  "dsa",
+	Short: "DSA key utilities",
+	Subcommands: []*clicontext.Command{
+		{
+			Name:  "show",
+			Short: "Show components of a DSA key",
+			Flags: func(set *flag.FlagSet) {
+				clicontext.InputFlags(set)
+				clicontext.OutputFlags(set, clicontext.FormatText,
+					clicontext.FormatText, clicontext.FormatJSON, clicontext.FormatPEM, clicontext.FormatDER)
+				set.Bool("public", false, "Show public key")
+			},
+			Run: dsaCommandShow,
+		},
+		{
+			Name:  "convert",
+			Short: "Convert a DSA key to PKCS#8, traditional or SPKI format",
+			Flags: func(set *flag.FlagSet) {
+				clicontext.InputFlags(set)
+				clicontext.OutputFlags(set, clicontext.FormatPEM,
+					clicontext.FormatPEM, clicontext.FormatDER)
+				set.Bool("pubout", false, "Output the public key in SPKI format")
+				clicontext.Choice(set, "format", "pkcs8", "Private key output format",
+					"pkcs8", "traditional")
+			},
+			Run: dsaCommandConvert,
+		},
+	},
+}
@@ -0,0 +1,123 @@
+package cipher
+
+import (
+	"crypto/dsa" //nolint:all
+	"crypto/rand"
+	"flag"
+	"io"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/encoder"
+	"github.com/flily/go-ssl/common/prettyprint"
+	"github.com/flily/go-ssl/modules/cipher"
+)
+
+func loadDSAParameters(filename string, form string) (*dsa.Parameters, error) {
+	container, err := clicontext.LoadContainer(filename, form)
+	if err != nil {
+		return nil, err
+	}
+
+	c := container
+	for c != nil {
+		switch c.KeyType() {
+		case encoder.KeyTypeDSAParameters:
+			return c.DSAParameters(), nil
+
+		case encoder.KeyTypeDSAPrivateKey:
+			return &c.DSAPrivateKey().Parameters, nil
+
+		case encoder.KeyTypeDSAPublicKey:
+			return &c.DSAPublicKey().Parameters, nil
+		}
+
+		c = c.Next()
+	}
+
+	return nil, clicontext.InputFormatErrorf("No DSA parameters found")
+}
+
+var DSAParamCommand = &clicontext.Command{
+	Name:  "dsaparam",
+	Short: "Show, check and generate DSA parameters",
+	Flags: func(set *flag.FlagSet) {
+		clicontext.InputFlags(set)
+		clicontext.OutputFlags(set, clicontext.FormatPEM, clicontext.FormatPEM, clicontext.FormatDER)
+		set.Int("bits", 0, "Generate parameters of the size, one of 1024, 2048 and 3072")
+		set.Bool("text", false, "Print the parameters in text form")
+		set.Bool("check", false, "Validate the parameters")
+		set.Bool("noout", false, "Do not output the encoded parameters")
+		set.Bool("genkey", false, "Generate a DSA key with the parameters")
+	},
+	FlagValues: map[string][]string{
+		"bits": {"1024", "2048", "3072"},
+	},
+	Run: dsaParamCommand,
+}
+
+func dsaParamCommand(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	var params *dsa.Parameters
+	var err error
+	if bits := ctx.Int("bits"); bits > 0 {
+		params, err = cipher.GenerateDSAParameters(rand.Reader, bits)
+	} else {
+		params, err = loadDSAParameters(cio.InFilename, cio.InForm)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	der, err := encoder.MarshalDSAParameters(params)
+	if err != nil {
+		return err
+	}
+
+	var key *cipher.DSAPrivateKey
+	if ctx.Bool("genkey") {
+		key, err = cipher.GenerateDSAKey(rand.Reader, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cio.Render(key != nil, func(w io.Writer) error {
+		p := prettyprint.NewPrinter(w)
+		if ctx.Bool("text") {
+			p.Printf("DSA-Parameters: (%d bit)\n", params.P.BitLen())
+			showDSAParameters(p, params)
+		}
+
+		if ctx.Bool("check") {
+			err := encoder.CheckDSAParameters(params)
+			if err != nil {
+				p.Printf("checking DSA parameters: failed\n")
+				return clicontext.NewError(clicontext.ErrorKindVerification, err)
+			}
+
+			p.Printf("checking DSA parameters: ok\n")
+		}
+
+		if err := p.Err(); err != nil {
+			return err
+		}
+
+		objects := make([][]byte, 0, 2)
+		if !ctx.Bool("noout") {
+			objects = append(objects, clicontext.EncodeObject(cio.OutForm, "DSA PARAMETERS", der))
+		}
+
+		if key != nil {
+			objects = append(objects, clicontext.EncodeObject(cio.OutForm, "PRIVATE KEY", key.PKCS8PrivateKey()))
+		}
+
+		for _, object := range objects {
+			if _, err := w.Write(object); err != nil {
+				return clicontext.NewError(clicontext.ErrorKindIO, err)
+			}
+		}
+
+		return nil
+	})
+}
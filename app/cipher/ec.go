@@ -2,43 +2,82 @@ package cipher
 
 import (
 	"crypto/ecdsa"
+	"crypto/x509"
 	"flag"
-	"fmt"
+	"io"
 
 	"github.com/flily/go-ssl/common/clicontext"
 	"github.com/flily/go-ssl/common/encoder"
 	"github.com/flily/go-ssl/common/prettyprint"
 )
 
-func showECPublicKeyXY(publicKey *ecdsa.PublicKey, showQ bool, showQCompress bool) {
-	if showQ {
-		if showQCompress {
-			prettyprint.PrintBinaries("Q (Public, Compressed)",
-				[]byte{0x02}, publicKey.X.Bytes())
-		} else {
-			prettyprint.PrintBinaries("Q (Public, Uncompressed)",
-				[]byte{0x04}, publicKey.X.Bytes(), publicKey.Y.Bytes())
-		}
-	} else {
-		prettyprint.PrintBinary("X (Public)", publicKey.X.Bytes())
-		prettyprint.PrintBinary("Y (Public)", publicKey.Y.Bytes())
-	}
+// ecKeyDocument is the EC key shown by ec show. Coordinates and private value are padded
+// to size of the curve. ShowQ, ShowQCompress and Integers only affect text output.
+type ecKeyDocument struct {
+	Type          string          `json:"type"`
+	Curve         string          `json:"curve"`
+	Private       prettyprint.Hex `json:"private,omitempty"`
+	X             prettyprint.Hex `json:"x"`
+	Y             prettyprint.Hex `json:"y"`
+	Q             prettyprint.Hex `json:"q"`
+	QCompressed   prettyprint.Hex `json:"q_compressed"`
+	ShowQ         bool            `json:"-"`
+	ShowQCompress bool            `json:"-"`
+
+	Integers prettyprint.IntegerFormat `json:"-"`
 }
 
-func showECPrivateKey(privateKey *ecdsa.PrivateKey, showQ bool, showQCompress bool) {
-	fmt.Printf("curve: %s\n", privateKey.Curve.Params().Name)
-	prettyprint.PrintBinary("D (Private)", privateKey.D.Bytes())
+func newECKeyDocument(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey, showPublic bool) *ecKeyDocument {
+	params := publicKey.Curve.Params()
+	size := (params.BitSize + 7) / 8
+	x := publicKey.X.FillBytes(make([]byte, size))
+	y := publicKey.Y.FillBytes(make([]byte, size))
+
+	prefix := byte(0x02)
+	if publicKey.Y.Bit(0) == 1 {
+		prefix = 0x03
+	}
+
+	doc := &ecKeyDocument{
+		Type:        "ec-public-key",
+		Curve:       params.Name,
+		X:           x,
+		Y:           y,
+		Q:           append(append([]byte{0x04}, x...), y...),
+		QCompressed: append([]byte{prefix}, x...),
+	}
+
+	if !showPublic {
+		doc.Type = "ec-private-key"
+		doc.Private = privateKey.D.FillBytes(make([]byte, size))
+	}
 
-	showECPublicKeyXY(&privateKey.PublicKey, showQ, showQCompress)
+	return doc
 }
 
-func showECPublicKey(publicKey *ecdsa.PublicKey, showQ bool, showQCompress bool) {
-	fmt.Printf("curve: %s\n", publicKey.Curve.Params().Name)
-	showECPublicKeyXY(publicKey, showQ, showQCompress)
+func (d *ecKeyDocument) WriteText(w io.Writer) error {
+	p := newIntegerPrinter(w, d.Integers)
+	p.Printf("curve: %s\n", d.Curve)
+	if d.Private != nil {
+		p.Integer("D (Private)", d.Private)
+	}
+
+	if d.ShowQ {
+		if d.ShowQCompress {
+			p.Binary("Q (Public, Compressed)", d.QCompressed)
+		} else {
+			p.Binary("Q (Public, Uncompressed)", d.Q)
+		}
+	} else {
+		p.Integer("X (Public)", d.X)
+		p.Integer("Y (Public)", d.Y)
+	}
+
+	return p.Err()
 }
 
-func loadECKey(filename string) (*ecdsa.PrivateKey, *ecdsa.PublicKey, error) {
-	container, err := encoder.ParseContainerChainFromFile(filename)
+func loadECKey(filename string, form string) (*ecdsa.PrivateKey, *ecdsa.PublicKey, error) {
+	container, err := clicontext.LoadContainer(filename, form)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -57,38 +96,63 @@ func loadECKey(filename string) (*ecdsa.PrivateKey, *ecdsa.PublicKey, error) {
 		c = c.Next()
 	}
 
-	return nil, nil, fmt.Errorf("No EC key found")
+	return nil, nil, clicontext.InputFormatErrorf("No EC key found")
 }
 
 func ecCommandShow(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("ec show", flag.ExitOnError)
-	inFile := set.String("in", "-", "Input file")
-	showPublic := set.Bool("public", false, "Show public key")
-	showQ := set.Bool("q", false, "Show public key in Q (x || y) format")
-	showQCompress := set.Bool("qcompress", false, "Show public key in Q compressed format")
-	err := ctx.Parse(set)
+	cio := ctx.IO()
+	privateKey, publicKey, err := loadECKey(cio.InFilename, cio.InForm)
 	if err != nil {
 		return err
 	}
 
-	privateKey, publicKey, err := loadECKey(*inFile)
-	if err != nil {
-		return err
-	}
+	showPublic := ctx.Bool("public") || privateKey == nil
+	if !cio.IsDocument() {
+		if showPublic {
+			der, err := x509.MarshalPKIXPublicKey(publicKey)
+			if err != nil {
+				return err
+			}
 
-	if *showPublic || privateKey == nil {
-		showECPublicKey(publicKey, *showQ, *showQCompress)
-	} else {
-		showECPrivateKey(privateKey, *showQ, *showQCompress)
+			return cio.WriteObject("PUBLIC KEY", der, false)
+		}
+
+		der, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return err
+		}
+
+		return cio.WriteObject("EC PRIVATE KEY", der, true)
 	}
 
-	return nil
-}
+	doc := newECKeyDocument(privateKey, publicKey, showPublic)
+	doc.ShowQ = ctx.Bool("q")
+	doc.ShowQCompress = ctx.Bool("qcompress")
+	doc.Integers = prettyprint.IntegerFormat(ctx.String("numform"))
+	if showPublic {
+		return cio.RenderDocument(doc)
+	}
 
-var ecCommands = map[string]clicontext.CommandEntryFunc{
-	"show": ecCommandShow,
+	return cio.RenderPrivateDocument(doc)
 }
 
-func MainEC(ctx *clicontext.CommandContext) error {
-	return ctx.Invoke(ecCommands)
+var ECCommand = &clicontext.Command{
+	Name:  "ec",
+	Short: "EC key utilities",
+	Subcommands: []*clicontext.Command{
+		{
+			Name:  "show",
+			Short: "Show components of an EC key",
+			Flags: func(set *flag.FlagSet) {
+				clicontext.InputFlags(set)
+				clicontext.OutputFlags(set, clicontext.FormatText,
+					clicontext.FormatText, clicontext.FormatJSON, clicontext.FormatPEM, clicontext.FormatDER)
+				set.Bool("public", false, "Show public key")
+				set.Bool("q", false, "Show public key in Q (x || y) format")
+				set.Bool("qcompress", false, "Show public key in Q compressed format")
+				numformFlag(set)
+			},
+			Run: ecCommandShow,
+		},
+	},
 }
@@ -0,0 +1,157 @@
+package cipher
+
+import (
+	"flag"
+	"io"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/encoder"
+	"github.com/flily/go-ssl/common/prettyprint"
+)
+
+func showECParameters(p *prettyprint.Printer, params *encoder.ECParameters) {
+	if params.BitSize() > 0 {
+		p.Printf("EC-Parameters: (%d bit)\n", params.BitSize())
+	} else {
+		p.Printf("EC-Parameters:\n")
+	}
+
+	if params.Named {
+		p.Printf("ASN1 OID: %s\n", params.Name)
+		if curve := params.EllipticCurve(); curve != nil {
+			p.Printf("NIST CURVE: %s\n", curve.Params().Name)
+		}
+
+		return
+	}
+
+	p.Printf("Field Type: prime-field\n")
+	p.Integer("Prime", params.P.Bytes())
+	p.Integer("A", params.A.Bytes())
+	p.Integer("B", params.B.Bytes())
+	p.Binaries("Generator (uncompressed)",
+		[]byte{0x04}, params.Gx.FillBytes(make([]byte, (params.BitSize()+7)/8)),
+		params.Gy.FillBytes(make([]byte, (params.BitSize()+7)/8)))
+	p.Integer("Order", params.N.Bytes())
+	if params.H != nil {
+		p.Printf("Cofactor: %d (0x%x)\n", params.H, params.H)
+	}
+
+	if len(params.Seed) > 0 {
+		p.Binary("Seed", params.Seed)
+	}
+
+	if params.Curve != nil {
+		p.Printf("Matched curve: %s\n", params.Curve.Name)
+	} else {
+		p.Printf("Matched curve: none\n")
+	}
+}
+
+func loadECParameters(filename string, form string) (*encoder.ECParameters, []byte, error) {
+	container, err := clicontext.LoadContainer(filename, form)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := container
+	for c != nil {
+		if c.KeyType() == encoder.KeyTypeECParameters {
+			return c.ECParameters(), c.Binary(), nil
+		}
+
+		c = c.Next()
+	}
+
+	return nil, nil, clicontext.InputFormatErrorf("No EC parameters found")
+}
+
+func listECCurves(p *prettyprint.Printer) {
+	for _, curve := range encoder.NamedCurves() {
+		p.Printf("  %-12s: %d bit prime field, OID %s\n",
+			curve.Name, curve.P.BitLen(), curve.OID)
+	}
+}
+
+var ECParamCommand = &clicontext.Command{
+	Name:  "ecparam",
+	Short: "Show, check and create EC parameters",
+	Flags: func(set *flag.FlagSet) {
+		clicontext.InputFlags(set)
+		clicontext.OutputFlags(set, clicontext.FormatPEM, clicontext.FormatPEM, clicontext.FormatDER)
+		set.String("name", "", "Use the named curve instead of reading -in")
+		set.Bool("text", false, "Print the parameters in text form")
+		set.Bool("check", false, "Validate the parameters")
+		set.Bool("noout", false, "Do not output the encoded parameters")
+		set.Bool("list_curves", false, "List all known named curves")
+	},
+	FlagValues: map[string][]string{
+		"name": encoder.NamedCurveNames(),
+	},
+	Run: ecParamCommand,
+}
+
+func ecParamCommand(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	curveName := ctx.String("name")
+	if ctx.Bool("list_curves") {
+		return cio.Render(false, func(w io.Writer) error {
+			p := prettyprint.NewPrinter(w)
+			listECCurves(p)
+			return p.Err()
+		})
+	}
+
+	var params *encoder.ECParameters
+	var der []byte
+	var err error
+	if len(curveName) > 0 {
+		curve := encoder.LookupNamedCurve(curveName)
+		if curve == nil {
+			return clicontext.UsageErrorf("Unknown curve name: %s", curveName)
+		}
+
+		der, err = curve.Marshal()
+		if err != nil {
+			return err
+		}
+
+		params, err = encoder.ParseECParameters(der)
+
+	} else {
+		params, der, err = loadECParameters(cio.InFilename, cio.InForm)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return cio.Render(false, func(w io.Writer) error {
+		p := prettyprint.NewPrinter(w)
+		if ctx.Bool("text") {
+			showECParameters(p, params)
+		}
+
+		if ctx.Bool("check") {
+			err := params.Check()
+			if err != nil {
+				p.Printf("checking elliptic curve parameters: failed\n")
+				return clicontext.NewError(clicontext.ErrorKindVerification, err)
+			}
+
+			p.Printf("checking elliptic curve parameters: ok\n")
+		}
+
+		if err := p.Err(); err != nil {
+			return err
+		}
+
+		if !ctx.Bool("noout") {
+			if _, err := w.Write(clicontext.EncodeObject(cio.OutForm, "EC PARAMETERS", der)); err != nil {
+				return clicontext.NewError(clicontext.ErrorKindIO, err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -2,41 +2,105 @@ package cipher
 
 import (
 	"crypto/rsa"
+	"crypto/x509"
 	"flag"
 	"fmt"
+	"io"
 
 	"github.com/flily/go-ssl/common/clicontext"
 	"github.com/flily/go-ssl/common/encoder"
 	"github.com/flily/go-ssl/common/prettyprint"
 )
 
-func showRSAPublicKeyEN(publicKey *rsa.PublicKey) {
-	fmt.Printf("E (public exponent): %d (0x%x)\n", publicKey.E, publicKey.E)
-	prettyprint.PPrintBinary("N (modulus)", publicKey.N.Bytes())
+// numformFlag defines -numform, format of integers in text output.
+func numformFlag(set *flag.FlagSet) {
+	clicontext.Choice(set, "numform", string(prettyprint.IntegerHexColon), "Format of integers in text output",
+		prettyprint.IntegerFormats()...)
 }
 
-func showRSAPrivateKey(privateKey *rsa.PrivateKey) {
-	d := privateKey.D.Bytes()
-	prettyprint.PPrintBinary("D (private exponent)", d)
+func newIntegerPrinter(w io.Writer, format prettyprint.IntegerFormat) *prettyprint.Printer {
+	layout := prettyprint.DefaultLayout()
+	if len(format) > 0 {
+		layout.Integers = format
+	}
 
-	showRSAPublicKeyEN(&privateKey.PublicKey)
+	return prettyprint.NewPrinterWithLayout(w, layout)
+}
 
-	for i, prime := range privateKey.Primes {
-		title := fmt.Sprintf("Prime %d (private)", i+1)
-		prettyprint.PPrintBinary(title, prime.Bytes())
+// rsaKeyDocument is the RSA key shown by rsa show, private fields are omitted for public
+// keys.
+type rsaKeyDocument struct {
+	Type            string            `json:"type"`
+	PrivateKeyFound bool              `json:"private_key_found"`
+	Bits            int               `json:"bits"`
+	PublicExponent  int               `json:"public_exponent"`
+	Modulus         prettyprint.Hex   `json:"modulus"`
+	PrivateExponent prettyprint.Hex   `json:"private_exponent,omitempty"`
+	Primes          []prettyprint.Hex `json:"primes,omitempty"`
+	Dp              prettyprint.Hex   `json:"dp,omitempty"`
+	Dq              prettyprint.Hex   `json:"dq,omitempty"`
+	Qinv            prettyprint.Hex   `json:"qinv,omitempty"`
+
+	Integers prettyprint.IntegerFormat `json:"-"`
+}
+
+func newRSAKeyDocument(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey, showPublic bool) *rsaKeyDocument {
+	doc := &rsaKeyDocument{
+		Type:            "rsa-public-key",
+		PrivateKeyFound: privateKey != nil,
+		Bits:            publicKey.N.BitLen(),
+		PublicExponent:  publicKey.E,
+		Modulus:         publicKey.N.Bytes(),
+	}
+
+	if showPublic {
+		return doc
+	}
+
+	doc.Type = "rsa-private-key"
+	doc.PrivateExponent = privateKey.D.Bytes()
+	for _, prime := range privateKey.Primes {
+		doc.Primes = append(doc.Primes, prime.Bytes())
 	}
 
-	prettyprint.PPrintBinary("Dp (private D mod P-1)", privateKey.Precomputed.Dp.Bytes())
-	prettyprint.PPrintBinary("Dq (private D mod Q-1)", privateKey.Precomputed.Dq.Bytes())
-	prettyprint.PPrintBinary("QInv (private Q^-1 mod P)", privateKey.Precomputed.Qinv.Bytes())
+	doc.Dp = privateKey.Precomputed.Dp.Bytes()
+	doc.Dq = privateKey.Precomputed.Dq.Bytes()
+	doc.Qinv = privateKey.Precomputed.Qinv.Bytes()
+	return doc
 }
 
-func showRSAPublicKey(publicKey *rsa.PublicKey) {
-	showRSAPublicKeyEN(publicKey)
+func (d *rsaKeyDocument) writePublicKey(p *prettyprint.Printer) {
+	p.Printf("E (public exponent): %d (0x%x)\n", d.PublicExponent, d.PublicExponent)
+	p.Integer("N (modulus)", d.Modulus)
+}
+
+func (d *rsaKeyDocument) WriteText(w io.Writer) error {
+	p := newIntegerPrinter(w, d.Integers)
+	if d.PrivateExponent == nil {
+		if d.PrivateKeyFound {
+			p.Printf("RSA Private key found.\n")
+		}
+
+		d.writePublicKey(p)
+		return nil
+	}
+
+	p.Integer("D (private exponent)", d.PrivateExponent)
+	d.writePublicKey(p)
+
+	for i, prime := range d.Primes {
+		title := fmt.Sprintf("Prime %d (private)", i+1)
+		p.Integer(title, prime)
+	}
+
+	p.Integer("Dp (private D mod P-1)", d.Dp)
+	p.Integer("Dq (private D mod Q-1)", d.Dq)
+	p.Integer("QInv (private Q^-1 mod P)", d.Qinv)
+	return p.Err()
 }
 
-func loadRSAKey(filename string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
-	container, err := encoder.ParseContainerChainFromFile(filename)
+func loadRSAKey(filename string, form string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	container, err := clicontext.LoadContainer(filename, form)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -55,42 +119,91 @@ func loadRSAKey(filename string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
 		c = c.Next()
 	}
 
-	return nil, nil, fmt.Errorf("No RSA key found")
+	return nil, nil, clicontext.InputFormatErrorf("No RSA key found")
 }
 
 func rsaCommandShow(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("rsa show", flag.ExitOnError)
-	inFile := set.String("in", "-", "Input file")
-	showPublic := set.Bool("public", false, "Show public key")
-	_ = ctx.Parse(set)
-
-	privateKey, publicKey, err := loadRSAKey(*inFile)
+	cio := ctx.IO()
+	privateKey, publicKey, err := loadRSAKey(cio.InFilename, cio.InForm)
 	if err != nil {
 		return err
 	}
 
-	if privateKey != nil {
-		if *showPublic {
-			fmt.Printf("RSA Private key found.\n")
-			showRSAPublicKey(publicKey)
+	showPublic := ctx.Bool("public") || privateKey == nil
+	if !cio.IsDocument() {
+		if showPublic {
+			der, err := x509.MarshalPKIXPublicKey(publicKey)
+			if err != nil {
+				return err
+			}
+
+			return cio.WriteObject("PUBLIC KEY", der, false)
+		}
 
-		} else {
-			showRSAPrivateKey(privateKey)
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return err
 		}
-	} else if publicKey != nil {
-		showRSAPublicKey(publicKey)
 
-	} else {
-		return fmt.Errorf("No RSA key found")
+		return cio.WriteObject("PRIVATE KEY", der, true)
 	}
 
-	return nil
-}
+	doc := newRSAKeyDocument(privateKey, publicKey, showPublic)
+	doc.Integers = prettyprint.IntegerFormat(ctx.String("numform"))
+	if showPublic {
+		return cio.RenderDocument(doc)
+	}
 
-var rsaCommands = map[string]clicontext.CommandEntryFunc{
-	"show": rsaCommandShow,
+	return cio.RenderPrivateDocument(doc)
 }
 
-func MainRSA(ctx *clicontext.CommandContext) error {
-	return ctx.Invoke(rsaCommands)
+var RSACommand = &clicontext.Command{
+	Name:  "rsa",
+	Short: "RSA key utilities",
+	Subcommands: []*clicontext.Command{
+		{
+			Name:  "show",
+			Short: "Show components of a RSA key",
+			Flags: func(set *flag.FlagSet) {
+				clicontext.InputFlags(set)
+				clicontext.OutputFlags(set, clicontext.FormatText,
+					clicontext.FormatText, clicontext.FormatJSON, clicontext.FormatPEM, clicontext.FormatDER)
+				set.Bool("public", false, "Show public key")
+				numformFlag(set)
+			},
+			Run: rsaCommandShow,
+		},
+		{
+			Name:  "check",
+			Short: "Check consistency and weakness of a RSA key",
+			Long:  rsaCheckLong,
+			Flags: rsaCheckFlags,
+			Run:   rsaCommandCheck,
+		},
+		{
+			Name:  "convert",
+			Short: "Convert a RSA key to PKCS#1, PKCS#8 or SPKI format",
+			Flags: rsaConvertFlags,
+			Run:   rsaCommandConvert,
+		},
+		{
+			Name:  "encrypt",
+			Short: "Encrypt data with a RSA public key",
+			Long: "Encrypt data with a RSA public key, or public part of a private key, e.g.\n\n" +
+				"  gossl rsa encrypt -inkey pub.pem -mode hybrid -in backup.tar -out backup.enc\n\n" +
+				rsaCryptLong,
+			Flags: rsaCryptFlags,
+			Run:   rsaCommandEncrypt,
+		},
+		{
+			Name:  "decrypt",
+			Short: "Decrypt data with a RSA private key",
+			Long: "Decrypt data encrypted by 'gossl rsa encrypt' or openssl pkeyutl, with the same\n" +
+				"mode and options, e.g.\n\n" +
+				"  gossl rsa decrypt -inkey key.pem -mode hybrid -in backup.enc -out backup.tar\n\n" +
+				rsaCryptLong,
+			Flags: rsaCryptFlags,
+			Run:   rsaCommandDecrypt,
+		},
+	},
 }
@@ -0,0 +1,219 @@
+package cipher
+
+import (
+	"bufio"
+	"crypto/x509"
+	"flag"
+	"io"
+	"strings"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/encoder"
+	"github.com/flily/go-ssl/common/prettyprint"
+	"github.com/flily/go-ssl/modules/cipher"
+)
+
+type rsaCheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// rsaCheckDocument is result of rsa check, checks are in fixed order.
+type rsaCheckDocument struct {
+	Bits       int              `json:"bits"`
+	PrivateKey bool             `json:"private_key"`
+	Checks     []rsaCheckResult `json:"checks"`
+}
+
+// count returns number of checks in status.
+func (d *rsaCheckDocument) count(status string) int {
+	n := 0
+	for _, check := range d.Checks {
+		if check.Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (d *rsaCheckDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinter(w)
+	kind := "public key"
+	if d.PrivateKey {
+		kind = "private key"
+	}
+
+	p.Printf("RSA %s: (%d bit)\n", kind, d.Bits)
+	for _, check := range d.Checks {
+		if len(check.Detail) > 0 {
+			p.Printf("  %s: %s, %s\n", check.Name, check.Status, check.Detail)
+		} else {
+			p.Printf("  %s: %s\n", check.Name, check.Status)
+		}
+	}
+
+	switch {
+	case d.count(encoder.RSACheckFailed) > 0:
+		p.Printf("RSA key error\n")
+
+	case d.count(encoder.RSACheckWeak) > 0:
+		p.Printf("RSA key is weak\n")
+
+	default:
+		p.Printf("RSA key ok\n")
+	}
+
+	return p.Err()
+}
+
+// loadDebianBlacklist loads fingerprints of openssl-vulnkey blacklists, lines starting with
+// # are comments.
+func loadDebianBlacklist(filenames []string) (map[string]bool, error) {
+	if len(filenames) <= 0 {
+		return nil, nil
+	}
+
+	blacklist := make(map[string]bool)
+	for _, filename := range filenames {
+		data, err := clicontext.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if len(line) > 0 && !strings.HasPrefix(line, "#") {
+				blacklist[line] = true
+			}
+		}
+	}
+
+	return blacklist, nil
+}
+
+// loadRSAKeyComponents loads the first RSA key of file without validation.
+func loadRSAKeyComponents(filename string, form string) (*encoder.RSAKeyComponents, error) {
+	data, err := clicontext.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if form != clicontext.FormatDER {
+		var der []byte
+		der, _ = encoder.PEMTryDecode(data)
+		if form == clicontext.FormatPEM && len(der) == len(data) {
+			return nil, clicontext.InputFormatErrorf("%s: no PEM block found", filename)
+		}
+
+		data = der
+	}
+
+	key, err := encoder.ParseRSAKeyComponents(data)
+	if err != nil {
+		return nil, clicontext.InputFormatErrorf("%s: %s", filename, err)
+	}
+
+	return key, nil
+}
+
+func rsaCommandCheck(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	key, err := loadRSAKeyComponents(cio.InFilename, cio.InForm)
+	if err != nil {
+		return err
+	}
+
+	blacklist, err := loadDebianBlacklist(ctx.Strings("blacklist"))
+	if err != nil {
+		return err
+	}
+
+	doc := &rsaCheckDocument{
+		Bits:       key.N.BitLen(),
+		PrivateKey: key.IsPrivate(),
+	}
+
+	for _, check := range encoder.CheckRSAKey(key, blacklist) {
+		doc.Checks = append(doc.Checks, rsaCheckResult(check))
+	}
+
+	if err := cio.RenderDocument(doc); err != nil {
+		return err
+	}
+
+	failed, weak := doc.count(encoder.RSACheckFailed), doc.count(encoder.RSACheckWeak)
+	if failed > 0 || weak > 0 {
+		return clicontext.VerificationErrorf("%d checks failed, %d weaknesses found", failed, weak)
+	}
+
+	return nil
+}
+
+func rsaCommandConvert(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	privateKey, publicKey, err := loadRSAKey(cio.InFilename, cio.InForm)
+	if err != nil {
+		return err
+	}
+
+	format := ctx.String("format")
+	if ctx.Bool("pubout") || privateKey == nil {
+		switch format {
+		case "pkcs1":
+			return cio.WriteObject("RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(publicKey), false)
+
+		case "", "spki":
+			der, err := x509.MarshalPKIXPublicKey(publicKey)
+			if err != nil {
+				return err
+			}
+
+			return cio.WriteObject("PUBLIC KEY", der, false)
+		}
+
+		return clicontext.UsageErrorf("public keys are written in pkcs1 or spki format, got %s", format)
+	}
+
+	key := &cipher.RSAPrivateKey{PrivateKey: *privateKey}
+	switch format {
+	case "pkcs1":
+		return cio.WriteObject("RSA PRIVATE KEY", key.PKCS1PrivateKey(), true)
+
+	case "", "pkcs8":
+		return cio.WriteObject("PRIVATE KEY", key.PKCS8PrivateKey(), true)
+	}
+
+	return clicontext.UsageErrorf("private keys are written in pkcs1 or pkcs8 format, use -pubout for %s", format)
+}
+
+func rsaCheckFlags(set *flag.FlagSet) {
+	clicontext.InputFlags(set)
+	clicontext.OutputFlags(set, "")
+	clicontext.List(set, "blacklist", "Blacklist of openssl-vulnkey for Debian weak keys, may be given multiple times")
+}
+
+func rsaConvertFlags(set *flag.FlagSet) {
+	clicontext.InputFlags(set)
+	clicontext.OutputFlags(set, clicontext.FormatPEM, clicontext.FormatPEM, clicontext.FormatDER)
+	set.Bool("pubout", false, "Output the public key")
+	clicontext.Choice(set, "format", "", "Key format, pkcs8 or spki by default",
+		"pkcs1", "pkcs8", "spki")
+}
+
+const rsaCheckLong = "Check consistency and known weaknesses of a RSA key, without rejecting the key on\n" +
+	"loading. Checks of public keys are:\n" +
+	"  modulus          modulus is odd, weak if less than 2048 bits\n" +
+	"  public exponent  exponent is odd and at least 3, weak if less than 65537\n" +
+	"  ROCA             modulus has fingerprint of Infineon keys of CVE-2017-15361\n" +
+	"  Debian weak key  fingerprint is in blacklists given by -blacklist, e.g.\n" +
+	"                   /usr/share/openssl-blacklist/blacklist.RSA-2048, CVE-2008-0166\n\n" +
+	"Checks of private keys are also:\n" +
+	"  primes                 all primes are probably prime\n" +
+	"  modulus factorization  product of primes is modulus\n" +
+	"  private exponent       d * e = 1 mod lambda(n)\n" +
+	"  CRT exponents          exponents are d mod (p - 1) of primes\n" +
+	"  CRT coefficients       coefficients are inverse of primes as in PKCS#1\n\n" +
+	"Exit status is 5 if any check fails or any weakness is found."
@@ -0,0 +1,202 @@
+package cipher
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/modules/cipher"
+)
+
+// Modes of RSA encryption.
+const (
+	rsaModeOAEP   = "oaep"
+	rsaModePKCS1  = "pkcs1"
+	rsaModeRaw    = "raw"
+	rsaModeHybrid = "hybrid"
+)
+
+// oaepHashes are hashes of OAEP, also used by MGF1.
+var oaepHashes = map[string]func() hash.Hash{
+	"sha1":     sha1.New,
+	"sha224":   sha256.New224,
+	"sha256":   sha256.New,
+	"sha384":   sha512.New384,
+	"sha512":   sha512.New,
+	"sha3-224": sha3.New224,
+	"sha3-256": sha3.New256,
+	"sha3-384": sha3.New384,
+	"sha3-512": sha3.New512,
+}
+
+func oaepHashNames() []string {
+	return []string{"sha1", "sha224", "sha256", "sha384", "sha512", "sha3-224", "sha3-256", "sha3-384", "sha3-512"}
+}
+
+// rsaCryptOptions are options of rsa encrypt and decrypt.
+type rsaCryptOptions struct {
+	Mode  string
+	Hash  func() hash.Hash
+	Label []byte
+}
+
+func newRSACryptOptions(ctx *clicontext.CommandContext) (*rsaCryptOptions, error) {
+	if len(ctx.String("inkey")) <= 0 {
+		return nil, clicontext.UsageErrorf("-inkey is required")
+	}
+
+	opts := &rsaCryptOptions{
+		Mode: ctx.String("mode"),
+		Hash: oaepHashes[ctx.String("hash")],
+	}
+
+	if ctx.IsSet("label") && ctx.IsSet("hexlabel") {
+		return nil, clicontext.UsageErrorf("-label and -hexlabel can not be used together")
+	}
+
+	if ctx.IsSet("hexlabel") {
+		label, err := hex.DecodeString(ctx.String("hexlabel"))
+		if err != nil {
+			return nil, clicontext.UsageErrorf("invalid -hexlabel: %s", err)
+		}
+
+		opts.Label = label
+	} else {
+		opts.Label = []byte(ctx.String("label"))
+	}
+
+	usesOAEP := opts.Mode == rsaModeOAEP || opts.Mode == rsaModeHybrid
+	if !usesOAEP && (ctx.IsSet("hash") || len(opts.Label) > 0) {
+		return nil, clicontext.UsageErrorf("-hash, -label and -hexlabel can only be used with -mode oaep or hybrid")
+	}
+
+	return opts, nil
+}
+
+func rsaCommandEncrypt(ctx *clicontext.CommandContext) error {
+	opts, err := newRSACryptOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, publicKey, err := loadRSAKey(ctx.String("inkey"), ctx.String("keyform"))
+	if err != nil {
+		return err
+	}
+
+	cio := ctx.IO()
+	data, err := cio.ReadInput()
+	if err != nil {
+		return err
+	}
+
+	var encrypted []byte
+	switch opts.Mode {
+	case rsaModeOAEP:
+		encrypted, err = rsa.EncryptOAEP(opts.Hash(), rand.Reader, publicKey, data, opts.Label)
+
+	case rsaModePKCS1:
+		encrypted, err = rsa.EncryptPKCS1v15(rand.Reader, publicKey, data)
+
+	case rsaModeRaw:
+		if len(data) > publicKey.Size() {
+			err = cipher.ErrMessageTooLong
+		} else {
+			encrypted, err = cipher.RSAEncryptRaw(publicKey, data)
+		}
+
+	case rsaModeHybrid:
+		encrypted, err = cipher.EncryptHybrid(rand.Reader, publicKey, opts.Hash, opts.Label, data)
+	}
+
+	if errors.Is(err, rsa.ErrMessageTooLong) || errors.Is(err, cipher.ErrMessageTooLong) {
+		return clicontext.InputFormatErrorf("%d bytes of data is too long for %d bits key in mode %s, use -mode hybrid",
+			len(data), publicKey.N.BitLen(), opts.Mode)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return cio.WriteOutput(encrypted, false)
+}
+
+func rsaCommandDecrypt(ctx *clicontext.CommandContext) error {
+	opts, err := newRSACryptOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	privateKey, _, err := loadRSAKey(ctx.String("inkey"), ctx.String("keyform"))
+	if err != nil {
+		return err
+	}
+
+	if privateKey == nil {
+		return clicontext.InputFormatErrorf("%s: no RSA private key found", ctx.String("inkey"))
+	}
+
+	cio := ctx.IO()
+	data, err := cio.ReadInput()
+	if err != nil {
+		return err
+	}
+
+	if opts.Mode != rsaModeHybrid && len(data) != privateKey.Size() {
+		return clicontext.InputFormatErrorf("size of encrypted data must be %d bytes of key in mode %s, got %d bytes",
+			privateKey.Size(), opts.Mode, len(data))
+	}
+
+	var decrypted []byte
+	switch opts.Mode {
+	case rsaModeOAEP:
+		decrypted, err = rsa.DecryptOAEP(opts.Hash(), rand.Reader, privateKey, data, opts.Label)
+
+	case rsaModePKCS1:
+		decrypted, err = rsa.DecryptPKCS1v15(rand.Reader, privateKey, data)
+
+	case rsaModeRaw:
+		decrypted, err = cipher.RSADecryptRaw(rand.Reader, privateKey, data)
+
+	case rsaModeHybrid:
+		decrypted, err = cipher.DecryptHybrid(rand.Reader, privateKey, opts.Hash, opts.Label, data)
+	}
+
+	if err != nil {
+		return clicontext.VerificationErrorf("decryption error, wrong key, mode or options, or modified data")
+	}
+
+	return cio.WriteOutput(decrypted, true)
+}
+
+func rsaCryptFlags(set *flag.FlagSet) {
+	set.String("in", "-", "Input file, - for stdin")
+	clicontext.OutputFlags(set, "")
+	set.String("inkey", "", "RSA key file")
+	clicontext.Choice(set, "keyform", clicontext.FormatAuto, "Key format",
+		clicontext.FormatAuto, clicontext.FormatPEM, clicontext.FormatDER)
+	clicontext.Choice(set, "mode", rsaModeOAEP, "Encryption mode",
+		rsaModeOAEP, rsaModePKCS1, rsaModeRaw, rsaModeHybrid)
+	clicontext.Choice(set, "hash", "sha256", "Hash of OAEP and MGF1", oaepHashNames()...)
+	set.String("label", "", "Label of OAEP")
+	set.String("hexlabel", "", "Label of OAEP in hex")
+}
+
+const rsaCryptLong = "Modes of encryption are:\n" +
+	"  oaep    RSAES-OAEP, with -hash for both OAEP and MGF1, and -label\n" +
+	"  pkcs1   RSAES-PKCS1-v1_5, for legacy systems only\n" +
+	"  raw     RSA without padding, data is an integer less than modulus, for testing only\n" +
+	"  hybrid  data of any size is encrypted by a random AES-256 key in GCM, and the key is\n" +
+	"          encrypted in OAEP. Output is the encrypted key in size of RSA key, 12 bytes\n" +
+	"          nonce and encrypted data with 16 bytes tag.\n\n" +
+	"Data of other modes must be shorter than the key. Default hash of OAEP is sha256, use\n" +
+	"-hash sha1 for data of openssl pkeyutl with rsa_padding_mode:oaep by default."
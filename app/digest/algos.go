@@ -7,34 +7,178 @@ import (
 	"crypto/sha512"
 	"fmt"
 	"hash"
-	"strings"
+	"io"
+	"sort"
 
-	"golang.org/x/crypto/md4" //nolint:all
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/md4"       //nolint:all
+	"golang.org/x/crypto/ripemd160" //nolint:all
 	"golang.org/x/crypto/sha3"
+
+	"github.com/flily/go-ssl/common/prettyprint"
+	"github.com/flily/go-ssl/modules/blake3"
+	"github.com/flily/go-ssl/modules/sm3"
 )
 
+// unkeyed returns constructor of a keyed hash used without key, which never fails.
+func unkeyed(newHash func(key []byte) (hash.Hash, error)) func() hash.Hash {
+	return func() hash.Hash {
+		h, _ := newHash(nil)
+		return h
+	}
+}
+
 var algorithmMap = map[string]func() hash.Hash{
-	"md4":        md4.New,
-	"md5":        md5.New,
-	"sha1":       sha1.New,
-	"sha224":     sha256.New224,
-	"sha256":     sha256.New,
-	"sha384":     sha512.New384,
-	"sha512":     sha512.New,
-	"sha512-224": sha512.New512_224,
-	"sha512-256": sha512.New512_256,
-	"sha3-224":   sha3.New224,
-	"sha3-256":   sha3.New256,
-	"sha3-384":   sha3.New384,
-	"sha3-512":   sha3.New512,
-}
-
-func getHasher(algo string) (hash.Hash, error) {
-	newFunc, found := algorithmMap[strings.ToLower(algo)]
+	"md4":         md4.New,
+	"md5":         md5.New,
+	"sha1":        sha1.New,
+	"sha224":      sha256.New224,
+	"sha256":      sha256.New,
+	"sha384":      sha512.New384,
+	"sha512":      sha512.New,
+	"sha512-224":  sha512.New512_224,
+	"sha512-256":  sha512.New512_256,
+	"sha3-224":    sha3.New224,
+	"sha3-256":    sha3.New256,
+	"sha3-384":    sha3.New384,
+	"sha3-512":    sha3.New512,
+	"keccak-256":  sha3.NewLegacyKeccak256,
+	"keccak-512":  sha3.NewLegacyKeccak512,
+	"blake2b-256": unkeyed(blake2b.New256),
+	"blake2b-384": unkeyed(blake2b.New384),
+	"blake2b-512": unkeyed(blake2b.New512),
+	"blake2s-256": unkeyed(blake2s.New256),
+	"ripemd160":   ripemd160.New,
+	"sm3":         sm3.New,
+}
+
+// shakeHash is a SHAKE or cSHAKE of fixed size output.
+type shakeHash struct {
+	sha3.ShakeHash
+	size int
+}
+
+func (h *shakeHash) Size() int {
+	return h.size
+}
+
+// Sum reads output from a copy of state, so that more data can be written after Sum.
+func (h *shakeHash) Sum(b []byte) []byte {
+	out := make([]byte, h.size)
+	_, _ = io.ReadFull(h.Clone(), out)
+	return append(b, out...)
+}
+
+func newShake(newShake func(n []byte, s []byte) sha3.ShakeHash) func(int, []byte) hash.Hash {
+	return func(size int, custom []byte) hash.Hash {
+		return &shakeHash{ShakeHash: newShake(nil, custom), size: size}
+	}
+}
+
+// xofAlgorithm is an extendable output function, Size is default size of output in bytes.
+type xofAlgorithm struct {
+	New  func(size int, custom []byte) hash.Hash
+	Size int
+}
+
+// xofMap are extendable output functions, only cSHAKE takes customization string. Default
+// sizes of SHAKE are the same as openssl.
+var xofMap = map[string]xofAlgorithm{
+	"shake128":  {newShake(sha3.NewCShake128), 16},
+	"shake256":  {newShake(sha3.NewCShake256), 32},
+	"cshake128": {newShake(sha3.NewCShake128), 32},
+	"cshake256": {newShake(sha3.NewCShake256), 64},
+	"blake3":    {func(size int, _ []byte) hash.Hash { return blake3.NewSize(size) }, blake3.Size},
+}
+
+func init() {
+	for name, xof := range xofMap {
+		xof := xof
+		algorithmMap[name] = func() hash.Hash { return xof.New(xof.Size, nil) }
+	}
+}
+
+func algorithmNames() []string {
+	names := make([]string, 0, len(algorithmMap))
+	for name := range algorithmMap {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// newHashOf returns constructor of algorithm, with size of output and customization string
+// of extendable output functions. size of 0 is the default size.
+func newHashOf(algorithm string, size int, custom []byte) (func() hash.Hash, error) {
+	xof, found := xofMap[algorithm]
 	if !found {
-		err := fmt.Errorf("no algorithm '%s'", algo)
-		return nil, err
+		if size > 0 {
+			return nil, fmt.Errorf("%s is not an extendable output function, size of output can not be given", algorithm)
+		}
+
+		if len(custom) > 0 {
+			return nil, fmt.Errorf("%s does not take customization string", algorithm)
+		}
+
+		return algorithmMap[algorithm], nil
+	}
+
+	if len(custom) > 0 && algorithm != "cshake128" && algorithm != "cshake256" {
+		return nil, fmt.Errorf("%s does not take customization string", algorithm)
+	}
+
+	if size <= 0 {
+		size = xof.Size
+	}
+
+	return func() hash.Hash { return xof.New(size, custom) }, nil
+}
+
+type algorithmInfo struct {
+	Name      string `json:"name"`
+	Size      int    `json:"size"`
+	BlockSize int    `json:"block_size"`
+	XOF       bool   `json:"xof"`
+}
+
+// algorithmListDocument is supported algorithms, size is the default size of output for
+// extendable output functions.
+type algorithmListDocument struct {
+	Algorithms []algorithmInfo `json:"algorithms"`
+}
+
+func newAlgorithmListDocument() *algorithmListDocument {
+	doc := &algorithmListDocument{
+		Algorithms: make([]algorithmInfo, 0, len(algorithmMap)),
+	}
+
+	for _, name := range algorithmNames() {
+		h := algorithmMap[name]()
+		_, xof := xofMap[name]
+		doc.Algorithms = append(doc.Algorithms, algorithmInfo{
+			Name:      name,
+			Size:      h.Size(),
+			BlockSize: h.BlockSize(),
+			XOF:       xof,
+		})
+	}
+
+	return doc
+}
+
+func (d *algorithmListDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinter(w)
+	p.Printf("%-12s %5s %6s  %s\n", "ALGORITHM", "SIZE", "BLOCK", "XOF")
+	for _, algo := range d.Algorithms {
+		xof := "no"
+		if algo.XOF {
+			xof = "yes"
+		}
+
+		p.Printf("%-12s %5d %6d  %s\n", algo.Name, algo.Size, algo.BlockSize, xof)
 	}
 
-	return newFunc(), nil
+	return p.Err()
 }
@@ -0,0 +1,314 @@
+package digest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/flily/go-ssl/common/clicontext"
+)
+
+// Results of checking a file.
+const (
+	checkOK      = "OK"
+	checkFailed  = "FAILED"
+	checkMissing = "MISSING"
+)
+
+var (
+	// bsdLine is a checksum line in BSD format, as written by digest and openssl dgst, e.g.
+	// "SHA256(file)= hex", or "SHA256 (file) = hex" by coreutils with --tag.
+	bsdLine = regexp.MustCompile(`^([A-Za-z0-9/-]+) ?\((.*)\) ?= ([0-9A-Fa-f]+)$`)
+
+	// gnuLine is a checksum line in GNU coreutils format, e.g. "hex  file", or "hex *file"
+	// for binary mode. Lines starting with a backslash have escaped file names.
+	gnuLine = regexp.MustCompile(`^(\\?)([0-9A-Fa-f]+) [ *](.*)$`)
+)
+
+// algorithmsBySize are algorithms of GNU format checksums by size of digest.
+var algorithmsBySize = map[int]string{
+	16: "md5",
+	20: "sha1",
+	28: "sha224",
+	32: "sha256",
+	48: "sha384",
+	64: "sha512",
+}
+
+// algorithmAliases are names of algorithms in BSD tags written by openssl.
+var algorithmAliases = map[string]string{
+	"ripemd-160": "ripemd160",
+	"ripemd":     "ripemd160",
+	"rmd160":     "ripemd160",
+	"shake-128":  "shake128",
+	"shake-256":  "shake256",
+}
+
+// normalizeAlgorithm returns name of algorithm of a BSD tag, e.g. sha256 for SHA256 and
+// SHA2-256, sha512-224 for SHA512/224, and ripemd160 for RIPEMD-160.
+func normalizeAlgorithm(tag string) string {
+	name := strings.ToLower(strings.ReplaceAll(tag, "/", "-"))
+	if strings.HasPrefix(name, "sha2-") {
+		name = "sha" + name[len("sha2-"):]
+	}
+
+	if alias, found := algorithmAliases[name]; found {
+		name = alias
+	}
+
+	return name
+}
+
+// unescapeFilename decodes file names of GNU format escaped by \\ and \n.
+func unescapeFilename(name string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r")
+	return replacer.Replace(name)
+}
+
+type checksumLine struct {
+	Algorithm string
+	Digest    []byte
+	File      string
+}
+
+// parseChecksumLine parses a line in BSD or GNU format. Algorithm of GNU format is algorithm
+// if given, or detected by size of digest.
+func parseChecksumLine(line string, algorithm string) (*checksumLine, error) {
+	var tag, file, digest string
+	if m := bsdLine.FindStringSubmatch(line); m != nil {
+		tag, file, digest = m[1], m[2], m[3]
+	} else if m := gnuLine.FindStringSubmatch(line); m != nil {
+		digest, file = m[2], m[3]
+		if len(m[1]) > 0 {
+			file = unescapeFilename(file)
+		}
+	} else {
+		return nil, fmt.Errorf("improperly formatted checksum line")
+	}
+
+	sum, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &checksumLine{
+		Digest: sum,
+		File:   file,
+	}
+
+	switch {
+	case len(tag) > 0:
+		result.Algorithm = normalizeAlgorithm(tag)
+
+	case len(algorithm) > 0:
+		result.Algorithm = algorithm
+
+	default:
+		result.Algorithm = algorithmsBySize[len(sum)]
+	}
+
+	if _, found := algorithmMap[result.Algorithm]; !found {
+		return nil, fmt.Errorf("unknown algorithm of %d bytes digest %s", len(sum), tag)
+	}
+
+	return result, nil
+}
+
+// readChecksums reads checksum lines of a file, improperly formatted lines are counted and
+// skipped, as well as empty lines and comments.
+func readChecksums(filename string, algorithm string) ([]*checksumLine, int, error) {
+	file, err := clicontext.OpenFile(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer file.Close()
+
+	lines := make([]*checksumLine, 0, 16)
+	improper := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if len(strings.TrimSpace(text)) <= 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		line, err := parseChecksumLine(text, algorithm)
+		if err != nil {
+			improper++
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, 0, clicontext.NewError(clicontext.ErrorKindIO, err)
+	}
+
+	return lines, improper, nil
+}
+
+type fileCheck struct {
+	File      string `json:"file"`
+	Algorithm string `json:"algorithm"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+}
+
+// checkDocument is results of checking files, Quiet and Status only affect text output.
+type checkDocument struct {
+	Files  []fileCheck `json:"files"`
+	Quiet  bool        `json:"-"`
+	Status bool        `json:"-"`
+}
+
+func (d *checkDocument) WriteText(w io.Writer) error {
+	if d.Status {
+		return nil
+	}
+
+	for _, file := range d.Files {
+		if d.Quiet && file.Result == checkOK {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s: %s\n", file.File, file.Result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkFile(line *checksumLine, buffer []byte) fileCheck {
+	result := fileCheck{
+		File:      line.File,
+		Algorithm: line.Algorithm,
+		Result:    checkOK,
+	}
+
+	// Size of output of extendable output functions is taken from the checksum.
+	newHash := algorithmMap[line.Algorithm]
+	if _, xof := xofMap[line.Algorithm]; xof {
+		newHash, _ = newHashOf(line.Algorithm, len(line.Digest), nil)
+	}
+
+	sums, err := hashFile([]func() hash.Hash{newHash}, line.File, buffer)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		result.Result = checkMissing
+
+	case err != nil:
+		result.Result = checkFailed
+		result.Error = err.Error()
+
+	case !bytes.Equal(sums[0], line.Digest):
+		result.Result = checkFailed
+	}
+
+	return result
+}
+
+func plural(n int, singular string, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// checkCommand verifies files listed in checksum files, like sha256sum -c does.
+func checkCommand(ctx *clicontext.CommandContext, algorithm string, sumFiles []string) error {
+	doc := &checkDocument{
+		Files:  make([]fileCheck, 0, 16),
+		Quiet:  ctx.Bool("quiet"),
+		Status: ctx.Bool("status"),
+	}
+
+	ignoreMissing := ctx.Bool("ignore-missing")
+	improper, mismatched, missing, unreadable := 0, 0, 0, 0
+	for _, sumFile := range sumFiles {
+		lines, n, err := readChecksums(sumFile, algorithm)
+		if err != nil {
+			return err
+		}
+
+		improper += n
+		if len(lines) <= 0 {
+			return clicontext.InputFormatErrorf("%s: no properly formatted checksum lines found", sumFile)
+		}
+
+		results := make([]fileCheck, len(lines))
+		forEachParallel(len(lines), ctx.Int("jobs"), func(i int, buffer []byte) {
+			results[i] = checkFile(lines[i], buffer)
+		})
+
+		for _, result := range results {
+			switch result.Result {
+			case checkMissing:
+				if ignoreMissing {
+					continue
+				}
+
+				missing++
+
+			case checkFailed:
+				if len(result.Error) <= 0 {
+					mismatched++
+					break
+				}
+
+				unreadable++
+				if !doc.Status {
+					fmt.Fprintf(os.Stderr, "Read error in %s: %s\n", result.File, result.Error)
+				}
+			}
+
+			doc.Files = append(doc.Files, result)
+		}
+	}
+
+	if err := ctx.IO().RenderDocument(doc); err != nil {
+		return err
+	}
+
+	if improper > 0 && !doc.Status {
+		fmt.Fprintf(os.Stderr, "WARNING: %s improperly formatted\n", plural(improper, "line is", "lines are"))
+	}
+
+	failures := make([]string, 0, 3)
+	if mismatched > 0 {
+		failures = append(failures, plural(mismatched, "computed checksum did NOT match", "computed checksums did NOT match"))
+	}
+
+	if unreadable > 0 {
+		failures = append(failures, plural(unreadable, "file could not be read", "files could not be read"))
+	}
+
+	if missing > 0 {
+		failures = append(failures, plural(missing, "file is missing", "files are missing"))
+	}
+
+	var err error
+	if len(failures) > 0 {
+		err = clicontext.VerificationErrorf("%s", strings.Join(failures, ", "))
+	} else if len(doc.Files) <= 0 {
+		err = clicontext.VerificationErrorf("no file was verified")
+	}
+
+	// Exit status shows the result with -status.
+	if doc.Status {
+		return clicontext.SilentError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,85 @@
+package digest
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseChecksumLine(t *testing.T) {
+	sha256Empty := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	md5Empty := "d41d8cd98f00b204e9800998ecf8427e"
+	cases := []struct {
+		line      string
+		algorithm string
+		expected  string
+		file      string
+		digest    string
+	}{
+		{sha256Empty + "  file.txt", "", "sha256", "file.txt", sha256Empty},
+		{sha256Empty + " *file.bin", "", "sha256", "file.bin", sha256Empty},
+		{md5Empty + "  name with spaces", "", "md5", "name with spaces", md5Empty},
+		{md5Empty + "  file", "md4", "md4", "file", md5Empty},
+		{`\` + md5Empty + `  back\\slash\nnewline`, "", "md5", "back\\slash\nnewline", md5Empty},
+		{"SHA256 (file.txt) = " + sha256Empty, "", "sha256", "file.txt", sha256Empty},
+		{"SHA256(file.txt)= " + sha256Empty, "", "sha256", "file.txt", sha256Empty},
+		{"SHA2-256(a (b).txt)= " + sha256Empty, "", "sha256", "a (b).txt", sha256Empty},
+		{"SHA512/256(file)= " + sha256Empty, "", "sha512-256", "file", sha256Empty},
+		{"RIPEMD-160(file)= " + md5Empty + "00000000", "", "ripemd160", "file", md5Empty + "00000000"},
+		{"MD5 (file) = " + md5Empty, "sha256", "md5", "file", md5Empty},
+	}
+
+	for _, c := range cases {
+		line, err := parseChecksumLine(c.line, c.algorithm)
+		if err != nil {
+			t.Errorf("parse %q failed: %s", c.line, err)
+			continue
+		}
+
+		if line.Algorithm != c.expected || line.File != c.file || hex.EncodeToString(line.Digest) != c.digest {
+			t.Errorf("wrong result of %q: %s %q %x", c.line, line.Algorithm, line.File, line.Digest)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"not a checksum line",
+		sha256Empty + "file",
+		"abc  file",
+		"0011  file",
+		"WHIRLPOOL (file) = " + sha256Empty,
+	}
+
+	for _, line := range invalid {
+		if result, err := parseChecksumLine(line, ""); err == nil {
+			t.Errorf("invalid line %q is parsed as %v", line, result)
+		}
+	}
+}
+
+func TestFilenameEscape(t *testing.T) {
+	cases := []struct {
+		name    string
+		escaped string
+		needed  bool
+	}{
+		{"file.txt", "file.txt", false},
+		{"with space", "with space", false},
+		{`back\slash`, `back\\slash`, true},
+		{"new\nline", `new\nline`, true},
+		{"carriage\rreturn", `carriage\rreturn`, true},
+		{`\n`, `\\n`, true},
+	}
+
+	for _, c := range cases {
+		escaped, needed := escapeFilename(c.name)
+		if escaped != c.escaped || needed != c.needed {
+			t.Errorf("wrong escape of %q: %q %v, expected %q %v", c.name, escaped, needed, c.escaped, c.needed)
+		}
+
+		if needed {
+			if name := unescapeFilename(escaped); name != c.name {
+				t.Errorf("wrong unescape of %q: %q, expected %q", escaped, name, c.name)
+			}
+		}
+	}
+}
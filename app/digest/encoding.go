@@ -0,0 +1,131 @@
+package digest
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Encodings of digests in text output.
+const (
+	encodingHex       = "hex"
+	encodingHexUpper  = "HEX"
+	encodingBase64    = "base64"
+	encodingBase64URL = "base64url"
+	encodingBase32    = "base32"
+	encodingNix32     = "nix32"
+)
+
+func encodingNames() []string {
+	return []string{encodingHex, encodingHexUpper, encodingBase64, encodingBase64URL, encodingBase32, encodingNix32}
+}
+
+// Formats of lines in text output.
+const (
+	formatBSD = "bsd"
+	formatGNU = "gnu"
+	formatSRI = "sri"
+	formatOCI = "oci"
+)
+
+func formatNames() []string {
+	return []string{formatBSD, formatGNU, formatSRI, formatOCI}
+}
+
+// sriAlgorithms are algorithms allowed by Subresource Integrity.
+var sriAlgorithms = map[string]bool{
+	"sha256": true,
+	"sha384": true,
+	"sha512": true,
+}
+
+// nix32Alphabet is alphabet of base32 used by Nix, without e, o, u and t.
+const nix32Alphabet = "0123456789abcdfghijklmnpqrsvwxyz"
+
+// encodeNix32 encodes data in base32 of Nix, which takes bits from the end of data.
+func encodeNix32(data []byte) string {
+	if len(data) <= 0 {
+		return ""
+	}
+
+	size := (len(data)*8-1)/5 + 1
+	buffer := make([]byte, 0, size)
+	for n := size - 1; n >= 0; n-- {
+		b := n * 5
+		i, j := b/8, uint(b%8)
+		c := data[i] >> j
+		if i+1 < len(data) {
+			c |= data[i+1] << (8 - j)
+		}
+
+		buffer = append(buffer, nix32Alphabet[c&0x1f])
+	}
+
+	return string(buffer)
+}
+
+// encodeDigest returns digest in encoding, base64url is without padding as used in URLs.
+func encodeDigest(encoding string, digest []byte) string {
+	switch encoding {
+	case encodingHexUpper:
+		return strings.ToUpper(hex.EncodeToString(digest))
+
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(digest)
+
+	case encodingBase64URL:
+		return base64.RawURLEncoding.EncodeToString(digest)
+
+	case encodingBase32:
+		return base32.StdEncoding.EncodeToString(digest)
+
+	case encodingNix32:
+		return encodeNix32(digest)
+	}
+
+	return hex.EncodeToString(digest)
+}
+
+// escapeFilename escapes file names of GNU format, reversed by unescapeFilename. Escaped is
+// true if the line must start with a backslash.
+func escapeFilename(name string) (string, bool) {
+	if !strings.ContainsAny(name, "\\\n\r") {
+		return name, false
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	return replacer.Replace(name), true
+}
+
+// fixedEncoding returns encoding of format, base64 of SRI and hex of OCI, or empty string if
+// encoding is given by -encoding.
+func fixedEncoding(format string) string {
+	switch format {
+	case formatSRI:
+		return encodingBase64
+
+	case formatOCI:
+		return encodingHex
+	}
+
+	return ""
+}
+
+// checkFormat checks format and encoding of text output of algorithms.
+func checkFormat(format string, encoding string, encodingSet bool, algorithms []string) error {
+	if expected := fixedEncoding(format); len(expected) > 0 && encodingSet && encoding != expected {
+		return fmt.Errorf("format %s is always encoded in %s", format, expected)
+	}
+
+	if format == formatSRI {
+		for _, algorithm := range algorithms {
+			if !sriAlgorithms[algorithm] {
+				return fmt.Errorf("algorithm %s is not allowed in SRI, must be sha256, sha384 or sha512", algorithm)
+			}
+		}
+	}
+
+	return nil
+}
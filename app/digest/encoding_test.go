@@ -0,0 +1,53 @@
+package digest
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestEncodeDigest(t *testing.T) {
+	empty := sha256.Sum256(nil)
+	cases := []struct {
+		encoding string
+		data     []byte
+		expected string
+	}{
+		{encodingHex, []byte{0xde, 0xad, 0xbe, 0xef}, "deadbeef"},
+		{encodingHexUpper, []byte{0xde, 0xad, 0xbe, 0xef}, "DEADBEEF"},
+		{encodingBase64, []byte{0xfb, 0xff}, "+/8="},
+		{encodingBase64URL, []byte{0xfb, 0xff}, "-_8"},
+		{encodingBase32, []byte("f"), "MY======"},
+		{encodingNix32, nil, ""},
+		{encodingNix32, []byte{0x1f}, "0z"},
+		{encodingNix32, empty[:], "0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73"},
+	}
+
+	for _, c := range cases {
+		if got := encodeDigest(c.encoding, c.data); got != c.expected {
+			t.Errorf("wrong %s of %x: %s, expected %s", c.encoding, c.data, got, c.expected)
+		}
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	cases := []struct {
+		format      string
+		encoding    string
+		encodingSet bool
+		algorithms  []string
+		valid       bool
+	}{
+		{formatSRI, encodingBase64, false, []string{"sha256", "sha512"}, true},
+		{formatSRI, encodingHex, true, []string{"sha256"}, false},
+		{formatSRI, encodingBase64, false, []string{"md5"}, false},
+		{formatOCI, encodingHex, true, []string{"sha256"}, true},
+		{formatOCI, encodingBase64, true, []string{"sha256"}, false},
+	}
+
+	for _, c := range cases {
+		err := checkFormat(c.format, c.encoding, c.encodingSet, c.algorithms)
+		if (err == nil) != c.valid {
+			t.Errorf("wrong result of format %s with %s %v: %v", c.format, c.encoding, c.algorithms, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package digest
+
+import (
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/flily/go-ssl/common/clicontext"
+)
+
+// bufferSize is size of read buffer of each worker, large buffers reduce system calls on
+// large files.
+const bufferSize = 1 << 20
+
+// hashFile calculates digests of all algorithms in a single pass of reading file.
+func hashFile(newHashes []func() hash.Hash, filename string, buffer []byte) ([][]byte, error) {
+	fd, err := clicontext.OpenFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fd.Close()
+
+	hashers := make([]hash.Hash, len(newHashes))
+	writers := make([]io.Writer, len(newHashes))
+	for i, newHash := range newHashes {
+		hashers[i] = newHash()
+		writers[i] = hashers[i]
+	}
+
+	// Reader is wrapped to hide WriteTo of files, which ignores the buffer.
+	reader := struct{ io.Reader }{fd}
+	if _, err := io.CopyBuffer(io.MultiWriter(writers...), reader, buffer); err != nil {
+		return nil, clicontext.NewError(clicontext.ErrorKindIO, err)
+	}
+
+	sums := make([][]byte, len(hashers))
+	for i, hasher := range hashers {
+		sums[i] = hasher.Sum(nil)
+	}
+
+	return sums, nil
+}
+
+// forEachParallel calls fn with 0 to n-1 in jobs workers, every worker has its own buffer.
+// jobs of 0 is number of CPUs.
+func forEachParallel(n int, jobs int, fn func(i int, buffer []byte)) {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	if jobs > n {
+		jobs = n
+	}
+
+	indexes := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buffer := make([]byte, bufferSize)
+			for i := range indexes {
+				fn(i, buffer)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+}
+
+// fileFilter selects files by glob patterns, matched against both base name and slash
+// separated path relative to the directory walked. Files of Skip, e.g. output files, are
+// never selected.
+type fileFilter struct {
+	Include []string
+	Exclude []string
+	Skip    []string
+}
+
+func matchAny(patterns []string, name string, relative string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, relative); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks syntax of patterns.
+func (f *fileFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return clicontext.UsageErrorf("invalid pattern %s: %s", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Excluded returns true if a file or directory is excluded.
+func (f *fileFilter) Excluded(name string, relative string) bool {
+	return matchAny(f.Exclude, name, relative)
+}
+
+// Selected returns true if a file is included and not excluded, all files are included if
+// no pattern of include is given.
+func (f *fileFilter) Selected(name string, relative string) bool {
+	if f.Excluded(name, relative) {
+		return false
+	}
+
+	return len(f.Include) <= 0 || matchAny(f.Include, name, relative)
+}
+
+// SkipFiles adds files to skip, standard I/O of - is ignored.
+func (f *fileFilter) SkipFiles(filenames ...string) {
+	for _, filename := range filenames {
+		if len(filename) <= 0 || filename == "-" {
+			continue
+		}
+
+		if absolute, err := filepath.Abs(filename); err == nil {
+			f.Skip = append(f.Skip, absolute)
+		}
+	}
+}
+
+func (f *fileFilter) skipped(filename string) bool {
+	if len(f.Skip) <= 0 {
+		return false
+	}
+
+	absolute, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+
+	for _, skip := range f.Skip {
+		if absolute == skip {
+			return true
+		}
+	}
+
+	return false
+}
+
+// walkFiles returns regular files in directory root recursively in lexical order. Excluded
+// directories are skipped.
+func walkFiles(root string, filter *fileFilter) ([]string, error) {
+	files := make([]string, 0, 64)
+	err := filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, _ := filepath.Rel(root, name)
+		relative = filepath.ToSlash(relative)
+		if entry.IsDir() {
+			if name != root && filter.Excluded(entry.Name(), relative) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if entry.Type().IsRegular() && filter.Selected(entry.Name(), relative) && !filter.skipped(name) {
+			files = append(files, name)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, clicontext.NewError(clicontext.ErrorKindIO, err)
+	}
+
+	return files, nil
+}
+
+// expandFiles replaces directories in arguments with files in them if recursive is true.
+// Other arguments are kept as they are, errors of them are reported when they are read.
+func expandFiles(args []string, recursive bool, filter *fileFilter) ([]string, error) {
+	if !recursive {
+		return args, nil
+	}
+
+	files := make([]string, 0, len(args))
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		found, err := walkFiles(arg, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, found...)
+	}
+
+	return files, nil
+}
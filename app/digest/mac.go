@@ -0,0 +1,251 @@
+package digest
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/modules/mac"
+)
+
+// MAC algorithms of -mac.
+const (
+	macHMAC       = "hmac"
+	macCMAC       = "cmac"
+	macKMAC128    = "kmac128"
+	macKMAC256    = "kmac256"
+	macPoly1305   = "poly1305"
+	macBLAKE2bMAC = "blake2bmac"
+	macBLAKE2sMAC = "blake2smac"
+)
+
+func macNames() []string {
+	return []string{macHMAC, macCMAC, macKMAC128, macKMAC256, macPoly1305, macBLAKE2bMAC, macBLAKE2sMAC}
+}
+
+// macOptions are options of MAC given by -macopt.
+type macOptions struct {
+	key    []byte
+	digest string
+	cipher string
+	custom []byte
+	size   int
+}
+
+// readKeyFile reads a key from file, a trailing newline is removed.
+func readKeyFile(filename string) ([]byte, error) {
+	data, err := clicontext.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	data = []byte(strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"))
+	return data, nil
+}
+
+// parseMACOptions parses options in form name:value, as openssl -macopt does.
+func parseMACOptions(options []string) (*macOptions, error) {
+	result := &macOptions{}
+	for _, option := range options {
+		name, value, found := strings.Cut(option, ":")
+		if !found {
+			return nil, clicontext.UsageErrorf("invalid MAC option %s, must be name:value", option)
+		}
+
+		var err error
+		switch strings.ToLower(name) {
+		case "key":
+			result.key = []byte(value)
+
+		case "hexkey":
+			result.key, err = hex.DecodeString(value)
+
+		case "keyfile":
+			result.key, err = readKeyFile(value)
+
+		case "keyenv":
+			env, found := os.LookupEnv(value)
+			if !found {
+				return nil, clicontext.UsageErrorf("environment variable %s of MAC key is not set", value)
+			}
+
+			result.key = []byte(env)
+
+		case "digest":
+			result.digest = strings.ToLower(value)
+
+		case "cipher":
+			result.cipher = strings.ToLower(value)
+
+		case "custom":
+			result.custom = []byte(value)
+
+		case "hexcustom":
+			result.custom, err = hex.DecodeString(value)
+
+		case "size":
+			result.size, err = strconv.Atoi(value)
+			if err == nil && result.size <= 0 {
+				err = fmt.Errorf("size must be positive")
+			}
+
+		default:
+			return nil, clicontext.UsageErrorf("unknown MAC option %s", name)
+		}
+
+		if err != nil {
+			if clicontext.ErrorKindOf(err) == clicontext.ErrorKindIO {
+				return nil, err
+			}
+
+			return nil, clicontext.UsageErrorf("invalid MAC option %s: %s", name, err)
+		}
+	}
+
+	return result, nil
+}
+
+// cmacCipher checks cipher option of CMAC against size of key, only AES in CBC mode is
+// supported, as openssl does.
+func cmacCipher(cipher string, key []byte) error {
+	sizes := map[string]int{
+		"aes-128-cbc": 16,
+		"aes-192-cbc": 24,
+		"aes-256-cbc": 32,
+	}
+
+	size, found := sizes[cipher]
+	if !found {
+		return clicontext.UsageErrorf("unsupported CMAC cipher %s, must be one of aes-128-cbc, aes-192-cbc and aes-256-cbc", cipher)
+	}
+
+	if size != len(key) {
+		return clicontext.UsageErrorf("CMAC cipher %s needs %d bytes key, got %d bytes", cipher, size, len(key))
+	}
+
+	return nil
+}
+
+// newMAC returns name and constructor of MAC algorithm. digest is hash algorithm of HMAC.
+// Keys and options are checked before returning, constructors do not fail.
+func newMAC(algorithm string, digest string, opts *macOptions) (string, func() hash.Hash, error) {
+	key := opts.key
+	if key == nil {
+		return "", nil, clicontext.UsageErrorf("%s needs a key, given by -macopt key:, hexkey:, keyfile: or keyenv:", algorithm)
+	}
+
+	var err error
+	switch algorithm {
+	case macHMAC:
+		if len(opts.digest) > 0 {
+			digest = opts.digest
+		}
+
+		newHash, found := algorithmMap[digest]
+		if !found {
+			return "", nil, clicontext.UsageErrorf("unknown digest %s of HMAC", digest)
+		}
+
+		return "hmac-" + digest, func() hash.Hash { return hmac.New(newHash, key) }, nil
+
+	case macCMAC:
+		if len(opts.cipher) > 0 {
+			if err := cmacCipher(opts.cipher, key); err != nil {
+				return "", nil, err
+			}
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return "", nil, clicontext.UsageErrorf("CMAC needs 16, 24 or 32 bytes key, got %d bytes", len(key))
+		}
+
+		// CMAC of AES never fails.
+		return algorithm, func() hash.Hash { m, _ := mac.NewCMAC(block); return m }, nil
+
+	case macKMAC128, macKMAC256:
+		size, newKMAC := 32, mac.NewKMAC128
+		if algorithm == macKMAC256 {
+			size, newKMAC = 64, mac.NewKMAC256
+		}
+
+		if opts.size > 0 {
+			size = opts.size
+		}
+
+		return algorithm, func() hash.Hash { return newKMAC(key, size, opts.custom) }, nil
+
+	case macPoly1305:
+		_, err = mac.NewPoly1305(key)
+		return algorithm, func() hash.Hash { m, _ := mac.NewPoly1305(key); return m }, err
+
+	case macBLAKE2bMAC:
+		size := blake2b.Size
+		if opts.size > 0 {
+			size = opts.size
+		}
+
+		_, err = blake2b.New(size, key)
+		return algorithm, func() hash.Hash { m, _ := blake2b.New(size, key); return m }, err
+
+	case macBLAKE2sMAC:
+		newBLAKE2s := blake2s.New256
+		switch opts.size {
+		case 0, blake2s.Size:
+		case blake2s.Size128:
+			newBLAKE2s = blake2s.New128
+
+		default:
+			return "", nil, clicontext.UsageErrorf("size of BLAKE2s MAC must be 16 or 32 bytes")
+		}
+
+		_, err = newBLAKE2s(key)
+		return algorithm, func() hash.Hash { m, _ := newBLAKE2s(key); return m }, err
+	}
+
+	return "", nil, clicontext.UsageErrorf("unknown MAC algorithm %s", algorithm)
+}
+
+// macOf returns name and constructor of MAC given by -hmac or -mac, or nil constructor if
+// no MAC is given.
+func macOf(ctx *clicontext.CommandContext, digest string) (string, func() hash.Hash, error) {
+	algorithm := ctx.String("mac")
+	options := ctx.Strings("macopt")
+	if ctx.IsSet("hmac") {
+		if len(algorithm) > 0 && algorithm != macHMAC {
+			return "", nil, clicontext.UsageErrorf("-hmac can not be used with -mac %s", algorithm)
+		}
+
+		algorithm = macHMAC
+		options = append([]string{"key:" + ctx.String("hmac")}, options...)
+	}
+
+	if len(algorithm) <= 0 {
+		if len(options) > 0 {
+			return "", nil, clicontext.UsageErrorf("-macopt is given without -mac")
+		}
+
+		return "", nil, nil
+	}
+
+	opts, err := parseMACOptions(options)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name, newHash, err := newMAC(algorithm, digest, opts)
+	if err != nil && clicontext.ErrorKindOf(err) == clicontext.ErrorKindGeneric {
+		err = clicontext.UsageErrorf("%s", err)
+	}
+
+	return name, newHash, err
+}
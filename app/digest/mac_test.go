@@ -0,0 +1,120 @@
+package digest
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/flily/go-ssl/common/clicontext"
+)
+
+func TestParseMACOptions(t *testing.T) {
+	t.Setenv("DIGEST_TEST_MAC_KEY", "from env")
+	cases := []struct {
+		options []string
+		key     string
+		digest  string
+		custom  string
+		size    int
+	}{
+		{[]string{"key:secret"}, "secret", "", "", 0},
+		{[]string{"key:a:b"}, "a:b", "", "", 0},
+		{[]string{"hexkey:00ff"}, "\x00\xff", "", "", 0},
+		{[]string{"keyenv:DIGEST_TEST_MAC_KEY"}, "from env", "", "", 0},
+		{[]string{"KEY:k", "Digest:SHA512", "custom:app"}, "k", "sha512", "app", 0},
+		{[]string{"key:k", "hexcustom:6170", "size:16"}, "k", "", "ap", 16},
+	}
+
+	for _, c := range cases {
+		opts, err := parseMACOptions(c.options)
+		if err != nil {
+			t.Errorf("parse %v failed: %s", c.options, err)
+			continue
+		}
+
+		if string(opts.key) != c.key || opts.digest != c.digest || string(opts.custom) != c.custom || opts.size != c.size {
+			t.Errorf("wrong options of %v: %+v", c.options, opts)
+		}
+	}
+
+	invalid := [][]string{
+		{"key"},
+		{"hexkey:xyz"},
+		{"size:0"},
+		{"size:many"},
+		{"keyenv:DIGEST_TEST_UNSET_VARIABLE"},
+		{"unknown:value"},
+	}
+
+	for _, options := range invalid {
+		_, err := parseMACOptions(options)
+		if err == nil || clicontext.ErrorKindOf(err) != clicontext.ErrorKindUsage {
+			t.Errorf("invalid options %v: %v", options, err)
+		}
+	}
+}
+
+func TestNewMAC(t *testing.T) {
+	key16 := []byte("0123456789abcdef")
+	key32 := append(append([]byte{}, key16...), key16...)
+	cases := []struct {
+		algorithm string
+		opts      *macOptions
+		name      string
+		size      int
+	}{
+		{macHMAC, &macOptions{key: []byte("k")}, "hmac-sha256", 32},
+		{macHMAC, &macOptions{key: []byte("k"), digest: "sha1"}, "hmac-sha1", 20},
+		{macCMAC, &macOptions{key: key16, cipher: "aes-128-cbc"}, macCMAC, 16},
+		{macKMAC128, &macOptions{key: key16}, macKMAC128, 32},
+		{macKMAC256, &macOptions{key: key16, size: 20}, macKMAC256, 20},
+		{macPoly1305, &macOptions{key: key32}, macPoly1305, 16},
+		{macBLAKE2bMAC, &macOptions{key: key16, size: 32}, macBLAKE2bMAC, 32},
+		{macBLAKE2sMAC, &macOptions{key: key16, size: 16}, macBLAKE2sMAC, 16},
+	}
+
+	for _, c := range cases {
+		name, newHash, err := newMAC(c.algorithm, "sha256", c.opts)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.algorithm, err)
+			continue
+		}
+
+		if size := newHash().Size(); name != c.name || size != c.size {
+			t.Errorf("%s: wrong MAC %s of %d bytes, expected %s of %d bytes", c.algorithm, name, size, c.name, c.size)
+		}
+	}
+
+	invalid := []struct {
+		algorithm string
+		opts      *macOptions
+	}{
+		{macHMAC, &macOptions{}},
+		{macHMAC, &macOptions{key: []byte("k"), digest: "none"}},
+		{macCMAC, &macOptions{key: []byte("short")}},
+		{macCMAC, &macOptions{key: key16, cipher: "aes-256-cbc"}},
+		{macPoly1305, &macOptions{key: key16}},
+		{macBLAKE2sMAC, &macOptions{key: key16, size: 20}},
+		{"unknown", &macOptions{key: key16}},
+	}
+
+	for _, c := range invalid {
+		if _, _, err := newMAC(c.algorithm, "sha256", c.opts); err == nil {
+			t.Errorf("%s: invalid options %+v are accepted", c.algorithm, c.opts)
+		}
+	}
+}
+
+func TestHMACVector(t *testing.T) {
+	// RFC 4231 test case 2.
+	_, newHash, err := newMAC(macHMAC, "sha256", &macOptions{key: []byte("Jefe")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	h := newHash()
+	h.Write([]byte("what do ya want for nothing?"))
+	expected := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got := hex.EncodeToString(h.Sum(nil)); got != expected {
+		t.Errorf("wrong HMAC: %s, expected %s", got, expected)
+	}
+}
@@ -1,93 +1,350 @@
 package digest
 
 import (
-	"errors"
 	"flag"
 	"fmt"
+	"hash"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/flily/go-ssl/common/clicontext"
 	"github.com/flily/go-ssl/common/cliutils"
+	"github.com/flily/go-ssl/common/prettyprint"
 )
 
-func hashFile(name string, filename string) error {
-	fd, err := cliutils.CLIReadFile(filename)
-	if err != nil {
-		return err
+type fileDigest struct {
+	File      string          `json:"file"`
+	Algorithm string          `json:"algorithm"`
+	Digest    prettyprint.Hex `json:"digest,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// digestDocument is message digests of files, one per file and algorithm in order of files
+// and then algorithms. Files failed to read have error instead. Format, Encoding and Binary
+// only affect text output.
+type digestDocument struct {
+	Algorithms []string     `json:"algorithms"`
+	Files      []fileDigest `json:"files"`
+	Format     string       `json:"-"`
+	Encoding   string       `json:"-"`
+	Binary     bool         `json:"-"`
+}
+
+// line returns text line of a digest in format, stdin is written as in openssl.
+func (d *digestDocument) line(file fileDigest) string {
+	digest := encodeDigest(d.Encoding, file.Digest)
+	name := file.File
+	if name == "-" {
+		name = "stdin"
 	}
 
-	defer fd.Close()
+	switch d.Format {
+	case formatGNU:
+		escaped, found := escapeFilename(name)
+		if found {
+			return fmt.Sprintf("\\%s *%s", digest, escaped)
+		}
 
-	hasher, _ := getHasher(name)
-	buffer := make([]byte, 1024)
-	for {
-		var n int
-		n, err = fd.Read(buffer)
-		if err != nil {
-			break
+		return fmt.Sprintf("%s *%s", digest, name)
+
+	case formatSRI, formatOCI:
+		separator := "-"
+		if d.Format == formatOCI {
+			separator = ":"
 		}
 
-		if n > 0 {
-			hasher.Write(buffer[:n])
+		digest = file.Algorithm + separator + encodeDigest(fixedEncoding(d.Format), file.Digest)
+		if file.File == "-" {
+			return digest
 		}
+
+		return fmt.Sprintf("%s  %s", digest, name)
 	}
 
-	if errors.Is(err, io.EOF) {
-		err = nil
+	if file.File == "-" && len(d.Algorithms) == 1 {
+		return digest
 	}
 
-	if err == nil {
-		checksum := hasher.Sum(nil)
-		if filename == "-" {
-			fmt.Printf("%x\n", checksum)
-		} else {
-			fmt.Printf("%s(%s)= %x\n",
-				strings.ToUpper(name), filename, checksum)
+	return fmt.Sprintf("%s(%s)= %s", strings.ToUpper(file.Algorithm), name, digest)
+}
+
+func (d *digestDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinterWithLayout(w, prettyprint.CompactLayout())
+	for _, file := range d.Files {
+		if len(file.Error) > 0 {
+			continue
+		}
+
+		if d.Binary {
+			if _, err := w.Write(file.Digest); err != nil {
+				return err
+			}
+
+			continue
 		}
+
+		p.Printf("%s\n", d.line(file))
 	}
 
-	return err
+	return p.Err()
 }
 
-func algoHandler(array *[]string, name string) func(string) error {
-	return func(_ string) error {
-		*array = append(*array, name)
-		return nil
-	}
+var Command = &clicontext.Command{
+	Name:    "digest",
+	Aliases: []string{"dgst"},
+	Short:   "Calculate message digest of files, or sign and verify them",
+	Long: "Calculate message digest of files, verify files with -c, or sign and verify files.\n\n" +
+		"With -c, files given are checksum files in BSD format, i.e. 'SHA256(file)= hex' as\n" +
+		"written by digest and openssl dgst, or in GNU coreutils format, i.e. 'hex  file' as\n" +
+		"written by sha256sum. Algorithm is taken from the tag of BSD format, or detected by\n" +
+		"size of digest for GNU format unless an algorithm is given. Every file is reported\n" +
+		"as OK, FAILED or MISSING, and exit status is non-zero if any file is not OK.\n\n" +
+		"MACs are calculated with -hmac key, or -mac with options given by -macopt, e.g.\n\n" +
+		"  gossl digest -sha512 -mac hmac -macopt keyenv:WEBHOOK_SECRET payload.json\n\n" +
+		"MAC options are:\n" +
+		"  key:string, hexkey:hex  key on command line\n" +
+		"  keyfile:file            key read from file, a trailing newline is removed\n" +
+		"  keyenv:name             key read from environment variable\n" +
+		"  digest:name             digest of hmac, the digest flag by default\n" +
+		"  cipher:name             cipher of cmac, aes-128-cbc, aes-192-cbc or aes-256-cbc\n" +
+		"  custom:string, hexcustom:hex  customization string of kmac128 and kmac256\n" +
+		"  size:n                  size of output in bytes of kmac and blake2 MACs\n\n" +
+		"Size of output of extendable output functions, i.e. shake128, shake256, cshake128,\n" +
+		"cshake256 and blake3, is given by -xoflen. Supported algorithms are listed by -list.\n\n" +
+		"Several algorithms may be given, all of them are calculated in a single pass of\n" +
+		"reading. Files are hashed in parallel by -jobs workers, and results are printed in\n" +
+		"order of arguments. With -R, directories are walked recursively in lexical order,\n" +
+		"files are selected by -include and -exclude globs, e.g.\n\n" +
+		"  gossl digest -sha256 -blake3 -R -exclude .git -include '*.tar.gz' release/\n\n" +
+		"Globs are matched against both file names and paths relative to the directory,\n" +
+		"excluded directories are skipped.\n\n" +
+		"Digests are written as 'SHA256(file)= hex' by default, as 'hex *file' of coreutils\n" +
+		"with -r or -format gnu, as 'sha384-base64' of Subresource Integrity with -format sri,\n" +
+		"or as 'sha256:hex' of OCI with -format oci. Encoding of digests is given by\n" +
+		"-encoding, nix32 is base32 of Nix. With -binary, raw digests are written.\n\n" +
+		"A file is signed with -sign key, or verified with -verify and -signature, e.g.\n\n" +
+		"  gossl dgst -sha384 -sign key.pem -out app.sig app.tar.gz\n" +
+		"  gossl dgst -sha384 -verify cert.pem -signature app.sig app.tar.gz\n\n" +
+		"Key of -verify is a public key, certificate, certificate request or private key. RSA\n" +
+		"keys sign in PKCS #1 v1.5 by default, ECDSA signatures are in ASN.1 DER by default,\n" +
+		"Ed25519 keys sign files without digest. With -prehashed, the file is a digest\n" +
+		"calculated already, Ed25519 keys sign digests of sha512 in Ed25519ph. Signature\n" +
+		"options are given by -sigopt:\n" +
+		"  rsa_padding_mode:pkcs1|pss       padding of RSA\n" +
+		"  rsa_pss_saltlen:digest|max|auto|n  salt length of PSS, auto by default, which is\n" +
+		"                                   max in signing and detected in verifying\n" +
+		"  rsa_mgf1_md:name                 digest of MGF1 of PSS, the digest by default\n" +
+		"  ecdsa_format:der|raw             format of ECDSA signatures, raw is r || s\n\n" +
+		"Manifests of directories are created and verified by 'gossl digest manifest'.",
+	Usage:    "[file ...]",
+	FileArgs: true,
+	Flags: func(set *flag.FlagSet) {
+		clicontext.OutputFlags(set, "")
+		for _, name := range algorithmNames() {
+			set.Bool(name, false, fmt.Sprintf("use %s algorithm", name))
+		}
+
+		set.Int("xoflen", 0, "Size of output in bytes of extendable output functions")
+		set.String("custom", "", "Customization string of cshake128 and cshake256")
+		set.Bool("list", false, "List supported algorithms with size of output and block")
+		set.Int("jobs", 0, "Number of files hashed in parallel, 0 for number of CPUs")
+		set.Bool("R", false, "Hash files in directories recursively")
+		clicontext.List(set, "include", "Hash only files matching glob with -R, may be given multiple times")
+		clicontext.List(set, "exclude", "Skip files and directories matching glob with -R, may be given multiple times")
+		clicontext.Choice(set, "format", formatBSD, "Format of digest lines", formatNames()...)
+		clicontext.Choice(set, "encoding", encodingHex, "Encoding of digests", encodingNames()...)
+		set.Bool("r", false, "Write digests in coreutils format, same as -format gnu")
+		set.Bool("binary", false, "Write raw digests")
+		set.String("hmac", "", "Calculate HMAC with key")
+		clicontext.Choice(set, "mac", "", "MAC algorithm", macNames()...)
+		clicontext.List(set, "macopt", "MAC option in form name:value, may be given multiple times")
+		set.Bool("c", false, "Read checksums from files and verify them")
+		set.Bool("check", false, "Same as -c")
+		set.Bool("quiet", false, "Do not print OK for verified files, with -c")
+		set.Bool("status", false, "Print nothing, exit status shows the result, with -c")
+		set.Bool("ignore-missing", false, "Do not fail or report missing files, with -c")
+		set.String("sign", "", "Sign file with private key, signature is written to -out")
+		clicontext.Choice(set, "keyform", clicontext.FormatAuto, "Private key format",
+			clicontext.FormatAuto, clicontext.FormatPEM, clicontext.FormatDER)
+		set.String("verify", "", "Verify signature of file with public key, certificate or request")
+		set.String("signature", "", "Signature file, with -verify")
+		clicontext.List(set, "sigopt", "Signature option in form name:value, may be given multiple times")
+		set.Bool("prehashed", false, "File is a digest, with -sign or -verify")
+	},
+	Run:         Main,
+	Subcommands: []*clicontext.Command{manifestCommand},
 }
 
 func Main(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("digest", flag.ExitOnError)
+	if ctx.Bool("list") {
+		return ctx.IO().RenderDocument(newAlgorithmListDocument())
+	}
+
 	algorithms := make([]string, 0, len(algorithmMap))
+	for _, name := range algorithmNames() {
+		if ctx.Bool(name) {
+			algorithms = append(algorithms, name)
+		}
+	}
+
+	if ctx.Int("jobs") < 0 {
+		return clicontext.UsageErrorf("-jobs must not be negative, got %d", ctx.Int("jobs"))
+	}
 
-	for name := range algorithmMap {
-		set.BoolFunc(name, fmt.Sprintf("use %s algorithm", name),
-			algoHandler(&algorithms, name))
+	if ctx.IsSet("sign") || ctx.IsSet("verify") {
+		return signatureCommand(ctx, algorithms)
 	}
 
-	err := ctx.Parse(set)
+	for _, name := range []string{"signature", "sigopt", "prehashed"} {
+		if ctx.IsSet(name) {
+			return clicontext.UsageErrorf("-%s can only be used with -sign or -verify", name)
+		}
+	}
+
+	if ctx.Bool("c") || ctx.Bool("check") {
+		if len(algorithms) > 1 {
+			return clicontext.UsageErrorf("only one algorithm can be specified with -c, got: %s",
+				strings.Join(algorithms, ", "))
+		}
+
+		algorithm := ""
+		if len(algorithms) > 0 {
+			algorithm = algorithms[0]
+		}
+
+		return checkCommand(ctx, algorithm, cliutils.CLIFileList(ctx.Args))
+	}
+
+	if len(algorithms) <= 0 {
+		algorithms = append(algorithms, "sha256")
+	}
+
+	newHashes, err := hashesOf(ctx, algorithms)
 	if err != nil {
 		return err
 	}
 
-	if len(algorithms) > 1 {
-		fmt.Printf("gossl:Error: only one algorithm can be specified, got: %s\n",
-			strings.Join(algorithms, ", "))
+	filter := &fileFilter{
+		Include: ctx.Strings("include"),
+		Exclude: ctx.Strings("exclude"),
+	}
+
+	if err := filter.Validate(); err != nil {
+		return err
 	}
 
-	hashAlgo := "sha256"
-	if len(algorithms) > 0 {
-		hashAlgo = algorithms[0]
+	if !ctx.Bool("R") && (len(filter.Include) > 0 || len(filter.Exclude) > 0) {
+		return clicontext.UsageErrorf("-include and -exclude can only be used with -R")
 	}
 
-	fileList := cliutils.CLIFileList(set.Args())
-	for _, filename := range fileList {
-		err = hashFile(hashAlgo, filename)
-		if err != nil {
-			fmt.Printf("Read error in %s: %s\n", filename, err)
+	format, encoding := ctx.String("format"), ctx.String("encoding")
+	if ctx.Bool("r") {
+		if ctx.IsSet("format") && format != formatGNU {
+			return clicontext.UsageErrorf("-r can not be used with -format %s", format)
+		}
+
+		format = formatGNU
+	}
+
+	if ctx.Bool("binary") && (format != formatBSD || ctx.IsSet("encoding")) {
+		return clicontext.UsageErrorf("-binary can not be used with -r, -format or -encoding")
+	}
+
+	if err := checkFormat(format, encoding, ctx.IsSet("encoding"), algorithms); err != nil {
+		return clicontext.UsageErrorf("%s", err)
+	}
+
+	fileList, err := expandFiles(cliutils.CLIFileList(ctx.Args), ctx.Bool("R"), filter)
+	if err != nil {
+		return err
+	}
+
+	results := make([][]fileDigest, len(fileList))
+	forEachParallel(len(fileList), ctx.Int("jobs"), func(i int, buffer []byte) {
+		results[i] = digestFile(fileList[i], algorithms, newHashes, buffer)
+	})
+
+	failed := 0
+	doc := &digestDocument{
+		Algorithms: algorithms,
+		Files:      make([]fileDigest, 0, len(fileList)*len(algorithms)),
+		Format:     format,
+		Encoding:   encoding,
+		Binary:     ctx.Bool("binary"),
+	}
+
+	for _, files := range results {
+		if len(files[0].Error) > 0 {
+			fmt.Fprintf(os.Stderr, "Read error in %s: %s\n", files[0].File, files[0].Error)
+			failed++
 		}
+
+		doc.Files = append(doc.Files, files...)
+	}
+
+	if err := ctx.IO().RenderDocument(doc); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return clicontext.NewError(clicontext.ErrorKindIO,
+			fmt.Errorf("%d of %d files can not be read", failed, len(fileList)))
 	}
 
 	return nil
 }
+
+// hashesOf returns constructors of algorithms, which are replaced by MAC of the algorithm if
+// -hmac or -mac is given. Names of MACs are written back to algorithms.
+func hashesOf(ctx *clicontext.CommandContext, algorithms []string) ([]func() hash.Hash, error) {
+	if ctx.IsSet("xoflen") && ctx.Int("xoflen") <= 0 {
+		return nil, clicontext.UsageErrorf("-xoflen must be positive, got %d", ctx.Int("xoflen"))
+	}
+
+	if len(algorithms) > 1 && (ctx.IsSet("xoflen") || ctx.IsSet("custom")) {
+		return nil, clicontext.UsageErrorf("-xoflen and -custom can only be used with a single algorithm")
+	}
+
+	newHashes := make([]func() hash.Hash, len(algorithms))
+	for i, algorithm := range algorithms {
+		newHash, err := newHashOf(algorithm, ctx.Int("xoflen"), []byte(ctx.String("custom")))
+		if err != nil {
+			return nil, clicontext.UsageErrorf("%s", err)
+		}
+
+		macName, newMAC, err := macOf(ctx, algorithm)
+		if err != nil {
+			return nil, err
+		}
+
+		if newMAC != nil {
+			if i > 0 && macName == algorithms[0] {
+				return nil, clicontext.UsageErrorf("%s does not use a digest, only one algorithm can be specified", macName)
+			}
+
+			algorithms[i], newHash = macName, newMAC
+		}
+
+		newHashes[i] = newHash
+	}
+
+	return newHashes, nil
+}
+
+// digestFile returns digests of a file of all algorithms, or an error of each algorithm if
+// the file can not be read.
+func digestFile(filename string, algorithms []string, newHashes []func() hash.Hash, buffer []byte) []fileDigest {
+	sums, err := hashFile(newHashes, filename, buffer)
+	files := make([]fileDigest, len(algorithms))
+	for i, algorithm := range algorithms {
+		files[i] = fileDigest{File: filename, Algorithm: algorithm}
+		if err != nil {
+			files[i].Error = err.Error()
+		} else {
+			files[i].Digest = sums[i]
+		}
+	}
+
+	return files
+}
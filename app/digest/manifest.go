@@ -0,0 +1,549 @@
+package digest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/prettyprint"
+)
+
+// Formats of manifests.
+const (
+	manifestJSON  = "json"
+	manifestMtree = "mtree"
+)
+
+// Status of files in verifying manifests.
+const (
+	manifestAdded    = "added"
+	manifestRemoved  = "removed"
+	manifestModified = "modified"
+)
+
+// manifestEntry is a regular file in a manifest, path is relative to the directory in slash
+// separated form, mode is permission bits in octal.
+type manifestEntry struct {
+	Digest prettyprint.Hex `json:"digest"`
+	Mode   string          `json:"mode"`
+	Path   string          `json:"path"`
+	Size   int64           `json:"size"`
+}
+
+// manifest is files of a directory sorted by path, selected by patterns of include and
+// exclude. Fields are in order of names, so that the JSON encoding is canonical.
+type manifest struct {
+	Algorithm string          `json:"algorithm"`
+	Entries   []manifestEntry `json:"entries"`
+	Exclude   []string        `json:"exclude,omitempty"`
+	Include   []string        `json:"include,omitempty"`
+	Version   int             `json:"version"`
+}
+
+// buildManifest hashes regular files of directory root in jobs workers.
+func buildManifest(root string, algorithm string, filter *fileFilter, jobs int) (*manifest, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, clicontext.NewError(clicontext.ErrorKindIO, err)
+	}
+
+	if !info.IsDir() {
+		return nil, clicontext.UsageErrorf("%s is not a directory", root)
+	}
+
+	files, err := walkFiles(root, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifest{
+		Algorithm: algorithm,
+		Entries:   make([]manifestEntry, len(files)),
+		Exclude:   filter.Exclude,
+		Include:   filter.Include,
+		Version:   1,
+	}
+
+	errs := make([]error, len(files))
+	newHashes := []func() hash.Hash{algorithmMap[algorithm]}
+	forEachParallel(len(files), jobs, func(i int, buffer []byte) {
+		info, err := os.Stat(files[i])
+		if err != nil {
+			errs[i] = clicontext.NewError(clicontext.ErrorKindIO, err)
+			return
+		}
+
+		sums, err := hashFile(newHashes, files[i], buffer)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+
+		relative, _ := filepath.Rel(root, files[i])
+		m.Entries[i] = manifestEntry{
+			Digest: sums[0],
+			Mode:   fmt.Sprintf("%04o", info.Mode().Perm()),
+			Path:   filepath.ToSlash(relative),
+			Size:   info.Size(),
+		}
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(m.Entries, func(i, j int) bool {
+		return m.Entries[i].Path < m.Entries[j].Path
+	})
+
+	return m, nil
+}
+
+// mtreeEscape escapes file names as vis(3) does in mtree, characters other than printable
+// ASCII, as well as backslash and hash, are written in octal.
+func mtreeEscape(name string) string {
+	buffer := &strings.Builder{}
+	for _, c := range []byte(name) {
+		if c <= ' ' || c >= 0x7f || c == '\\' || c == '#' {
+			fmt.Fprintf(buffer, "\\%03o", c)
+		} else {
+			buffer.WriteByte(c)
+		}
+	}
+
+	return buffer.String()
+}
+
+func mtreeUnescape(name string) (string, error) {
+	buffer := &strings.Builder{}
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			buffer.WriteByte(name[i])
+			continue
+		}
+
+		if i+4 > len(name) {
+			return "", fmt.Errorf("invalid escape in %s", name)
+		}
+
+		c, err := strconv.ParseUint(name[i+1:i+4], 8, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %s", name)
+		}
+
+		buffer.WriteByte(byte(c))
+		i += 3
+	}
+
+	return buffer.String(), nil
+}
+
+// mtree comments of patterns of files selected in manifest.
+const (
+	mtreeInclude = "# include: "
+	mtreeExclude = "# exclude: "
+)
+
+// Encode writes manifest in canonical JSON, or in BSD mtree format with keyword of digest
+// named by algorithm, e.g. sha256digest. Patterns of mtree manifests are in comments.
+func (m *manifest) Encode(format string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if format == manifestMtree {
+		fmt.Fprintf(buffer, "#mtree v2.0\n")
+		for _, pattern := range m.Include {
+			fmt.Fprintf(buffer, "%s%s\n", mtreeInclude, mtreeEscape(pattern))
+		}
+
+		for _, pattern := range m.Exclude {
+			fmt.Fprintf(buffer, "%s%s\n", mtreeExclude, mtreeEscape(pattern))
+		}
+
+		for _, entry := range m.Entries {
+			fmt.Fprintf(buffer, "./%s type=file size=%d mode=%s %sdigest=%s\n",
+				mtreeEscape(entry.Path), entry.Size, entry.Mode, m.Algorithm, hex.EncodeToString(entry.Digest))
+		}
+
+		return buffer.Bytes(), nil
+	}
+
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(m); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// parseMtree parses manifest in mtree format written by Encode. Keywords other than type,
+// size, mode and digest are ignored.
+func parseMtree(data []byte) (*manifest, error) {
+	m := &manifest{Version: 1}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		for _, comment := range []struct {
+			prefix   string
+			patterns *[]string
+		}{{mtreeInclude, &m.Include}, {mtreeExclude, &m.Exclude}} {
+			if escaped, found := strings.CutPrefix(line, comment.prefix); found {
+				pattern, err := mtreeUnescape(escaped)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", n, err)
+				}
+
+				*comment.patterns = append(*comment.patterns, pattern)
+			}
+		}
+
+		if len(line) <= 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		name, err := mtreeUnescape(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+
+		entry := manifestEntry{Path: strings.TrimPrefix(name, "./")}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			switch {
+			case key == "type" && value != "file":
+				return nil, fmt.Errorf("line %d: type %s is not supported", n, value)
+
+			case key == "size":
+				entry.Size, err = strconv.ParseInt(value, 10, 64)
+
+			case key == "mode":
+				entry.Mode = value
+
+			case strings.HasSuffix(key, "digest"):
+				algorithm := strings.TrimSuffix(key, "digest")
+				if len(m.Algorithm) > 0 && m.Algorithm != algorithm {
+					return nil, fmt.Errorf("line %d: digest of %s is different from %s", n, algorithm, m.Algorithm)
+				}
+
+				m.Algorithm = algorithm
+				entry.Digest, err = hex.DecodeString(value)
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %s", n, key, err)
+			}
+		}
+
+		m.Entries = append(m.Entries, entry)
+	}
+
+	return m, scanner.Err()
+}
+
+// parseManifest parses manifest in JSON or mtree format, detected by the first character.
+func parseManifest(data []byte) (*manifest, error) {
+	var m *manifest
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		m = &manifest{}
+		err = json.Unmarshal(data, m)
+	} else {
+		m, err = parseMtree(data)
+	}
+
+	if err != nil {
+		return nil, clicontext.InputFormatErrorf("invalid manifest: %s", err)
+	}
+
+	if _, found := algorithmMap[m.Algorithm]; !found {
+		return nil, clicontext.InputFormatErrorf("unknown algorithm %s of manifest", m.Algorithm)
+	}
+
+	filter := &fileFilter{Include: m.Include, Exclude: m.Exclude}
+	if err := filter.Validate(); err != nil {
+		return nil, clicontext.InputFormatErrorf("invalid manifest: %s", err)
+	}
+
+	return m, nil
+}
+
+type manifestChange struct {
+	Path   string   `json:"path"`
+	Status string   `json:"status"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// diffManifests returns changes of files from expected to actual, in order of path.
+func diffManifests(expected *manifest, actual *manifest) []manifestChange {
+	entries := make(map[string]manifestEntry, len(actual.Entries))
+	for _, entry := range actual.Entries {
+		entries[entry.Path] = entry
+	}
+
+	changes := make([]manifestChange, 0)
+	for _, old := range expected.Entries {
+		entry, found := entries[old.Path]
+		if !found {
+			changes = append(changes, manifestChange{Path: old.Path, Status: manifestRemoved})
+			continue
+		}
+
+		delete(entries, old.Path)
+		fields := make([]string, 0, 3)
+		if entry.Size != old.Size {
+			fields = append(fields, "size")
+		}
+
+		if entry.Mode != old.Mode {
+			fields = append(fields, "mode")
+		}
+
+		if !bytes.Equal(entry.Digest, old.Digest) {
+			fields = append(fields, "digest")
+		}
+
+		if len(fields) > 0 {
+			changes = append(changes, manifestChange{Path: old.Path, Status: manifestModified, Fields: fields})
+		}
+	}
+
+	for path := range entries {
+		changes = append(changes, manifestChange{Path: path, Status: manifestAdded})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// manifestVerifyDocument is result of verifying a directory with a manifest, Signer is
+// subject of certificate, or file name of key, verifying signature of manifest.
+type manifestVerifyDocument struct {
+	Algorithm string           `json:"algorithm"`
+	Files     int              `json:"files"`
+	Signer    string           `json:"signer,omitempty"`
+	Changes   []manifestChange `json:"changes"`
+}
+
+func (d *manifestVerifyDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinter(w)
+	if len(d.Signer) > 0 {
+		p.Printf("Signature verified by %s\n", d.Signer)
+	}
+
+	for _, change := range d.Changes {
+		if len(change.Fields) > 0 {
+			p.Printf("%s: %s (%s)\n", change.Status, change.Path, strings.Join(change.Fields, ", "))
+		} else {
+			p.Printf("%s: %s\n", change.Status, change.Path)
+		}
+	}
+
+	if len(d.Changes) <= 0 {
+		p.Printf("OK: %s match manifest\n", plural(d.Files, "file", "files"))
+	}
+
+	return p.Err()
+}
+
+// manifestFilter returns filter of files given by -include and -exclude.
+func manifestFilter(ctx *clicontext.CommandContext) (*fileFilter, error) {
+	filter := &fileFilter{
+		Include: ctx.Strings("include"),
+		Exclude: ctx.Strings("exclude"),
+	}
+
+	return filter, filter.Validate()
+}
+
+func manifestDirectory(ctx *clicontext.CommandContext) (string, error) {
+	if len(ctx.Args) != 1 {
+		return "", clicontext.UsageErrorf("exactly one directory must be given")
+	}
+
+	return ctx.Args[0], nil
+}
+
+func manifestCreate(ctx *clicontext.CommandContext) error {
+	root, err := manifestDirectory(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter, err := manifestFilter(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, sigout := ctx.String("sign"), ctx.String("sigout")
+	if (len(key) > 0) != (len(sigout) > 0) {
+		return clicontext.UsageErrorf("-sign and -sigout must be given together")
+	}
+
+	// Manifest and signature written into the directory are not part of it.
+	filter.SkipFiles(ctx.String("out"), sigout)
+
+	m, err := buildManifest(root, ctx.String("digest"), filter, ctx.Int("jobs"))
+	if err != nil {
+		return err
+	}
+
+	data, err := m.Encode(ctx.String("format"))
+	if err != nil {
+		return err
+	}
+
+	if len(key) > 0 {
+		signer, err := loadSigner(key, ctx.String("keyform"))
+		if err != nil {
+			return err
+		}
+
+		signature, err := signData(signer, data)
+		if err != nil {
+			return err
+		}
+
+		out := &clicontext.Context{OutFilename: sigout}
+		if err := out.WriteOutput(signature, false); err != nil {
+			return err
+		}
+	}
+
+	return ctx.IO().WriteOutput(data, false)
+}
+
+func manifestVerify(ctx *clicontext.CommandContext) error {
+	root, err := manifestDirectory(ctx)
+	if err != nil {
+		return err
+	}
+
+	signature, cert := ctx.String("signature"), ctx.String("cert")
+	if (len(signature) > 0) != (len(cert) > 0) {
+		return clicontext.UsageErrorf("-signature and -cert must be given together")
+	}
+
+	data, err := clicontext.ReadFile(ctx.String("in"))
+	if err != nil {
+		return err
+	}
+
+	doc := &manifestVerifyDocument{}
+	if len(signature) > 0 {
+		public, signer, err := loadVerifier(cert, true)
+		if err != nil {
+			return err
+		}
+
+		sig, err := clicontext.ReadFile(signature)
+		if err != nil {
+			return err
+		}
+
+		if err := verifyData(public, data, sig); err != nil {
+			return err
+		}
+
+		doc.Signer = signer
+	}
+
+	expected, err := parseManifest(data)
+	if err != nil {
+		return err
+	}
+
+	// Files are selected by patterns recorded in manifest.
+	filter := &fileFilter{Include: expected.Include, Exclude: expected.Exclude}
+	filter.SkipFiles(ctx.String("in"), signature)
+	actual, err := buildManifest(root, expected.Algorithm, filter, ctx.Int("jobs"))
+	if err != nil {
+		return err
+	}
+
+	doc.Algorithm = expected.Algorithm
+	doc.Files = len(expected.Entries)
+	doc.Changes = diffManifests(expected, actual)
+	if err := ctx.IO().RenderDocument(doc); err != nil {
+		return err
+	}
+
+	if len(doc.Changes) > 0 {
+		counts := map[string]int{}
+		for _, change := range doc.Changes {
+			counts[change.Status]++
+		}
+
+		return clicontext.VerificationErrorf("%d added, %d removed, %d modified",
+			counts[manifestAdded], counts[manifestRemoved], counts[manifestModified])
+	}
+
+	return nil
+}
+
+func manifestFlags(set *flag.FlagSet) {
+	set.Int("jobs", 0, "Number of files hashed in parallel, 0 for number of CPUs")
+}
+
+var manifestCommand = &clicontext.Command{
+	Name:  "manifest",
+	Short: "Create and verify manifests of directories",
+	Long: "Create manifests of regular files in a directory, with path, size, mode and digest of\n" +
+		"every file, in canonical JSON or BSD mtree format. Manifests are signed with RSA,\n" +
+		"ECDSA or Ed25519 keys to a detached signature, and verified with the certificate or\n" +
+		"public key, e.g.\n\n" +
+		"  gossl digest manifest create -sign key.pem -sigout MANIFEST.sig -out MANIFEST release/\n" +
+		"  gossl digest manifest verify -in MANIFEST -signature MANIFEST.sig -cert cert.pem release/\n\n" +
+		"Patterns of -include and -exclude are recorded in the manifest and applied in verifying,\n" +
+		"the manifest and signature files are never part of it. Verifying reports files added,\n" +
+		"removed or modified since the manifest was created.",
+	Subcommands: []*clicontext.Command{
+		{
+			Name:     "create",
+			Short:    "Create manifest of a directory",
+			Usage:    "directory",
+			FileArgs: true,
+			Flags: func(set *flag.FlagSet) {
+				clicontext.OutputFlags(set, "")
+				manifestFlags(set)
+				clicontext.List(set, "include", "Include only files matching glob, may be given multiple times")
+				clicontext.List(set, "exclude", "Skip files and directories matching glob, may be given multiple times")
+				clicontext.Choice(set, "format", manifestJSON, "Format of manifest", manifestJSON, manifestMtree)
+				clicontext.Choice(set, "digest", "sha256", "Digest algorithm of files", algorithmNames()...)
+				set.String("sign", "", "Private key to sign manifest")
+				clicontext.Choice(set, "keyform", clicontext.FormatAuto, "Private key format",
+					clicontext.FormatAuto, clicontext.FormatPEM, clicontext.FormatDER)
+				set.String("sigout", "", "Output file of detached signature, with -sign")
+			},
+			Run: manifestCreate,
+		},
+		{
+			Name:     "verify",
+			Short:    "Verify a directory with manifest",
+			Usage:    "directory",
+			FileArgs: true,
+			Flags: func(set *flag.FlagSet) {
+				set.String("in", "-", "Manifest file, - for stdin")
+				manifestFlags(set)
+				set.String("signature", "", "Detached signature of manifest")
+				set.String("cert", "", "Certificate or public key to verify signature, with -signature")
+			},
+			Run: manifestVerify,
+		},
+	},
+}
@@ -0,0 +1,175 @@
+package digest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeManifestTestFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatalf("create directory failed: %s", err)
+		}
+
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatalf("write file failed: %s", err)
+		}
+	}
+}
+
+func TestMtreeEscape(t *testing.T) {
+	cases := []struct {
+		name    string
+		escaped string
+	}{
+		{"plain.txt", "plain.txt"},
+		{"with space", `with\040space`},
+		{`back\slash#hash`, `back\134slash\043hash`},
+		{"café", `caf\303\251`},
+	}
+
+	for _, c := range cases {
+		escaped := mtreeEscape(c.name)
+		if escaped != c.escaped {
+			t.Errorf("wrong escape of %q: %s, expected %s", c.name, escaped, c.escaped)
+		}
+
+		name, err := mtreeUnescape(escaped)
+		if err != nil || name != c.name {
+			t.Errorf("wrong unescape of %s: %q, %v", escaped, name, err)
+		}
+	}
+
+	for _, invalid := range []string{`\04`, `\999`} {
+		if _, err := mtreeUnescape(invalid); err == nil {
+			t.Errorf("invalid escape %s is accepted", invalid)
+		}
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	writeManifestTestFiles(t, root, map[string]string{
+		"a.txt":          "a",
+		"sub/b c.txt":    "b",
+		"sub/skip.log":   "log",
+		"logs/old.txt":   "old",
+		"sub/#hash.txt":  "hash",
+		"sub/back\\.txt": "back",
+	})
+
+	filter := &fileFilter{Include: []string{"*.txt"}, Exclude: []string{"logs"}}
+	m, err := buildManifest(root, "sha256", filter, 2)
+	if err != nil {
+		t.Fatalf("build manifest failed: %s", err)
+	}
+
+	paths := make([]string, len(m.Entries))
+	for i, entry := range m.Entries {
+		paths[i] = entry.Path
+	}
+
+	expected := []string{"a.txt", "sub/#hash.txt", "sub/b c.txt", "sub/back\\.txt"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("wrong files of manifest: %q, expected %q", paths, expected)
+	}
+
+	for _, format := range []string{manifestJSON, manifestMtree} {
+		data, err := m.Encode(format)
+		if err != nil {
+			t.Fatalf("%s: encode failed: %s", format, err)
+		}
+
+		parsed, err := parseManifest(data)
+		if err != nil {
+			t.Fatalf("%s: parse failed: %s", format, err)
+		}
+
+		if !reflect.DeepEqual(parsed, m) {
+			t.Errorf("%s: wrong manifest parsed: %+v, expected %+v", format, parsed, m)
+		}
+	}
+
+	if _, err := parseManifest([]byte(`{"algorithm":"none","entries":[],"version":1}`)); err == nil {
+		t.Errorf("manifest of unknown algorithm is accepted")
+	}
+
+	if _, err := parseManifest([]byte("#mtree v2.0\n# include: [\n")); err == nil {
+		t.Errorf("manifest of invalid pattern is accepted")
+	}
+}
+
+func TestDiffManifests(t *testing.T) {
+	root := t.TempDir()
+	writeManifestTestFiles(t, root, map[string]string{
+		"same.txt":     "same",
+		"changed.txt":  "before",
+		"removed.txt":  "removed",
+		"resized.txt":  "short",
+		"dir/mode.txt": "mode",
+	})
+
+	expected, err := buildManifest(root, "sha256", &fileFilter{}, 0)
+	if err != nil {
+		t.Fatalf("build manifest failed: %s", err)
+	}
+
+	writeManifestTestFiles(t, root, map[string]string{
+		"changed.txt": "after!",
+		"resized.txt": "much longer",
+		"added.txt":   "added",
+	})
+
+	if err := os.Remove(filepath.Join(root, "removed.txt")); err != nil {
+		t.Fatalf("remove file failed: %s", err)
+	}
+
+	if err := os.Chmod(filepath.Join(root, "dir", "mode.txt"), 0600); err != nil {
+		t.Fatalf("chmod failed: %s", err)
+	}
+
+	actual, err := buildManifest(root, "sha256", &fileFilter{}, 0)
+	if err != nil {
+		t.Fatalf("build manifest failed: %s", err)
+	}
+
+	changes := diffManifests(expected, actual)
+	want := []manifestChange{
+		{Path: "added.txt", Status: manifestAdded},
+		{Path: "changed.txt", Status: manifestModified, Fields: []string{"digest"}},
+		{Path: "dir/mode.txt", Status: manifestModified, Fields: []string{"mode"}},
+		{Path: "removed.txt", Status: manifestRemoved},
+		{Path: "resized.txt", Status: manifestModified, Fields: []string{"size", "digest"}},
+	}
+
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("wrong changes: %+v, expected %+v", changes, want)
+	}
+
+	if changes := diffManifests(actual, actual); len(changes) != 0 {
+		t.Errorf("changes of the same manifest: %+v", changes)
+	}
+}
+
+func TestManifestSkipFiles(t *testing.T) {
+	root := t.TempDir()
+	writeManifestTestFiles(t, root, map[string]string{
+		"a.txt":        "a",
+		"MANIFEST":     "manifest",
+		"MANIFEST.sig": "signature",
+	})
+
+	filter := &fileFilter{}
+	filter.SkipFiles(filepath.Join(root, "MANIFEST"), filepath.Join(root, "MANIFEST.sig"), "-", "")
+	m, err := buildManifest(root, "sha256", filter, 0)
+	if err != nil {
+		t.Fatalf("build manifest failed: %s", err)
+	}
+
+	if len(m.Entries) != 1 || m.Entries[0].Path != "a.txt" {
+		t.Errorf("wrong entries: %+v", m.Entries)
+	}
+}
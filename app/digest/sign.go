@@ -0,0 +1,528 @@
+package digest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/cliutils"
+	"github.com/flily/go-ssl/common/prettyprint"
+	"github.com/flily/go-ssl/modules/asn1"
+	"github.com/flily/go-ssl/modules/cipher"
+)
+
+// Paddings of RSA signatures.
+const (
+	paddingPKCS1 = "pkcs1"
+	paddingPSS   = "pss"
+)
+
+// Formats of ECDSA signatures, raw is r || s in size of the curve as used by JWS.
+const (
+	ecdsaFormatDER = "der"
+	ecdsaFormatRaw = "raw"
+)
+
+// digestInfoOIDs are OIDs of digest algorithms in DigestInfo of PKCS #1 v1.5 signatures.
+var digestInfoOIDs = map[string]*asn1.ASN1ObjectIdentifier{
+	"md4":         asn1.OidMD4,
+	"md5":         asn1.OidMD5,
+	"sha1":        asn1.OidSHA1,
+	"sha224":      asn1.OidSHA224,
+	"sha256":      asn1.OidSHA256,
+	"sha384":      asn1.OidSHA384,
+	"sha512":      asn1.OidSHA512,
+	"sha512-224":  asn1.OidSHA512224,
+	"sha512-256":  asn1.OidSHA512256,
+	"sha3-224":    asn1.OidSHA3224,
+	"sha3-256":    asn1.OidSHA3256,
+	"sha3-384":    asn1.OidSHA3384,
+	"sha3-512":    asn1.OidSHA3512,
+	"shake128":    asn1.OidSHAKE128,
+	"shake256":    asn1.OidSHAKE256,
+	"blake2b-256": asn1.OidBLAKE2b256,
+	"blake2b-384": asn1.OidBLAKE2b384,
+	"blake2b-512": asn1.OidBLAKE2b512,
+	"blake2s-256": asn1.OidBLAKE2s256,
+	"ripemd160":   asn1.OidRIPEMD160,
+	"sm3":         asn1.OidSM3,
+}
+
+// cryptoHashes are digest algorithms known by crypto, PSS signatures of them are created by
+// crypto/rsa if MGF1 uses the same algorithm.
+var cryptoHashes = map[string]crypto.Hash{
+	"md4":         crypto.MD4,
+	"md5":         crypto.MD5,
+	"sha1":        crypto.SHA1,
+	"sha224":      crypto.SHA224,
+	"sha256":      crypto.SHA256,
+	"sha384":      crypto.SHA384,
+	"sha512":      crypto.SHA512,
+	"sha512-224":  crypto.SHA512_224,
+	"sha512-256":  crypto.SHA512_256,
+	"sha3-224":    crypto.SHA3_224,
+	"sha3-256":    crypto.SHA3_256,
+	"sha3-384":    crypto.SHA3_384,
+	"sha3-512":    crypto.SHA3_512,
+	"blake2b-256": crypto.BLAKE2b_256,
+	"blake2b-384": crypto.BLAKE2b_384,
+	"blake2b-512": crypto.BLAKE2b_512,
+	"blake2s-256": crypto.BLAKE2s_256,
+	"ripemd160":   crypto.RIPEMD160,
+}
+
+// signatureOptions are options of signatures given by -sigopt in names of openssl. Empty
+// Algorithm means the message itself is signed, which is only supported by Ed25519.
+type signatureOptions struct {
+	Algorithm   string
+	Padding     string
+	SaltLength  int
+	MGFHash     string
+	ECDSAFormat string
+}
+
+func newSignatureOptions(algorithm string) *signatureOptions {
+	return &signatureOptions{
+		Algorithm:   algorithm,
+		Padding:     paddingPKCS1,
+		SaltLength:  cipher.PSSSaltLengthAuto,
+		MGFHash:     algorithm,
+		ECDSAFormat: ecdsaFormatDER,
+	}
+}
+
+// Set sets an option in form name:value.
+func (o *signatureOptions) Set(option string) error {
+	name, value, found := strings.Cut(option, ":")
+	if !found {
+		return fmt.Errorf("invalid signature option %s, must be in form name:value", option)
+	}
+
+	switch name {
+	case "rsa_padding_mode":
+		if value != paddingPKCS1 && value != paddingPSS {
+			return fmt.Errorf("unknown rsa_padding_mode %s, must be pkcs1 or pss", value)
+		}
+
+		o.Padding = value
+
+	case "rsa_pss_saltlen":
+		switch value {
+		case "digest":
+			o.SaltLength = cipher.PSSSaltLengthEqualsHash
+
+		case "max":
+			o.SaltLength = cipher.PSSSaltLengthMax
+
+		case "auto":
+			o.SaltLength = cipher.PSSSaltLengthAuto
+
+		default:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid rsa_pss_saltlen %s, must be digest, max, auto or a number", value)
+			}
+
+			o.SaltLength = n
+		}
+
+	case "rsa_mgf1_md":
+		algorithm := normalizeAlgorithm(value)
+		if _, found := algorithmMap[algorithm]; !found {
+			return fmt.Errorf("unknown digest %s of rsa_mgf1_md", value)
+		}
+
+		o.MGFHash = algorithm
+
+	case "ecdsa_format":
+		if value != ecdsaFormatDER && value != ecdsaFormatRaw {
+			return fmt.Errorf("unknown ecdsa_format %s, must be der or raw", value)
+		}
+
+		o.ECDSAFormat = value
+
+	default:
+		return fmt.Errorf("unknown signature option %s", name)
+	}
+
+	return nil
+}
+
+func (o *signatureOptions) pssOptions() *cipher.PSSOptions {
+	opts := &cipher.PSSOptions{
+		SaltLength: o.SaltLength,
+		Hash:       algorithmMap[o.Algorithm],
+		StdHash:    cryptoHashes[o.Algorithm],
+	}
+
+	if o.MGFHash != o.Algorithm {
+		opts.MGFHash = algorithmMap[o.MGFHash]
+	}
+
+	return opts
+}
+
+// digestInfo returns DigestInfo of digest signed in PKCS #1 v1.5.
+func (o *signatureOptions) digestInfo(digest []byte) ([]byte, error) {
+	oid, found := digestInfoOIDs[o.Algorithm]
+	if !found {
+		return nil, clicontext.UsageErrorf("%s can not be used in PKCS #1 v1.5 signatures", o.Algorithm)
+	}
+
+	return asn1.Marshal(asn1.NewSequence(
+		asn1.NewSequence(oid, asn1.NewNull()),
+		asn1.NewOctetStringFromBytes(digest),
+	))
+}
+
+// loadSigner loads private key from filename for signing.
+func loadSigner(filename string, form string) (crypto.Signer, error) {
+	container, err := clicontext.LoadContainer(filename, form)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := container.FirstPrivateKey().(crypto.Signer)
+	if !ok {
+		return nil, clicontext.InputFormatErrorf("%s: no private key for signing found", filename)
+	}
+
+	return signer, nil
+}
+
+// loadVerifier loads public key from filename of a certificate, certificate request, public
+// key or private key. It returns subject of the certificate as name of signer, or filename
+// for others. With checkValidity, it fails if the certificate is not valid now.
+func loadVerifier(filename string, checkValidity bool) (crypto.PublicKey, string, error) {
+	container, err := clicontext.LoadContainer(filename, clicontext.FormatAuto)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for c := container; c != nil; c = c.Next() {
+		cert := c.Certificate()
+		if cert == nil {
+			continue
+		}
+
+		now := time.Now()
+		if checkValidity && (now.Before(cert.NotBefore) || now.After(cert.NotAfter)) {
+			return nil, "", clicontext.VerificationErrorf("certificate %s is not valid now, valid from %s to %s",
+				cert.Subject, cert.NotBefore.UTC(), cert.NotAfter.UTC())
+		}
+
+		return cert.PublicKey, cert.Subject.String(), nil
+	}
+
+	public := container.FirstSubjectPublicKey()
+	if public == nil {
+		return nil, "", clicontext.InputFormatErrorf("%s: no public key found", filename)
+	}
+
+	return public, filename, nil
+}
+
+// signDigest signs digest of opts.Algorithm. Ed25519 keys sign the message itself if
+// Algorithm is empty, or digest in Ed25519ph of SHA-512.
+func signDigest(signer crypto.Signer, digest []byte, opts *signatureOptions) ([]byte, error) {
+	switch public := signer.Public().(type) {
+	case ed25519.PublicKey:
+		if len(opts.Algorithm) <= 0 {
+			return signer.Sign(rand.Reader, digest, crypto.Hash(0))
+		}
+
+		return signer.Sign(rand.Reader, digest, &ed25519.Options{Hash: crypto.SHA512})
+
+	case *rsa.PublicKey:
+		if opts.Padding == paddingPSS {
+			key, ok := signer.(*rsa.PrivateKey)
+			if !ok {
+				return nil, clicontext.UsageErrorf("key of %T does not support PSS", signer)
+			}
+
+			signature, err := cipher.SignPSS(rand.Reader, key, digest, opts.pssOptions())
+			if err != nil {
+				return nil, clicontext.UsageErrorf("%s", err)
+			}
+
+			return signature, nil
+		}
+
+		info, err := opts.digestInfo(digest)
+		if err != nil {
+			return nil, err
+		}
+
+		return signer.Sign(rand.Reader, info, crypto.Hash(0))
+
+	case *ecdsa.PublicKey:
+		// Digests of algorithms out of crypto.Hash can not be signed by crypto.Signer.
+		key, ok := signer.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, clicontext.UsageErrorf("key of %T does not support signing", signer)
+		}
+
+		if opts.ECDSAFormat != ecdsaFormatRaw {
+			return ecdsa.SignASN1(rand.Reader, key, digest)
+		}
+
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, err
+		}
+
+		size := (public.Curve.Params().BitSize + 7) / 8
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+		return signature, nil
+	}
+
+	return nil, clicontext.UsageErrorf("key of %T does not support signing", signer.Public())
+}
+
+// verifyDigest verifies signature of digest created by signDigest.
+func verifyDigest(public crypto.PublicKey, digest []byte, signature []byte, opts *signatureOptions) error {
+	valid := false
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		if len(opts.Algorithm) <= 0 {
+			valid = ed25519.Verify(key, digest, signature)
+		} else {
+			valid = ed25519.VerifyWithOptions(key, digest, signature, &ed25519.Options{Hash: crypto.SHA512}) == nil
+		}
+
+	case *rsa.PublicKey:
+		if opts.Padding == paddingPSS {
+			valid = cipher.VerifyPSSSignature(key, digest, signature, opts.pssOptions()) == nil
+			break
+		}
+
+		info, err := opts.digestInfo(digest)
+		if err != nil {
+			return err
+		}
+
+		valid = rsa.VerifyPKCS1v15(key, crypto.Hash(0), info, signature) == nil
+
+	case *ecdsa.PublicKey:
+		if opts.ECDSAFormat != ecdsaFormatRaw {
+			valid = ecdsa.VerifyASN1(key, digest, signature)
+			break
+		}
+
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			valid = ecdsa.Verify(key, digest, r, s)
+		}
+
+	default:
+		return clicontext.UsageErrorf("key of %T does not support verifying", public)
+	}
+
+	if !valid {
+		return clicontext.VerificationErrorf("signature verification failure")
+	}
+
+	return nil
+}
+
+// signData signs data with SHA-256, in PKCS #1 v1.5 for RSA, ASN.1 DER for ECDSA, and
+// pure Ed25519 of data itself.
+func signData(signer crypto.Signer, data []byte) ([]byte, error) {
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		return signDigest(signer, data, newSignatureOptions(""))
+	}
+
+	sum := sha256.Sum256(data)
+	return signDigest(signer, sum[:], newSignatureOptions("sha256"))
+}
+
+// verifyData verifies signature of data created by signData.
+func verifyData(public crypto.PublicKey, data []byte, signature []byte) error {
+	if _, ok := public.(ed25519.PublicKey); ok {
+		return verifyDigest(public, data, signature, newSignatureOptions(""))
+	}
+
+	sum := sha256.Sum256(data)
+	return verifyDigest(public, sum[:], signature, newSignatureOptions("sha256"))
+}
+
+// verifyDocument is result of verifying signature of a file, Signer is subject of
+// certificate, or file name of key. Algorithm is empty for pure Ed25519.
+type verifyDocument struct {
+	File      string `json:"file"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Signer    string `json:"signer"`
+	Verified  bool   `json:"verified"`
+}
+
+func (d *verifyDocument) WriteText(w io.Writer) error {
+	p := prettyprint.NewPrinter(w)
+	if d.Verified {
+		p.Printf("Verified OK\n")
+	} else {
+		p.Printf("Verification failure\n")
+	}
+
+	return p.Err()
+}
+
+// signatureInput returns digest of file, or the file itself if it is signed without digest
+// or is a digest given by -prehashed.
+func signatureInput(ctx *clicontext.CommandContext, filename string, opts *signatureOptions) ([]byte, error) {
+	if len(opts.Algorithm) <= 0 {
+		return clicontext.ReadFile(filename)
+	}
+
+	newHashes, err := hashesOf(ctx, []string{opts.Algorithm})
+	if err != nil {
+		return nil, err
+	}
+
+	if !ctx.Bool("prehashed") {
+		sums, err := hashFile(newHashes, filename, make([]byte, bufferSize))
+		if err != nil {
+			return nil, err
+		}
+
+		return sums[0], nil
+	}
+
+	digest, err := clicontext.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if size := newHashes[0]().Size(); len(digest) != size {
+		return nil, clicontext.InputFormatErrorf("%s: digest of %s must be %d bytes, got %d bytes",
+			filename, opts.Algorithm, size, len(digest))
+	}
+
+	return digest, nil
+}
+
+// signatureCommand signs a file with -sign, or verifies signature of a file with -verify.
+// algorithms are digest algorithms given on command line.
+func signatureCommand(ctx *clicontext.CommandContext, algorithms []string) error {
+	key, verifier := ctx.String("sign"), ctx.String("verify")
+	if len(key) > 0 && len(verifier) > 0 {
+		return clicontext.UsageErrorf("-sign and -verify can not be used together")
+	}
+
+	for _, name := range []string{"c", "check", "R", "hmac", "mac", "format", "encoding", "r", "binary"} {
+		if ctx.IsSet(name) {
+			return clicontext.UsageErrorf("-%s can not be used with -sign or -verify", name)
+		}
+	}
+
+	if len(algorithms) > 1 {
+		return clicontext.UsageErrorf("only one algorithm can be specified with -sign or -verify, got: %s",
+			strings.Join(algorithms, ", "))
+	}
+
+	files := cliutils.CLIFileList(ctx.Args)
+	if len(files) != 1 {
+		return clicontext.UsageErrorf("only one file can be signed or verified, got %d", len(files))
+	}
+
+	signatureFile := ctx.String("signature")
+	if len(verifier) > 0 && len(signatureFile) <= 0 {
+		return clicontext.UsageErrorf("-signature is required with -verify")
+	}
+
+	if len(key) > 0 && len(signatureFile) > 0 {
+		return clicontext.UsageErrorf("-signature can only be used with -verify")
+	}
+
+	var signer crypto.Signer
+	var public crypto.PublicKey
+	name := ""
+	var err error
+	if len(key) > 0 {
+		signer, err = loadSigner(key, ctx.String("keyform"))
+		if err == nil {
+			public = signer.Public()
+		}
+	} else {
+		public, name, err = loadVerifier(verifier, false)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	algorithm := "sha256"
+	if len(algorithms) > 0 {
+		algorithm = algorithms[0]
+	}
+
+	if _, ok := public.(ed25519.PublicKey); ok {
+		switch {
+		case !ctx.Bool("prehashed") && len(algorithms) > 0:
+			return clicontext.UsageErrorf("Ed25519 signs files without digest, use -prehashed -sha512 for Ed25519ph")
+
+		case !ctx.Bool("prehashed"):
+			algorithm = ""
+
+		case algorithm != "sha512":
+			return clicontext.UsageErrorf("Ed25519ph only signs digests of sha512, got %s", algorithm)
+		}
+	}
+
+	opts := newSignatureOptions(algorithm)
+	for _, option := range ctx.Strings("sigopt") {
+		if err := opts.Set(option); err != nil {
+			return clicontext.UsageErrorf("%s", err)
+		}
+	}
+
+	input, err := signatureInput(ctx, files[0], opts)
+	if err != nil {
+		return err
+	}
+
+	if signer != nil {
+		signature, err := signDigest(signer, input, opts)
+		if err != nil {
+			return err
+		}
+
+		return ctx.IO().WriteOutput(signature, false)
+	}
+
+	signature, err := clicontext.ReadFile(signatureFile)
+	if err != nil {
+		return err
+	}
+
+	verifyErr := verifyDigest(public, input, signature, opts)
+	if verifyErr != nil && clicontext.ErrorKindOf(verifyErr) != clicontext.ErrorKindVerification {
+		return verifyErr
+	}
+
+	doc := &verifyDocument{
+		File:      files[0],
+		Algorithm: algorithm,
+		Signer:    name,
+		Verified:  verifyErr == nil,
+	}
+
+	if err := ctx.IO().RenderDocument(doc); err != nil {
+		return err
+	}
+
+	return verifyErr
+}
@@ -0,0 +1,115 @@
+package digest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/flily/go-ssl/modules/cipher"
+)
+
+func TestSignatureOptions(t *testing.T) {
+	cases := []struct {
+		options  []string
+		expected signatureOptions
+	}{
+		{nil, signatureOptions{"sha256", paddingPKCS1, cipher.PSSSaltLengthAuto, "sha256", ecdsaFormatDER}},
+		{[]string{"rsa_padding_mode:pss", "rsa_pss_saltlen:digest"},
+			signatureOptions{"sha256", paddingPSS, cipher.PSSSaltLengthEqualsHash, "sha256", ecdsaFormatDER}},
+		{[]string{"rsa_pss_saltlen:max", "rsa_mgf1_md:SHA1"},
+			signatureOptions{"sha256", paddingPKCS1, cipher.PSSSaltLengthMax, "sha1", ecdsaFormatDER}},
+		{[]string{"rsa_pss_saltlen:20", "rsa_mgf1_md:SHA2-512"},
+			signatureOptions{"sha256", paddingPKCS1, 20, "sha512", ecdsaFormatDER}},
+		{[]string{"ecdsa_format:raw"}, signatureOptions{"sha256", paddingPKCS1, cipher.PSSSaltLengthAuto, "sha256", ecdsaFormatRaw}},
+	}
+
+	for _, c := range cases {
+		opts := newSignatureOptions("sha256")
+		for _, option := range c.options {
+			if err := opts.Set(option); err != nil {
+				t.Fatalf("set %s failed: %s", option, err)
+			}
+		}
+
+		if *opts != c.expected {
+			t.Errorf("wrong options of %v: %+v, expected %+v", c.options, *opts, c.expected)
+		}
+	}
+
+	invalid := []string{
+		"rsa_padding_mode",
+		"rsa_padding_mode:oaep",
+		"rsa_pss_saltlen:-1",
+		"rsa_pss_saltlen:some",
+		"rsa_mgf1_md:none",
+		"ecdsa_format:p1363",
+		"unknown:value",
+	}
+
+	for _, option := range invalid {
+		if err := newSignatureOptions("sha256").Set(option); err == nil {
+			t.Errorf("invalid option %s is accepted", option)
+		}
+	}
+}
+
+func TestPSSOptionsOfSignature(t *testing.T) {
+	opts := newSignatureOptions("sha256")
+	if pss := opts.pssOptions(); pss.MGFHash != nil || pss.StdHash != crypto.SHA256 {
+		t.Errorf("PSS with the same MGF1 hash is not standard: %+v", pss)
+	}
+
+	opts.MGFHash = "sha1"
+	if pss := opts.pssOptions(); pss.MGFHash == nil {
+		t.Errorf("MGF1 hash of sha1 is ignored")
+	}
+}
+
+func TestSignAndVerifyDigest(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	digest := sha256.Sum256([]byte("message"))
+
+	// Ed25519 signs the message itself without algorithm.
+	cases := []struct {
+		signer    crypto.Signer
+		algorithm string
+		options   []string
+	}{
+		{rsaKey, "sha256", nil},
+		{rsaKey, "sha256", []string{"rsa_padding_mode:pss"}},
+		{rsaKey, "sha256", []string{"rsa_padding_mode:pss", "rsa_pss_saltlen:0", "rsa_mgf1_md:sha1"}},
+		{ecKey, "sha256", nil},
+		{ecKey, "sha256", []string{"ecdsa_format:raw"}},
+		{edKey, "", nil},
+	}
+
+	for _, c := range cases {
+		opts := newSignatureOptions(c.algorithm)
+		for _, option := range c.options {
+			if err := opts.Set(option); err != nil {
+				t.Fatalf("set %s failed: %s", option, err)
+			}
+		}
+
+		signature, err := signDigest(c.signer, digest[:], opts)
+		if err != nil {
+			t.Fatalf("%T %v: sign failed: %s", c.signer, c.options, err)
+		}
+
+		if err := verifyDigest(c.signer.Public(), digest[:], signature, opts); err != nil {
+			t.Errorf("%T %v: verify failed: %s", c.signer, c.options, err)
+		}
+
+		wrong := sha256.Sum256([]byte("other message"))
+		if err := verifyDigest(c.signer.Public(), wrong[:], signature, opts); err == nil {
+			t.Errorf("%T %v: signature of other digest is accepted", c.signer, c.options)
+		}
+	}
+}
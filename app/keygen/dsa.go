@@ -0,0 +1,36 @@
+package keygen
+
+import (
+	"crypto/rand"
+	"flag"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/modules/cipher"
+)
+
+var GenDSACommand = &clicontext.Command{
+	Name:  "gendsa",
+	Short: "Generate a DSA private key",
+	Flags: func(set *flag.FlagSet) {
+		clicontext.OutputFlags(set, clicontext.FormatPEM, clicontext.FormatPEM, clicontext.FormatDER)
+		set.Int("bits", 2048, "Size of the key, one of 1024, 2048 and 3072")
+	},
+	FlagValues: map[string][]string{
+		"bits": {"1024", "2048", "3072"},
+	},
+	Run: genDSACommand,
+}
+
+func genDSACommand(ctx *clicontext.CommandContext) error {
+	params, err := cipher.GenerateDSAParameters(rand.Reader, ctx.Int("bits"))
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := cipher.GenerateDSAKey(rand.Reader, params)
+	if err != nil {
+		return err
+	}
+
+	return ctx.IO().WriteObject("PRIVATE KEY", privateKey.DER(), true)
+}
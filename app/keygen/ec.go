@@ -3,7 +3,7 @@ package keygen
 import (
 	"crypto/elliptic"
 	"flag"
-	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/flily/go-ssl/common/clicontext"
@@ -22,17 +22,34 @@ var curves = map[string]elliptic.Curve{
 	"secp521r1":  elliptic.P521(),
 }
 
-func MainGenEC(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("genec", flag.ExitOnError)
-	curveName := set.String("curve", "P256", "Curve name, one of P224, P256, P384 and P521")
-	err := ctx.Parse(set)
-	if err != nil {
-		return err
+var GenECCommand = &clicontext.Command{
+	Name:  "genecdsa",
+	Short: "Generate an EC private key",
+	Flags: func(set *flag.FlagSet) {
+		clicontext.OutputFlags(set, clicontext.FormatPEM, clicontext.FormatPEM, clicontext.FormatDER)
+		set.String("curve", "P256", "Curve name, one of P224, P256, P384 and P521")
+	},
+	FlagValues: map[string][]string{
+		"curve": curveNames(),
+	},
+	Run: genECCommand,
+}
+
+func curveNames() []string {
+	names := make([]string, 0, len(curves))
+	for name := range curves {
+		names = append(names, name)
 	}
 
-	curve, found := curves[strings.ToLower(*curveName)]
+	sort.Strings(names)
+	return names
+}
+
+func genECCommand(ctx *clicontext.CommandContext) error {
+	curveName := ctx.String("curve")
+	curve, found := curves[strings.ToLower(curveName)]
 	if !found {
-		return fmt.Errorf("Unknown curve name: %s", *curveName)
+		return clicontext.UsageErrorf("Unknown curve name: %s", curveName)
 	}
 
 	privateKey, err := cipher.GenerateECKey(curve)
@@ -40,7 +57,5 @@ func MainGenEC(ctx *clicontext.CommandContext) error {
 		return err
 	}
 
-	fmt.Printf("%s", privateKey.PEM())
-
-	return nil
+	return ctx.IO().WriteObject("EC PRIVATE KEY", privateKey.DER(), true)
 }
@@ -3,41 +3,141 @@ package keygen
 import (
 	"crypto/rand"
 	"flag"
-	"fmt"
 	"io"
+	"os"
+	"strings"
 
 	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/encoder"
 	"github.com/flily/go-ssl/modules/cipher"
 )
 
 type GenerateRSAKeyConfigure struct {
-	Random io.Reader
-	Bits   int
+	Random   io.Reader
+	Bits     int
+	Primes   int
+	Exponent int
 }
 
-func GenerateRSAKey(conf *GenerateRSAKeyConfigure) {
-	privateKey, err := cipher.GenerateRSAKey(conf.Random, conf.Bits)
-	if err != nil {
-		panic(err)
+// GenerateRSAKey generates a RSA key, keys of two primes and exponent 65537 are generated by
+// crypto/rsa.
+func GenerateRSAKey(conf *GenerateRSAKeyConfigure) (*cipher.RSAPrivateKey, error) {
+	if conf.Primes == 2 && conf.Exponent == 65537 {
+		return cipher.GenerateRSAKey(conf.Random, conf.Bits)
+	}
+
+	return cipher.GenerateMultiPrimeRSAKey(conf.Random, conf.Primes, conf.Bits, conf.Exponent)
+}
+
+// readPassword reads password given in form of openssl -passout, i.e. pass:password,
+// env:name or file:name of which the first line is the password.
+func readPassword(source string) ([]byte, error) {
+	kind, value, _ := strings.Cut(source, ":")
+	switch kind {
+	case "pass":
+		return []byte(value), nil
+
+	case "env":
+		password, found := os.LookupEnv(value)
+		if !found {
+			return nil, clicontext.UsageErrorf("environment variable %s of password is not set", value)
+		}
+
+		return []byte(password), nil
+
+	case "file":
+		data, err := clicontext.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+
+		line, _, _ := strings.Cut(string(data), "\n")
+		return []byte(strings.TrimSuffix(line, "\r")), nil
 	}
 
-	fmt.Printf("%s", string(privateKey.PKCS8PrivateKeyPEM()))
+	return nil, clicontext.UsageErrorf("invalid password source %s, must be pass:, env: or file:", source)
+}
+
+var GenRSACommand = &clicontext.Command{
+	Name:  "genrsa",
+	Short: "Generate a RSA private key",
+	Long: "Generate a RSA private key, written in PKCS#8 by default, or in PKCS#1 with -format\n" +
+		"pkcs1. Keys of more than two primes are generated with -primes, up to 3 primes for\n" +
+		"keys less than 4096 bits, 4 for 8192 bits and 5 for larger keys.\n\n" +
+		"With -cipher, PKCS#8 keys are encrypted in PBES2 with PBKDF2-HMAC-SHA256 of -iter\n" +
+		"iterations, and password given by -passout, e.g.\n\n" +
+		"  gossl genrsa -bits 3072 -cipher aes-256-cbc -passout env:KEY_PASSWORD -out key.pem\n\n" +
+		"Password sources are pass:password, env:name, and file:name of which the first line\n" +
+		"is the password. Files of -out are only accessible by owner.",
+	Flags: func(set *flag.FlagSet) {
+		clicontext.OutputFlags(set, clicontext.FormatPEM, clicontext.FormatPEM, clicontext.FormatDER)
+		set.Int("bits", 2048, "Size of the key")
+		set.Int("primes", 2, "Number of primes")
+		set.Int("e", 65537, "Public exponent, odd and at least 3")
+		clicontext.Choice(set, "format", "pkcs8", "Private key format", "pkcs8", "pkcs1")
+		clicontext.Choice(set, "cipher", "", "Cipher to encrypt the key", encoder.PBES2CipherNames()...)
+		set.String("passout", "", "Password source of encryption, with -cipher")
+		set.Int("iter", 600000, "Iterations of PBKDF2, with -cipher")
+	},
+	Run: genRSACommand,
 }
 
-func MainGenRSA(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("genrsa", flag.ExitOnError)
-	bits := set.Int("bits", 2048, "Size of the key")
-	err := ctx.Parse(set)
+func genRSACommand(ctx *clicontext.CommandContext) error {
+	conf := &GenerateRSAKeyConfigure{
+		Random:   rand.Reader,
+		Bits:     ctx.Int("bits"),
+		Primes:   ctx.Int("primes"),
+		Exponent: ctx.Int("e"),
+	}
+
+	if err := cipher.CheckRSAKeyParameters(conf.Primes, conf.Bits, conf.Exponent); err != nil {
+		return clicontext.UsageErrorf("%s", err)
+	}
+
+	encryption := ctx.String("cipher")
+	if len(encryption) > 0 {
+		if ctx.String("format") != "pkcs8" {
+			return clicontext.UsageErrorf("only PKCS#8 keys can be encrypted, -format pkcs1 can not be used with -cipher")
+		}
+
+		if len(ctx.String("passout")) <= 0 {
+			return clicontext.UsageErrorf("-passout is required with -cipher")
+		}
+
+		if ctx.Int("iter") <= 0 {
+			return clicontext.UsageErrorf("-iter must be positive, got %d", ctx.Int("iter"))
+		}
+	} else if ctx.IsSet("passout") || ctx.IsSet("iter") {
+		return clicontext.UsageErrorf("-passout and -iter can only be used with -cipher")
+	}
+
+	var password []byte
+	if len(encryption) > 0 {
+		var err error
+		password, err = readPassword(ctx.String("passout"))
+		if err != nil {
+			return err
+		}
+	}
+
+	privateKey, err := GenerateRSAKey(conf)
 	if err != nil {
 		return err
 	}
 
-	conf := &GenerateRSAKeyConfigure{
-		Random: rand.Reader,
-		Bits:   *bits,
+	if ctx.String("format") == "pkcs1" {
+		return ctx.IO().WriteObject("RSA PRIVATE KEY", privateKey.PKCS1PrivateKey(), true)
+	}
+
+	if len(encryption) <= 0 {
+		return ctx.IO().WriteObject("PRIVATE KEY", privateKey.PKCS8PrivateKey(), true)
 	}
 
-	GenerateRSAKey(conf)
+	encrypted, err := encoder.EncryptPKCS8PrivateKey(rand.Reader, privateKey.PKCS8PrivateKey(), password,
+		encryption, ctx.Int("iter"))
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return ctx.IO().WriteObject("ENCRYPTED PRIVATE KEY", encrypted, true)
 }
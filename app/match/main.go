@@ -0,0 +1,145 @@
+package match
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/encoder"
+)
+
+type skippedFile struct {
+	filename string
+	err      error
+}
+
+func listFiles(paths []string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.Type().IsRegular() {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func collectEntries(files []string) ([]*encoder.MatchEntry, []skippedFile) {
+	entries := make([]*encoder.MatchEntry, 0, len(files))
+	skipped := make([]skippedFile, 0)
+	for _, filename := range files {
+		chain, errs, err := encoder.ParseContainerChainLenientFromFile(filename)
+		if err != nil {
+			skipped = append(skipped, skippedFile{filename, err})
+			continue
+		}
+
+		for _, e := range errs {
+			skipped = append(skipped, skippedFile{filename, e})
+		}
+
+		fileEntries, err := encoder.CollectMatchEntries(filename, chain)
+		if err != nil {
+			skipped = append(skipped, skippedFile{filename, err})
+			continue
+		}
+
+		entries = append(entries, fileEntries...)
+	}
+
+	return entries, skipped
+}
+
+func showKeyGroup(w io.Writer, i int, group *encoder.KeyGroup) {
+	fmt.Fprintf(w, "Key %d: %s\n", i+1, encoder.PublicKeyDescription(group.PublicKey))
+	fmt.Fprintf(w, "  SPKI SHA256: %s\n", encoder.FingerprintString(group.Fingerprint))
+	fmt.Fprintf(w, "  Status: %s\n", group.Status())
+	for _, entry := range group.Entries {
+		fmt.Fprintf(w, "    %s #%d: %s\n", entry.Source, entry.Index, entry.Description())
+	}
+}
+
+var Command = &clicontext.Command{
+	Name:     "match",
+	Short:    "Group private keys, requests and certificates by public key",
+	Usage:    "file|directory ...",
+	FileArgs: true,
+	Flags: func(set *flag.FlagSet) {
+		clicontext.OutputFlags(set, "")
+		set.Bool("mismatch", false, "Only show keys which are not matched")
+	},
+	Run: matchCommand,
+}
+
+func matchCommand(ctx *clicontext.CommandContext) error {
+	if len(ctx.Args) <= 0 {
+		return clicontext.UsageErrorf("At least one file or directory is required")
+	}
+
+	onlyProblems := ctx.Bool("mismatch")
+	files, err := listFiles(ctx.Args)
+	if err != nil {
+		return err
+	}
+
+	entries, skipped := collectEntries(files)
+	groups := encoder.GroupByPublicKey(entries)
+	mismatches := encoder.FindSourceMismatches(entries)
+	err = ctx.IO().Render(false, func(w io.Writer) error {
+		for i, group := range groups {
+			if onlyProblems && group.IsMatched() {
+				continue
+			}
+
+			showKeyGroup(w, i, group)
+		}
+
+		for _, source := range mismatches {
+			fmt.Fprintf(w, "Mismatch: %s contains a private key not matching its certificates\n", source)
+		}
+
+		fmt.Fprintf(w, "%d files, %d objects, %d keys, %d mismatched files, %d skipped\n",
+			len(files), len(entries), len(groups), len(mismatches), len(skipped))
+		return nil
+	})
+
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "Skipped: %s: %s\n", s.filename, s.err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) > 0 {
+		return clicontext.VerificationErrorf("%d files contain mismatched keys", len(mismatches))
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func isVariableChar(ch byte) bool {
+	return ch == '_' ||
+		(ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// expandVariable resolves variable reference s after $, i.e. name or {name}, returns value
+// and length of reference consumed. Shell variables take precedence over environment.
+func (s *session) expandVariable(ref string) (string, int, error) {
+	start, end := 0, 0
+	if strings.HasPrefix(ref, "{") {
+		close := strings.IndexByte(ref, '}')
+		if close < 0 {
+			return "", 0, fmt.Errorf("missing close brace of variable")
+		}
+
+		start, end = 1, close
+	} else {
+		for end < len(ref) && isVariableChar(ref[end]) {
+			end++
+		}
+	}
+
+	name := ref[start:end]
+	if len(name) <= 0 {
+		// A single $ is kept as it is.
+		return "$", 0, nil
+	}
+
+	if start > 0 {
+		end++
+	}
+
+	if value, found := s.variables[name]; found {
+		return value, end, nil
+	}
+
+	if value, found := os.LookupEnv(name); found {
+		return value, end, nil
+	}
+
+	return "", 0, fmt.Errorf("variable %s is not set", name)
+}
+
+// splitWords splits a command line into words as a POSIX shell does for simple commands,
+// with quotes, backslash escapes, $name expansion and # comments.
+func (s *session) splitWords(line string) ([]string, error) {
+	words := make([]string, 0, 8)
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case ch == '#' && !inWord:
+			i = len(line)
+
+		case ch == '\\':
+			inWord = true
+			if i+1 < len(line) {
+				i++
+				word.WriteByte(line[i])
+			}
+
+		case ch == '\'':
+			inWord = true
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("missing close quote")
+			}
+
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+
+		case ch == '"':
+			inWord = true
+			n, err := s.doubleQuoted(&word, line[i+1:])
+			if err != nil {
+				return nil, err
+			}
+
+			i += n
+
+		case ch == '$':
+			inWord = true
+			value, n, err := s.expandVariable(line[i+1:])
+			if err != nil {
+				return nil, err
+			}
+
+			word.WriteString(value)
+			i += n
+
+		default:
+			inWord = true
+			word.WriteByte(ch)
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// doubleQuoted writes content of double quoted s into word, returns length consumed
+// including the close quote.
+func (s *session) doubleQuoted(word *strings.Builder, quoted string) (int, error) {
+	for i := 0; i < len(quoted); i++ {
+		ch := quoted[i]
+		switch ch {
+		case '"':
+			return i + 1, nil
+
+		case '\\':
+			if i+1 < len(quoted) && strings.IndexByte("\"\\$", quoted[i+1]) >= 0 {
+				i++
+			}
+
+			word.WriteByte(quoted[i])
+
+		case '$':
+			value, n, err := s.expandVariable(quoted[i+1:])
+			if err != nil {
+				return 0, err
+			}
+
+			word.WriteString(value)
+			i += n
+
+		default:
+			word.WriteByte(ch)
+		}
+	}
+
+	return 0, fmt.Errorf("missing close quote")
+}
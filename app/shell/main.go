@@ -0,0 +1,421 @@
+package shell
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flily/go-ssl/common/clicontext"
+)
+
+const (
+	prompt         = "gossl> "
+	historyEnv     = "GOSSL_HISTORY"
+	historyFile    = ".gossl_history"
+	historyEntries = 1000
+)
+
+var errExit = fmt.Errorf("exit")
+
+// running is set while a shell session is running, checked when the shell command is run so
+// that it is not nested however it is invoked, e.g. with global flags like `-json shell`.
+var running bool
+
+type builtin struct {
+	usage string
+	short string
+	run   func(s *session, args []string) error
+}
+
+var builtins map[string]*builtin
+
+func init() {
+	builtins = map[string]*builtin{
+		"set":     {"set [name value ...]", "Set a variable, or list variables", (*session).set},
+		"unset":   {"unset name ...", "Remove variables and in-memory files", (*session).unset},
+		"load":    {"load name file", "Load file into memory as @name", (*session).load},
+		"save":    {"save name file", "Save in-memory file @name to file", (*session).save},
+		"vars":    {"vars", "List variables and in-memory files", (*session).vars},
+		"history": {"history", "List command history", (*session).listHistory},
+		"help":    {"help [command ...]", "Show help of shell or a command", (*session).help},
+		"exit":    {"exit", "Leave the shell", (*session).exit},
+		"quit":    {"quit", "Leave the shell", (*session).exit},
+	}
+}
+
+type session struct {
+	ctx       *clicontext.CommandContext
+	store     *clicontext.MemoryStore
+	variables map[string]string
+	history   []string
+	stdout    io.Writer
+}
+
+func (s *session) set(args []string) error {
+	if len(args) <= 0 {
+		names := make([]string, 0, len(s.variables))
+		for name := range s.variables {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(s.stdout, "%s=%s\n", name, s.variables[name])
+		}
+
+		return nil
+	}
+
+	if len(args) < 2 {
+		return clicontext.UsageErrorf("usage: %s", builtins["set"].usage)
+	}
+
+	s.variables[args[0]] = strings.Join(args[1:], " ")
+	return nil
+}
+
+func (s *session) unset(args []string) error {
+	for _, name := range args {
+		if strings.HasPrefix(name, clicontext.MemoryPrefix) {
+			s.store.Delete(name[len(clicontext.MemoryPrefix):])
+		} else {
+			delete(s.variables, name)
+		}
+	}
+
+	return nil
+}
+
+// memoryFileName strips optional @ of name.
+func memoryFileName(name string) string {
+	return strings.TrimPrefix(name, clicontext.MemoryPrefix)
+}
+
+func (s *session) load(args []string) error {
+	if len(args) != 2 {
+		return clicontext.UsageErrorf("usage: %s", builtins["load"].usage)
+	}
+
+	data, err := clicontext.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	s.store.Set(memoryFileName(args[0]), data)
+	return nil
+}
+
+func (s *session) save(args []string) error {
+	if len(args) != 2 {
+		return clicontext.UsageErrorf("usage: %s", builtins["save"].usage)
+	}
+
+	name := memoryFileName(args[0])
+	data, found := s.store.Get(name)
+	if !found {
+		return clicontext.NewError(clicontext.ErrorKindIO,
+			fmt.Errorf("%s%s: no such variable", clicontext.MemoryPrefix, name))
+	}
+
+	// Files are private unless known to have no private key.
+	private := true
+	if container, err := clicontext.LoadContainer(clicontext.MemoryPrefix+name, clicontext.FormatAuto); err == nil {
+		private = container.FirstPrivateKey() != nil
+	}
+
+	cio := &clicontext.Context{OutFilename: args[1]}
+	return cio.WriteOutput(data, private)
+}
+
+func (s *session) vars(args []string) error {
+	if err := s.set(nil); err != nil {
+		return err
+	}
+
+	for _, name := range s.store.Names() {
+		data, _ := s.store.Get(name)
+		description := "unknown"
+		container, err := clicontext.LoadContainer(clicontext.MemoryPrefix+name, clicontext.FormatAuto)
+		if err == nil {
+			description = container.KeyTypeString()
+		}
+
+		fmt.Fprintf(s.stdout, "%s%s: %d bytes, %s\n", clicontext.MemoryPrefix, name, len(data), description)
+	}
+
+	return nil
+}
+
+func (s *session) listHistory(args []string) error {
+	for i, line := range s.history {
+		fmt.Fprintf(s.stdout, "%5d  %s\n", i+1, line)
+	}
+
+	return nil
+}
+
+func (s *session) help(args []string) error {
+	if len(args) > 0 {
+		return s.ctx.Invoke(append([]string{"help"}, args...))
+	}
+
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	fmt.Fprintf(s.stdout, "Shell commands:\n")
+	for _, name := range names {
+		fmt.Fprintf(s.stdout, "  %-20s  %s\n", builtins[name].usage, builtins[name].short)
+	}
+
+	fmt.Fprintf(s.stdout, "\nOther commands are gossl commands, e.g. 'rsa show -in @key'.\n")
+	fmt.Fprintf(s.stdout, "@name refers an in-memory file in place of a file name, for input and output.\n")
+	fmt.Fprintf(s.stdout, "$name and ${name} are replaced by value of variables or environment.\n")
+	fmt.Fprintf(s.stdout, "\nRun 'help <command>' for help of a gossl command.\n")
+	return nil
+}
+
+func (s *session) exit(args []string) error {
+	return errExit
+}
+
+// expandHistory replaces !! with the last command, and !N with the Nth command.
+func (s *session) expandHistory(line string) (string, error) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "!") {
+		return line, nil
+	}
+
+	ref, rest, _ := strings.Cut(trimmed[1:], " ")
+	index := len(s.history)
+	if ref != "!" {
+		n, err := strconv.Atoi(ref)
+		if err != nil || n <= 0 || n > len(s.history) {
+			return "", clicontext.UsageErrorf("!%s: event not found", ref)
+		}
+
+		index = n
+	}
+
+	if index <= 0 {
+		return "", clicontext.UsageErrorf("!%s: event not found", ref)
+	}
+
+	expanded := s.history[index-1]
+	if len(rest) > 0 {
+		expanded += " " + rest
+	}
+
+	fmt.Fprintf(os.Stderr, "%s\n", expanded)
+	return expanded, nil
+}
+
+// execute runs a command line, builtin or gossl command.
+func (s *session) execute(line string) error {
+	words, err := s.splitWords(line)
+	if err != nil {
+		return clicontext.UsageErrorf("%s", err)
+	}
+
+	if len(words) <= 0 {
+		return nil
+	}
+
+	if b, found := builtins[words[0]]; found {
+		return b.run(s, words[1:])
+	}
+
+	return s.ctx.Invoke(words)
+}
+
+func historyFilename() string {
+	if filename := os.Getenv(historyEnv); len(filename) > 0 {
+		return filename
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, historyFile)
+}
+
+func (s *session) loadHistory(filename string) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if len(strings.TrimSpace(line)) > 0 {
+			s.history = append(s.history, line)
+		}
+	}
+
+	if len(s.history) > historyEntries {
+		s.history = s.history[len(s.history)-historyEntries:]
+	}
+}
+
+func (s *session) addHistory(filename string, line string) {
+	if len(strings.TrimSpace(line)) <= 0 {
+		return
+	}
+
+	s.history = append(s.history, line)
+	if len(filename) <= 0 {
+		return
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+
+	defer file.Close()
+	fmt.Fprintf(file, "%s\n", line)
+}
+
+func reportError(err error) {
+	if clicontext.IsSilent(err) {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "gossl: %s: %s\n", clicontext.ErrorKindOf(err), err)
+}
+
+func shellCommand(ctx *clicontext.CommandContext) error {
+	if running {
+		return clicontext.UsageErrorf("shell can not be nested")
+	}
+
+	if len(ctx.Args) > 1 {
+		return clicontext.UsageErrorf("too many scripts: %s", strings.Join(ctx.Args, " "))
+	}
+
+	input := io.Reader(os.Stdin)
+	name := "-"
+	interactive := len(ctx.Args) <= 0 && clicontext.IsTerminal(os.Stdin)
+	if len(ctx.Args) > 0 {
+		name = ctx.Args[0]
+		file, err := clicontext.OpenFile(name)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		input = file
+	}
+
+	s := &session{
+		ctx:       ctx,
+		store:     clicontext.NewMemoryStore(),
+		variables: make(map[string]string),
+		stdout:    os.Stdout,
+	}
+
+	running = true
+	defer func() { running = false }()
+
+	clicontext.SetMemoryStore(s.store)
+	defer clicontext.SetMemoryStore(nil)
+
+	history := ""
+	if interactive {
+		history = historyFilename()
+		s.loadHistory(history)
+	}
+
+	batch := ctx.Bool("batch")
+	failed := 0
+	var lastErr error
+	reader := bufio.NewReader(input)
+	for lineno := 1; ; lineno++ {
+		if interactive {
+			fmt.Fprint(os.Stdout, prompt)
+		}
+
+		line, readErr := reader.ReadString('\n')
+		if len(line) <= 0 && readErr != nil {
+			if interactive {
+				fmt.Fprintln(os.Stdout)
+			}
+
+			if readErr != io.EOF {
+				return clicontext.NewError(clicontext.ErrorKindIO, readErr)
+			}
+
+			break
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		var err error
+		if interactive {
+			line, err = s.expandHistory(line)
+			if err == nil {
+				s.addHistory(history, line)
+			}
+		}
+
+		if err == nil {
+			err = s.execute(line)
+		}
+
+		if err == errExit {
+			break
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if !interactive {
+			err = fmt.Errorf("%s:%d: %w", name, lineno, err)
+		}
+
+		if batch {
+			return err
+		}
+
+		reportError(err)
+		failed++
+		lastErr = err
+	}
+
+	if failed > 0 && !interactive {
+		return clicontext.NewError(clicontext.ErrorKindOf(lastErr),
+			fmt.Errorf("%d commands failed", failed))
+	}
+
+	return nil
+}
+
+var Command = &clicontext.Command{
+	Name:  "shell",
+	Short: "Run gossl commands interactively or from a script",
+	Long: "Run gossl commands interactively, or from a script file or stdin.\n\n" +
+		"Commands share configuration and global flags, and in-memory files named @name\n" +
+		"are kept between commands, e.g.\n\n" +
+		"  load ca ca.pem\n" +
+		"  genrsa -out @key\n" +
+		"  cert csr -key @key -subj /CN=example.com -out @csr\n" +
+		"  save key key.pem\n\n" +
+		"Interactive history is kept in $" + historyEnv + " or ~/" + historyFile + ",\n" +
+		"!! runs the last command and !N runs the Nth command. Run 'help' in the shell for\n" +
+		"shell commands.",
+	Usage:    "[script]",
+	FileArgs: true,
+	Flags: func(set *flag.FlagSet) {
+		set.Bool("batch", false, "Stop at the first failed command")
+	},
+	Run: shellCommand,
+}
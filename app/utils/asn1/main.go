@@ -1,83 +1,135 @@
 package asn1
 
 import (
+	"encoding/pem"
 	"flag"
-	"fmt"
 	"io"
+	"regexp"
+	"strings"
 
 	"github.com/flily/go-ssl/common/clicontext"
-	"github.com/flily/go-ssl/common/cliutils"
+	"github.com/flily/go-ssl/common/prettyprint"
 	asn1decode "github.com/flily/go-ssl/modules/asn1"
 )
 
-func decodeASN1ObjectFromFile(filename string) (asn1decode.ASN1Object, error) {
-	fd, err := cliutils.CLIReadFile(filename)
+// decodeASN1Object decodes a DER object from input. PEM is decoded first unless inform is der.
+func decodeASN1Object(cio *clicontext.Context) (asn1decode.ASN1Object, error) {
+	content, err := cio.ReadInput()
 	if err != nil {
 		return nil, err
 	}
 
-	defer fd.Close()
-	content, err := io.ReadAll(fd)
-	if err != nil {
-		return nil, err
+	if cio.InForm != clicontext.FormatDER {
+		block, _ := pem.Decode(content)
+		if block != nil {
+			content = block.Bytes
+		} else if cio.InForm == clicontext.FormatPEM {
+			return nil, clicontext.InputFormatErrorf("%s: no PEM block found", cio.InFilename)
+		}
 	}
 
 	result, length, err := asn1decode.ReadASN1Object(content, 0)
 	if err != nil {
-		return nil, err
+		return nil, clicontext.InputError(err)
 	}
 
 	if length != len(content) {
-		return nil, fmt.Errorf("asn1: not all data parsed: %d/%d bytes parsed", length, len(content))
+		return nil, clicontext.InputFormatErrorf("asn1: not all data parsed: %d/%d bytes parsed", length, len(content))
 	}
 
 	return result, nil
 }
 
-func showASN1Decode(filename string) error {
-	obj, err := decodeASN1ObjectFromFile(filename)
-	if err != nil {
-		return err
-	}
+// treeLine matches a line of ASN.1 tree, i.e. tree prefix, type and optional content in
+// brackets, e.g. "| + ObjectIdentifier[2.5.4.3 (Common Name (CN))]".
+var treeLine = regexp.MustCompile(`^((?:\| )*(?:\+ )?)(\w+)( ?\[(.*)\])?$`)
 
-	fmt.Printf("%s\n", obj.PrettyString(""))
-	return nil
+// writeTree writes tree of obj, types are colored as tags, and contents of object identifiers
+// and strings are colored if output is colored.
+func writeTree(p *prettyprint.Printer, obj asn1decode.ASN1Object) {
+	for _, line := range strings.Split(obj.PrettyString(""), "\n") {
+		p.Printf("%s\n", colorizeTreeLine(p, line))
+	}
 }
 
-func asn1CommandShow(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("asn1", flag.ExitOnError)
-	inFile := set.String("in", "-", "Input file")
-	_ = ctx.Parse(set)
+func colorizeTreeLine(p *prettyprint.Printer, line string) string {
+	m := treeLine.FindStringSubmatch(line)
+	if m == nil {
+		return line
+	}
 
-	return showASN1Decode(*inFile)
-}
+	prefix, name, content := m[1], m[2], m[3]
+	style := prettyprint.StyleNone
+	switch name {
+	case "ObjectIdentifier":
+		style = prettyprint.StyleOID
 
-func asn1CommandGuess(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("asn1", flag.ExitOnError)
-	inFile := set.String("in", "-", "Input file")
-	_ = ctx.Parse(set)
+	case "PrintableString":
+		style = prettyprint.StyleString
+	}
+
+	if len(content) > 0 && style != prettyprint.StyleNone {
+		content = content[:strings.IndexByte(content, '[')+1] + p.Colorize(style, m[4]) + "]"
+	}
+
+	return prefix + p.Colorize(prettyprint.StyleTag, name) + content
+}
 
-	obj, err := decodeASN1ObjectFromFile(*inFile)
+func asn1CommandShow(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	obj, err := decodeASN1Object(cio)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s\n", obj.PrettyString(""))
-	err = asn1decode.CanBeX509Certificate(obj)
+	return cio.Render(false, func(w io.Writer) error {
+		p := prettyprint.NewPrinter(w)
+		writeTree(p, obj)
+		return p.Err()
+	})
+}
+
+func asn1CommandGuess(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	obj, err := decodeASN1Object(cio)
 	if err != nil {
-		fmt.Printf("Not a X.509 certificate: %s\n", err)
-	} else {
-		fmt.Printf("X.509 certificate\n")
+		return err
 	}
 
-	return nil
+	return cio.Render(false, func(w io.Writer) error {
+		p := prettyprint.NewPrinter(w)
+		writeTree(p, obj)
+		err := asn1decode.CanBeX509Certificate(obj)
+		if err != nil {
+			p.Printf("Not a X.509 certificate: %s\n", err)
+		} else {
+			p.Printf("X.509 certificate\n")
+		}
+
+		return p.Err()
+	})
 }
 
-var asn1Commands = map[string]clicontext.CommandEntryFunc{
-	"show":  asn1CommandShow,
-	"guess": asn1CommandGuess,
+func ioFlags(set *flag.FlagSet) {
+	clicontext.InputFlags(set)
+	clicontext.OutputFlags(set, "")
 }
 
-func MainASN1(ctx *clicontext.CommandContext) error {
-	return ctx.Invoke(asn1Commands)
+var Command = &clicontext.Command{
+	Name:  "asn1",
+	Short: "Decode ASN.1 DER data",
+	Subcommands: []*clicontext.Command{
+		{
+			Name:  "show",
+			Short: "Show ASN.1 structure",
+			Flags: ioFlags,
+			Run:   asn1CommandShow,
+		},
+		{
+			Name:  "guess",
+			Short: "Show ASN.1 structure and guess whether it is a X.509 certificate",
+			Flags: ioFlags,
+			Run:   asn1CommandGuess,
+		},
+	},
 }
@@ -3,38 +3,240 @@ package format
 import (
 	"flag"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/flily/go-ssl/common/clicontext"
+	"github.com/flily/go-ssl/common/cliutils"
 	"github.com/flily/go-ssl/common/encoder"
 )
 
-func detectFileType(filename string) error {
-	container, err := encoder.ParseContainerChainFromFile(filename)
-	if err != nil {
-		return err
-	}
+type candidateDocument struct {
+	Format string `json:"format"`
+	Parsed bool   `json:"parsed"`
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type blockDocument struct {
+	Index          int                 `json:"index"`
+	PEM            bool                `json:"pem"`
+	PEMType        string              `json:"pem_type,omitempty"`
+	Size           int                 `json:"size"`
+	StructureError string              `json:"structure_error,omitempty"`
+	Detected       string              `json:"detected"`
+	Ambiguous      bool                `json:"ambiguous"`
+	Candidates     []candidateDocument `json:"candidates"`
+}
 
-	typeChain := make([]string, 0, 10)
+// fileDocument is format of a file, chain of objects detected, or all blocks with
+// candidate formats in verbose mode.
+type fileDocument struct {
+	File    string           `json:"file"`
+	Chain   []string         `json:"chain,omitempty"`
+	Errors  []string         `json:"errors,omitempty"`
+	Blocks  []*blockDocument `json:"blocks,omitempty"`
+	verbose bool
+}
+
+type formatDocument struct {
+	Files []*fileDocument `json:"files"`
+}
+
+func typeChain(container *encoder.Container) []string {
+	chain := make([]string, 0, 10)
 	c := container
 	for c != nil {
-		typeChain = append(typeChain, c.KeyTypeString())
+		chain = append(chain, c.KeyTypeString())
 		c = c.Next()
 	}
-	fmt.Printf("%s: %s\n", filename, strings.Join(typeChain, " -> "))
+
+	return chain
+}
+
+func detectFileType(filename string) (*fileDocument, error) {
+	data, err := clicontext.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := encoder.ParseContainerChain(data)
+	if err != nil {
+		return nil, clicontext.InputError(err)
+	}
+
+	doc := &fileDocument{
+		File:  filename,
+		Chain: typeChain(container),
+	}
+
+	return doc, nil
+}
+
+func detectFileTypeLenient(filename string) (*fileDocument, error) {
+	data, err := clicontext.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	container, errs := encoder.ParseContainerChainLenient(data)
+	doc := &fileDocument{
+		File:  filename,
+		Chain: typeChain(container),
+	}
+
+	for _, e := range errs {
+		doc.Errors = append(doc.Errors, e.Error())
+	}
+
+	return doc, nil
+}
+
+func newBlockDocument(i int, d *encoder.Detection) *blockDocument {
+	doc := &blockDocument{
+		Index:      i,
+		PEM:        d.IsPEM,
+		PEMType:    d.PEMType,
+		Size:       len(d.Data),
+		Detected:   d.Format().String(),
+		Ambiguous:  d.Ambiguous(),
+		Candidates: make([]candidateDocument, 0, len(d.Candidates)),
+	}
+
+	if d.StructureErr != nil {
+		doc.StructureError = d.StructureErr.Error()
+	}
+
+	for _, c := range d.Candidates {
+		candidate := candidateDocument{
+			Format: c.Format.String(),
+			Parsed: c.Parsed(),
+			Reason: c.Reason,
+		}
+
+		if c.Err != nil {
+			candidate.Error = c.Err.Error()
+		}
+
+		doc.Candidates = append(doc.Candidates, candidate)
+	}
+
+	return doc
+}
+
+func detectFileTypeVerbose(filename string) (*fileDocument, error) {
+	content, err := clicontext.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &fileDocument{
+		File:    filename,
+		verbose: true,
+	}
+
+	for i, d := range encoder.DetectFormat(content) {
+		doc.Blocks = append(doc.Blocks, newBlockDocument(i, d))
+	}
+
+	return doc, nil
+}
+
+func (d *blockDocument) writeText(w io.Writer) {
+	if d.PEM {
+		fmt.Fprintf(w, "  block %d: PEM (%s), %d bytes\n", d.Index, d.PEMType, d.Size)
+	} else {
+		fmt.Fprintf(w, "  block %d: DER, %d bytes\n", d.Index, d.Size)
+	}
+
+	if len(d.StructureError) > 0 {
+		fmt.Fprintf(w, "    ASN.1 structure: %s\n", d.StructureError)
+	}
+
+	fmt.Fprintf(w, "    detected: %s", d.Detected)
+	if d.Ambiguous {
+		fmt.Fprintf(w, " (ambiguous)")
+	}
+	fmt.Fprintf(w, "\n")
+
+	for _, c := range d.Candidates {
+		if c.Parsed {
+			fmt.Fprintf(w, "    + %s: %s\n", c.Format, c.Reason)
+		} else if len(c.Reason) > 0 {
+			fmt.Fprintf(w, "    - %s: %s, rejected: %s\n", c.Format, c.Reason, c.Error)
+		} else {
+			fmt.Fprintf(w, "    - %s: rejected: %s\n", c.Format, c.Error)
+		}
+	}
+}
+
+func (d *fileDocument) writeText(w io.Writer) {
+	if d.verbose {
+		fmt.Fprintf(w, "%s:\n", d.File)
+		for _, block := range d.Blocks {
+			block.writeText(w)
+		}
+
+		return
+	}
+
+	fmt.Fprintf(w, "%s: %s\n", d.File, strings.Join(d.Chain, " -> "))
+	for _, e := range d.Errors {
+		fmt.Fprintf(w, "  %s\n", e)
+	}
+}
+
+func (d *formatDocument) WriteText(w io.Writer) error {
+	for _, file := range d.Files {
+		file.writeText(w)
+	}
+
 	return nil
 }
 
-func MainFormat(ctx *clicontext.CommandContext) error {
-	set := flag.NewFlagSet("format", flag.ExitOnError)
-	_ = ctx.Parse(set)
+var Command = &clicontext.Command{
+	Name:     "format",
+	Short:    "Detect format of key, certificate and bundle files",
+	Usage:    "[file ...]",
+	FileArgs: true,
+	Flags: func(set *flag.FlagSet) {
+		clicontext.OutputFlags(set, "")
+		set.Bool("v", false, "Show all candidate formats and parser errors")
+		set.Bool("lenient", false, "Skip garbage and keep unknown blocks in bundles")
+	},
+	Run: formatCommand,
+}
+
+func formatCommand(ctx *clicontext.CommandContext) error {
+	cio := ctx.IO()
+	doc := &formatDocument{
+		Files: make([]*fileDocument, 0, len(ctx.Args)),
+	}
+
+	var err error
+	for _, filename := range cliutils.CLIFileList(ctx.Args) {
+		var file *fileDocument
+		if ctx.Bool("v") {
+			file, err = detectFileTypeVerbose(filename)
+		} else if ctx.Bool("lenient") {
+			file, err = detectFileTypeLenient(filename)
+		} else {
+			file, err = detectFileType(filename)
+		}
 
-	for _, filename := range set.Args() {
-		err := detectFileType(filename)
 		if err != nil {
-			return err
+			break
 		}
+
+		doc.Files = append(doc.Files, file)
 	}
 
-	return nil
+	// Files detected before an error are still shown.
+	return cio.Render(false, func(w io.Writer) error {
+		if e := cio.Renderer.Render(w, doc); e != nil {
+			return e
+		}
+
+		return err
+	})
 }
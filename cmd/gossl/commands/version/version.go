@@ -8,6 +8,12 @@ import (
 
 var Version = "0.0.0 (on development)"
 
+var Command = &clicontext.Command{
+	Name:  "version",
+	Short: "Show version of gossl",
+	Run:   MainVersion,
+}
+
 func MainVersion(ctx *clicontext.CommandContext) error {
 	fmt.Printf("gossl %s\n", Version)
 	return nil
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -8,48 +9,68 @@ import (
 	"github.com/flily/go-ssl/app/cipher"
 	"github.com/flily/go-ssl/app/digest"
 	"github.com/flily/go-ssl/app/keygen"
+	"github.com/flily/go-ssl/app/match"
+	"github.com/flily/go-ssl/app/shell"
 	"github.com/flily/go-ssl/app/utils/asn1"
 	"github.com/flily/go-ssl/app/utils/format"
 	"github.com/flily/go-ssl/cmd/gossl/commands/version"
 	"github.com/flily/go-ssl/common/clicontext"
 )
 
-type Entry func(args []string) error
-
-var commands map[string]clicontext.CommandEntryFunc
-
-func init() {
-	commands = map[string]clicontext.CommandEntryFunc{
-		"version":  version.MainVersion,
-		"digest":   digest.Main,
-		"genrsa":   keygen.MainGenRSA,
-		"rsa":      cipher.MainRSA,
-		"genecdsa": keygen.MainGenEC,
-		"ec":       cipher.MainEC,
-		"format":   format.MainFormat,
-		"asn1":     asn1.MainASN1,
-		"cert":     cert.MainCert,
-		"help":     showHelp,
-	}
-}
-
-func showHelp(ctx *clicontext.CommandContext) error {
-	help()
-	return nil
-}
-
-func help() {
-	fmt.Printf("Usage: %s <command> [options]\n", os.Args[0])
-	fmt.Println("Commands:")
-	for name := range commands {
-		fmt.Printf("  %s\n", name)
-	}
+var rootCommand = &clicontext.Command{
+	Name:  "gossl",
+	Short: "gossl is a toolkit for keys, certificates and message digests.",
+	Long: "gossl is a toolkit for keys, certificates and message digests.\n\n" +
+		"Exit status:\n" +
+		"  0  success\n" +
+		"  1  other errors\n" +
+		"  2  usage error, e.g. unknown command, flag or flag value\n" +
+		"  3  I/O error, e.g. file can not be read or written\n" +
+		"  4  input format error, e.g. data is not a key or certificate expected\n" +
+		"  5  verification failure, e.g. check of parameters or keys failed\n\n" +
+		"Configuration:\n" +
+		"  Configuration file is given by -config or $GOSSL_CONF, in format of openssl.cnf.\n" +
+		"  Options of a command take defaults from the section named by the command, e.g.\n" +
+		"  [rsa_show] then [rsa] for 'gossl rsa show', and global options from [gossl].\n" +
+		"  Options on command line take precedence.\n\n" +
+		"JSON output:\n" +
+		"  With -json, inspection commands write a JSON object instead of text, the schema\n" +
+		"  is documented in docs/json.md.\n\n" +
+		"Colors:\n" +
+		"  Text output is colored when written to a terminal, unless $NO_COLOR is set or\n" +
+		"  -color=never is given. -color=always colors output to files and pipes as well.",
+	Flags: func(set *flag.FlagSet) {
+		clicontext.ConfigFlags(set)
+		clicontext.RenderFlags(set)
+	},
+	Subcommands: []*clicontext.Command{
+		version.Command,
+		digest.Command,
+		keygen.GenRSACommand,
+		cipher.RSACommand,
+		keygen.GenECCommand,
+		cipher.ECCommand,
+		cipher.ECParamCommand,
+		keygen.GenDSACommand,
+		cipher.DSACommand,
+		cipher.DSAParamCommand,
+		format.Command,
+		asn1.Command,
+		cert.Command,
+		match.Command,
+		shell.Command,
+		clicontext.HelpCommand(),
+		clicontext.CompletionCommand(),
+	},
 }
 
 func main() {
-	ctx := clicontext.NewCommandContext(os.Args)
-	err := ctx.Invoke(commands)
+	err := clicontext.Execute(rootCommand, os.Args)
 	if err != nil {
-		fmt.Printf("gossl error: %s\n", err)
+		if !clicontext.IsSilent(err) {
+			fmt.Fprintf(os.Stderr, "gossl: %s: %s\n", clicontext.ErrorKindOf(err), err)
+		}
+
+		os.Exit(clicontext.ExitCode(err))
 	}
 }
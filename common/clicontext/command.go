@@ -1,18 +1,25 @@
 package clicontext
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"strings"
+
+	"github.com/flily/go-ssl/common/config"
 )
 
 type CommandEntryFunc func(*CommandContext) error
 
 type CommandContext struct {
-	Command        string
-	Args           []string
-	context        []string
-	defaultCommand string
+	Command string
+	Args    []string
+	Flags   *flag.FlagSet
+	context []string
+	root    *Command
+	parent  *CommandContext
+	config  *config.Config
 }
 
 func NewCommandContext(args []string) *CommandContext {
@@ -23,74 +30,240 @@ func NewCommandContext(args []string) *CommandContext {
 	command := args[0]
 	nextArgs := args[1:]
 	c := &CommandContext{
-		Command:        command,
-		Args:           nextArgs,
-		context:        []string{},
-		defaultCommand: "",
+		Command: command,
+		Args:    nextArgs,
+		context: []string{},
 	}
 	return c
 }
 
+// CurrentCommand returns the full path of current command, e.g. "gossl rsa show".
 func (c *CommandContext) CurrentCommand() string {
-	return strings.Join(c.context, " ")
+	return strings.Join(append(c.context, c.Command), " ")
 }
 
-func (c *CommandContext) SetDefaultCommand(name string) {
-	c.defaultCommand = name
+// Root returns the root command of the command tree being executed.
+func (c *CommandContext) Root() *Command {
+	return c.root
 }
 
-func (c *CommandContext) showHelp(commands map[string]CommandEntryFunc) error {
-	fmt.Printf("Usage:  %s <command> [options]\n", c.CurrentCommand())
-	fmt.Println("Commands:")
-	for name := range commands {
-		fmt.Printf("  %s\n", name)
+func (c *CommandContext) NextContext(name string, args []string) *CommandContext {
+	context := make([]string, 0, len(c.context)+1)
+	context = append(context, c.context...)
+	context = append(context, c.Command)
+
+	next := &CommandContext{
+		Command: name,
+		Args:    args,
+		context: context,
+		root:    c.root,
+		parent:  c,
+		config:  c.config,
 	}
 
-	return nil
+	return next
 }
 
-func (c *CommandContext) NextContext(name string, args []string) *CommandContext {
-	next := &CommandContext{
-		Command:        name,
-		Args:           args,
-		context:        append(c.context, c.Command),
-		defaultCommand: "",
+// Execute parses flags of cmd from c.Args and runs it, or dispatches to its subcommands.
+func (c *CommandContext) Execute(cmd *Command) error {
+	if c.root == nil {
+		c.root = cmd
 	}
 
-	return next
+	set, err := c.parseFlags(cmd)
+	if errors.Is(err, flag.ErrHelp) {
+		cmd.WriteHelp(os.Stdout, c.CurrentCommand())
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.Flags = set
+	c.Args = set.Args()
+	if len(cmd.Subcommands) <= 0 {
+		if cmd.Run == nil {
+			return fmt.Errorf("command %s is not runnable", c.CurrentCommand())
+		}
+
+		return cmd.Run(c)
+	}
+
+	name := cmd.Default
+	var nextArgs []string
+	if len(c.Args) > 0 {
+		name = c.Args[0]
+		nextArgs = c.Args[1:]
+	}
+
+	if len(name) <= 0 {
+		if cmd.Run != nil {
+			return cmd.Run(c)
+		}
+
+		cmd.WriteHelp(os.Stdout, c.CurrentCommand())
+		return nil
+	}
+
+	sub := cmd.Lookup(name)
+	if sub == nil && cmd.Run != nil && cmd.FileArgs {
+		return cmd.Run(c)
+	}
+
+	if sub == nil {
+		cmd.WriteUsage(os.Stderr, c.CurrentCommand())
+		return UsageErrorf("unknown command: %s", name)
+	}
+
+	ctx := c.NextContext(sub.Name, nextArgs)
+	return ctx.Execute(sub)
 }
 
-func (c *CommandContext) Parse(set *flag.FlagSet) error {
-	return set.Parse(c.Args)
+// Invoke runs command line args as a subcommand of the root command, e.g. args of
+// ["rsa", "show", "-in", "key.pem"]. Configuration and global flags of the root command are
+// shared with c, unless args starts with global flags, e.g. ["-json", "rsa", "show"].
+func (c *CommandContext) Invoke(args []string) error {
+	if len(args) <= 0 {
+		return nil
+	}
+
+	if strings.HasPrefix(args[0], "-") {
+		ctx := &CommandContext{
+			Command: c.root.Name,
+			Args:    args,
+			context: []string{},
+			root:    c.root,
+			config:  c.config,
+		}
+
+		return ctx.Execute(c.root)
+	}
+
+	rootCtx := c
+	for rootCtx.parent != nil {
+		rootCtx = rootCtx.parent
+	}
+
+	cmd := c.root.Lookup(args[0])
+	if cmd == nil {
+		return UsageErrorf("unknown command: %s", args[0])
+	}
+
+	ctx := rootCtx.NextContext(cmd.Name, args[1:])
+	return ctx.Execute(cmd)
 }
 
-func (c *CommandContext) Invoke(commands map[string]CommandEntryFunc) error {
-	set := flag.NewFlagSet(c.CurrentCommand(), flag.ExitOnError)
+// parseFlags parses c.Args, then sets flags not given on command line from configuration,
+// which is loaded with -config if the command defines it.
+func (c *CommandContext) parseFlags(cmd *Command) (*flag.FlagSet, error) {
+	set := cmd.NewFlagSet(c.CurrentCommand())
 	err := set.Parse(c.Args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil, err
+	}
+
 	if err != nil {
-		return err
+		cmd.WriteUsage(os.Stderr, c.CurrentCommand())
+		return nil, NewError(ErrorKindUsage, err)
 	}
 
-	rawArgs := set.Args()
-	command := ""
-	var nextArgs []string
-	if len(rawArgs) <= 0 {
-		if c.defaultCommand != "" {
-			command = c.defaultCommand
-		} else {
-			return c.showHelp(commands)
+	if err := c.loadConfig(set); err != nil {
+		return nil, err
+	}
+
+	if err := c.applyConfig(set); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// findFlag finds flag name in flags of the command, or of its parent commands, e.g. global
+// flags of the root command.
+func (c *CommandContext) findFlag(name string) (*flag.Flag, *flag.FlagSet) {
+	for ctx := c; ctx != nil; ctx = ctx.parent {
+		if ctx.Flags == nil {
+			continue
+		}
+
+		if f := ctx.Flags.Lookup(name); f != nil {
+			return f, ctx.Flags
 		}
-	} else {
-		command = rawArgs[0]
-		nextArgs = rawArgs[1:]
 	}
 
-	entry, found := commands[command]
-	if !found {
-		_ = c.showHelp(commands)
-		return fmt.Errorf("unknown command: %s", command)
+	return nil, nil
+}
+
+func (c *CommandContext) lookupFlag(name string) (*flag.Flag, *flag.FlagSet) {
+	f, set := c.findFlag(name)
+	if f == nil {
+		panic(fmt.Sprintf("clicontext: flag -%s is not defined in %s", name, c.CurrentCommand()))
+	}
+
+	return f, set
+}
+
+// HasFlag reports whether flag name is defined by the command or its parent commands.
+func (c *CommandContext) HasFlag(name string) bool {
+	f, _ := c.findFlag(name)
+	return f != nil
+}
+
+// Value returns value of flag name, panics if the flag is not defined by the command or its
+// parent commands.
+func (c *CommandContext) Value(name string) any {
+	f, _ := c.lookupFlag(name)
+	if getter, ok := f.Value.(flag.Getter); ok {
+		return getter.Get()
+	}
+
+	return f.Value.String()
+}
+
+func (c *CommandContext) String(name string) string {
+	value, _ := c.Value(name).(string)
+	return value
+}
+
+func (c *CommandContext) Bool(name string) bool {
+	value, _ := c.Value(name).(bool)
+	return value
+}
+
+func (c *CommandContext) Int(name string) int {
+	value, _ := c.Value(name).(int)
+	return value
+}
+
+// Strings returns values of a flag defined by List.
+func (c *CommandContext) Strings(name string) []string {
+	value, _ := c.Value(name).([]string)
+	return value
+}
+
+// IsSet reports whether flag name is given on command line.
+func (c *CommandContext) IsSet(name string) bool {
+	_, set := c.lookupFlag(name)
+
+	found := false
+	set.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+
+	return found
+}
+
+// Execute runs cmd as the root command with command line arguments args, args[0] is the
+// program name.
+func Execute(cmd *Command, args []string) error {
+	ctx := NewCommandContext(args)
+	if ctx == nil {
+		ctx = NewCommandContext([]string{cmd.Name})
 	}
 
-	ctx := c.NextContext(command, nextArgs)
-	return entry(ctx)
+	ctx.Command = cmd.Name
+	return ctx.Execute(cmd)
 }
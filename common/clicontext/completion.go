@@ -0,0 +1,346 @@
+package clicontext
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type completionFlag struct {
+	name   string
+	usage  string
+	values []string
+	isBool bool
+	isFile bool
+}
+
+type completionNode struct {
+	path  string
+	cmd   *Command
+	flags []completionFlag
+}
+
+func newCompletionNode(path string, cmd *Command) *completionNode {
+	node := &completionNode{
+		path: path,
+		cmd:  cmd,
+	}
+
+	cmd.NewFlagSet(path).VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		cf := completionFlag{
+			name:   f.Name,
+			usage:  usage,
+			values: cmd.FlagValues[f.Name],
+		}
+
+		if choices, ok := f.Value.(*choiceValue); ok && len(cf.values) <= 0 {
+			cf.values = choices.Choices()
+		}
+
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.isBool = true
+		} else if getter, ok := f.Value.(flag.Getter); ok && len(cf.values) <= 0 {
+			_, cf.isFile = getter.Get().(string)
+		}
+
+		node.flags = append(node.flags, cf)
+	})
+
+	return node
+}
+
+// collectCompletionNodes lists all commands in the tree in depth-first order.
+func collectCompletionNodes(path string, cmd *Command) []*completionNode {
+	nodes := []*completionNode{newCompletionNode(path, cmd)}
+	for _, sub := range cmd.SortedSubcommands() {
+		nodes = append(nodes, collectCompletionNodes(path+" "+sub.Name, sub)...)
+	}
+
+	return nodes
+}
+
+// subcommandWords returns names and aliases of subcommands with the path they lead to.
+func (n *completionNode) subcommandWords() [][2]string {
+	result := make([][2]string, 0)
+	for _, sub := range n.cmd.SortedSubcommands() {
+		next := n.path + " " + sub.Name
+		result = append(result, [2]string{sub.Name, next})
+		for _, alias := range sub.Aliases {
+			result = append(result, [2]string{alias, next})
+		}
+	}
+
+	return result
+}
+
+func (n *completionNode) flagNames() []string {
+	names := make([]string, len(n.flags))
+	for i, f := range n.flags {
+		names[i] = "-" + f.name
+	}
+
+	return names
+}
+
+func (n *completionNode) subcommandNames() []string {
+	names := make([]string, 0, len(n.cmd.Subcommands))
+	for _, sub := range n.cmd.SortedSubcommands() {
+		names = append(names, sub.Name)
+	}
+
+	return names
+}
+
+func quoteSingle(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func WriteBashCompletion(w io.Writer, root *Command) {
+	name := root.Name
+	fn := "_" + strings.ReplaceAll(name, "-", "_")
+	nodes := collectCompletionNodes(name, root)
+
+	fmt.Fprintf(w, "# bash completion for %s\n\n", name)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "    local cur prev cmdpath i words files\n")
+	fmt.Fprintf(w, "    COMPREPLY=()\n")
+	fmt.Fprintf(w, "    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(w, "    cmdpath=%q\n", name)
+	fmt.Fprintf(w, "    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(w, "        case \"$cmdpath/${COMP_WORDS[i]}\" in\n")
+	for _, n := range nodes {
+		for _, word := range n.subcommandWords() {
+			fmt.Fprintf(w, "            %q) cmdpath=%q ;;\n", n.path+"/"+word[0], word[1])
+		}
+	}
+	fmt.Fprintf(w, "        esac\n")
+	fmt.Fprintf(w, "    done\n\n")
+
+	fmt.Fprintf(w, "    case \"$cmdpath/$prev\" in\n")
+	for _, n := range nodes {
+		for _, f := range n.flags {
+			label := fmt.Sprintf("%q", n.path+"/-"+f.name)
+			switch {
+			case len(f.values) > 0:
+				fmt.Fprintf(w, "        %s)\n", label)
+				fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %s -- \"$cur\"))\n",
+					quoteSingle(strings.Join(f.values, " ")))
+				fmt.Fprintf(w, "            return ;;\n")
+
+			case f.isFile:
+				fmt.Fprintf(w, "        %s)\n", label)
+				fmt.Fprintf(w, "            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+				fmt.Fprintf(w, "            return ;;\n")
+
+			case !f.isBool:
+				fmt.Fprintf(w, "        %s)\n", label)
+				fmt.Fprintf(w, "            return ;;\n")
+			}
+		}
+	}
+	fmt.Fprintf(w, "    esac\n\n")
+
+	fmt.Fprintf(w, "    words=\"\"\n")
+	fmt.Fprintf(w, "    files=0\n")
+	fmt.Fprintf(w, "    case \"$cmdpath\" in\n")
+	for _, n := range nodes {
+		words := append(n.subcommandNames(), n.flagNames()...)
+		fmt.Fprintf(w, "        %q)\n", n.path)
+		fmt.Fprintf(w, "            words=%s\n", quoteSingle(strings.Join(words, " ")))
+		if n.cmd.FileArgs {
+			fmt.Fprintf(w, "            files=1\n")
+		}
+		fmt.Fprintf(w, "            ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n\n")
+
+	fmt.Fprintf(w, "    if [[ \"$cur\" != -* && $files -eq 1 ]]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprintf(w, "    else\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "complete -o filenames -F %s %s\n", fn, name)
+}
+
+func zshDescribeItem(name string, description string) string {
+	item := strings.ReplaceAll(name, ":", `\:`) + ":" + description
+	return quoteSingle(item)
+}
+
+func WriteZshCompletion(w io.Writer, root *Command) {
+	name := root.Name
+	fn := "_" + strings.ReplaceAll(name, "-", "_")
+	nodes := collectCompletionNodes(name, root)
+
+	fmt.Fprintf(w, "#compdef %s\n\n", name)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "    local cur prev cmdpath i files\n")
+	fmt.Fprintf(w, "    local -a subcmds opts\n")
+	fmt.Fprintf(w, "    cur=\"${words[CURRENT]}\"\n")
+	fmt.Fprintf(w, "    prev=\"${words[CURRENT-1]}\"\n")
+	fmt.Fprintf(w, "    cmdpath=%q\n", name)
+	fmt.Fprintf(w, "    for ((i = 2; i < CURRENT; i++)); do\n")
+	fmt.Fprintf(w, "        case \"$cmdpath/${words[i]}\" in\n")
+	for _, n := range nodes {
+		for _, word := range n.subcommandWords() {
+			fmt.Fprintf(w, "            (%q) cmdpath=%q ;;\n", n.path+"/"+word[0], word[1])
+		}
+	}
+	fmt.Fprintf(w, "        esac\n")
+	fmt.Fprintf(w, "    done\n\n")
+
+	fmt.Fprintf(w, "    case \"$cmdpath/$prev\" in\n")
+	for _, n := range nodes {
+		for _, f := range n.flags {
+			label := fmt.Sprintf("%q", n.path+"/-"+f.name)
+			switch {
+			case len(f.values) > 0:
+				fmt.Fprintf(w, "        (%s) compadd -- %s; return ;;\n", label,
+					strings.Join(f.values, " "))
+
+			case f.isFile:
+				fmt.Fprintf(w, "        (%s) _files; return ;;\n", label)
+
+			case !f.isBool:
+				fmt.Fprintf(w, "        (%s) return ;;\n", label)
+			}
+		}
+	}
+	fmt.Fprintf(w, "    esac\n\n")
+
+	fmt.Fprintf(w, "    files=0\n")
+	fmt.Fprintf(w, "    case \"$cmdpath\" in\n")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "        (%q)\n", n.path)
+		if len(n.cmd.Subcommands) > 0 {
+			items := make([]string, 0)
+			for _, sub := range n.cmd.SortedSubcommands() {
+				items = append(items, zshDescribeItem(sub.Name, sub.Short))
+			}
+			fmt.Fprintf(w, "            subcmds=(%s)\n", strings.Join(items, " "))
+		}
+
+		if len(n.flags) > 0 {
+			items := make([]string, 0)
+			for _, f := range n.flags {
+				items = append(items, zshDescribeItem("-"+f.name, f.usage))
+			}
+			fmt.Fprintf(w, "            opts=(%s)\n", strings.Join(items, " "))
+		}
+
+		if n.cmd.FileArgs {
+			fmt.Fprintf(w, "            files=1\n")
+		}
+		fmt.Fprintf(w, "            ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n\n")
+
+	fmt.Fprintf(w, "    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(w, "        _describe -t options 'option' opts\n")
+	fmt.Fprintf(w, "    elif (( files )); then\n")
+	fmt.Fprintf(w, "        _files\n")
+	fmt.Fprintf(w, "    elif (( ${#subcmds} )); then\n")
+	fmt.Fprintf(w, "        _describe -t commands 'command' subcmds\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "if [[ \"$funcstack[1]\" == %q ]]; then\n", fn)
+	fmt.Fprintf(w, "    %s \"$@\"\n", fn)
+	fmt.Fprintf(w, "else\n")
+	fmt.Fprintf(w, "    compdef %s %s\n", fn, name)
+	fmt.Fprintf(w, "fi\n")
+}
+
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func WriteFishCompletion(w io.Writer, root *Command) {
+	name := root.Name
+	fn := "__" + strings.ReplaceAll(name, "-", "_") + "_path"
+	nodes := collectCompletionNodes(name, root)
+
+	fmt.Fprintf(w, "# fish completion for %s\n\n", name)
+	fmt.Fprintf(w, "function %s\n", fn)
+	fmt.Fprintf(w, "    set -l cmdpath %s\n", quoteFish(name))
+	fmt.Fprintf(w, "    for word in (commandline -opc)[2..-1]\n")
+	fmt.Fprintf(w, "        switch \"$cmdpath/$word\"\n")
+	for _, n := range nodes {
+		for _, word := range n.subcommandWords() {
+			fmt.Fprintf(w, "            case %s\n", quoteFish(n.path+"/"+word[0]))
+			fmt.Fprintf(w, "                set cmdpath %s\n", quoteFish(word[1]))
+		}
+	}
+	fmt.Fprintf(w, "        end\n")
+	fmt.Fprintf(w, "    end\n")
+	fmt.Fprintf(w, "    test \"$cmdpath\" = \"$argv[1]\"\n")
+	fmt.Fprintf(w, "end\n\n")
+
+	fmt.Fprintf(w, "complete -c %s -f\n", name)
+	for _, n := range nodes {
+		condition := quoteFish(fmt.Sprintf("%s %s", fn, quoteFish(n.path)))
+		for _, sub := range n.cmd.SortedSubcommands() {
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s -d %s\n",
+				name, condition, quoteFish(sub.Name), quoteFish(sub.Short))
+		}
+
+		for _, f := range n.flags {
+			line := fmt.Sprintf("complete -c %s -n %s -o %s -d %s",
+				name, condition, quoteFish(f.name), quoteFish(f.usage))
+			switch {
+			case len(f.values) > 0:
+				line += " -x -a " + quoteFish(strings.Join(f.values, " "))
+
+			case f.isFile:
+				line += " -r -F"
+
+			case !f.isBool:
+				line += " -x"
+			}
+
+			fmt.Fprintln(w, line)
+		}
+
+		if n.cmd.FileArgs {
+			fmt.Fprintf(w, "complete -c %s -n %s -F\n", name, condition)
+		}
+	}
+}
+
+var completionWriters = map[string]func(io.Writer, *Command){
+	"bash": WriteBashCompletion,
+	"zsh":  WriteZshCompletion,
+	"fish": WriteFishCompletion,
+}
+
+// CompletionCommand creates a `completion` command generating shell completion scripts of
+// the whole command tree.
+func CompletionCommand() *Command {
+	cmd := &Command{
+		Name:  "completion",
+		Short: "Generate shell completion script",
+		Long: "Generate shell completion script for bash, zsh or fish, e.g.\n" +
+			"  source <(gossl completion bash)\n" +
+			"  gossl completion zsh > \"${fpath[1]}/_gossl\"\n" +
+			"  gossl completion fish > ~/.config/fish/completions/gossl.fish",
+	}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		writer := completionWriters[shell]
+		cmd.Subcommands = append(cmd.Subcommands, &Command{
+			Name:  shell,
+			Short: fmt.Sprintf("Generate %s completion script", shell),
+			Run: func(ctx *CommandContext) error {
+				writer(os.Stdout, ctx.Root())
+				return nil
+			},
+		})
+	}
+
+	return cmd
+}
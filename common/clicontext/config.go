@@ -0,0 +1,109 @@
+package clicontext
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/flily/go-ssl/common/config"
+)
+
+// ConfigEnv is the environment variable of configuration file, used if -config is not given.
+const ConfigEnv = "GOSSL_CONF"
+
+// ConfigFlags defines -config. Commands defining it load the configuration file, and the
+// configuration is inherited by subcommands.
+func ConfigFlags(set *flag.FlagSet) {
+	set.String("config", "", "Configuration file, $"+ConfigEnv+" if not given")
+}
+
+// Config returns the configuration loaded, an empty configuration if none is loaded.
+func (c *CommandContext) Config() *config.Config {
+	if c.config == nil {
+		return config.New()
+	}
+
+	return c.config
+}
+
+// configSections returns sections providing flag defaults of current command, from the
+// most specific one, e.g. rsa_show and rsa for `gossl rsa show`, and gossl for global flags
+// of the root command.
+func (c *CommandContext) configSections() []string {
+	path := append(c.context, c.Command)
+	if len(path) <= 1 {
+		return path
+	}
+
+	path = path[1:]
+
+	sections := make([]string, 0, len(path))
+	for i := len(path); i > 0; i-- {
+		sections = append(sections, strings.Join(path[:i], "_"))
+	}
+
+	return sections
+}
+
+// applyConfig sets flag values in set from the configuration as defaults, after command
+// line is parsed. Flags given on command line are kept, values of list flags are not
+// appended to values from the configuration.
+func (c *CommandContext) applyConfig(set *flag.FlagSet) error {
+	if c.config == nil {
+		return nil
+	}
+
+	given := make(map[string]bool)
+	set.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	sections := c.configSections()
+	var err error
+	set.VisitAll(func(f *flag.Flag) {
+		if err != nil || given[f.Name] || f.Name == "config" {
+			return
+		}
+
+		for _, section := range sections {
+			value, found := c.config.Section(section).Get(f.Name)
+			if !found {
+				continue
+			}
+
+			if e := f.Value.Set(value); e != nil {
+				err = UsageErrorf("%s: [%s] %s: %s", c.config.Filename, section, f.Name, e)
+			}
+
+			return
+		}
+	})
+
+	return err
+}
+
+// loadConfig loads configuration file given by -config, or $GOSSL_CONF if no
+// configuration is loaded.
+func (c *CommandContext) loadConfig(set *flag.FlagSet) error {
+	f := set.Lookup("config")
+	if f == nil {
+		return nil
+	}
+
+	filename := f.Value.String()
+	if len(filename) <= 0 && c.config == nil {
+		filename = os.Getenv(ConfigEnv)
+	}
+
+	if len(filename) <= 0 {
+		return nil
+	}
+
+	conf, err := config.Load(filename)
+	if err != nil {
+		return InputError(err)
+	}
+
+	c.config = conf
+	return nil
+}
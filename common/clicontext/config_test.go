@@ -0,0 +1,115 @@
+package clicontext
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "gossl.cnf")
+	text := "[key]\nbits = 3072\nform = der\n[key_show]\nform = text\n"
+	if err := os.WriteFile(filename, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var bits int
+	var form string
+	root := &Command{
+		Name:  "tool",
+		Flags: ConfigFlags,
+		Subcommands: []*Command{
+			{
+				Name: "key",
+				Subcommands: []*Command{
+					{
+						Name: "show",
+						Flags: func(set *flag.FlagSet) {
+							set.Int("bits", 2048, "Bits")
+							set.String("form", "pem", "Format")
+						},
+						Run: func(ctx *CommandContext) error {
+							bits, form = ctx.Int("bits"), ctx.String("form")
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		env  string
+		args []string
+		bits int
+		form string
+	}{
+		{"", []string{"tool", "key", "show"}, 2048, "pem"},
+		{"", []string{"tool", "-config", filename, "key", "show"}, 3072, "text"},
+		{filename, []string{"tool", "key", "show", "-bits", "4096"}, 4096, "text"},
+	}
+
+	for _, c := range cases {
+		t.Setenv(ConfigEnv, c.env)
+		if err := Execute(root, c.args); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if bits != c.bits || form != c.form {
+			t.Errorf("wrong flags of %v: %d %s, expected %d %s", c.args, bits, form, c.bits, c.form)
+		}
+	}
+
+	err := Execute(root, []string{"tool", "-config", filename + ".missing", "key", "show"})
+	if ErrorKindOf(err) != ErrorKindIO {
+		t.Errorf("missing config file should be an I/O error: %v", err)
+	}
+}
+
+func TestConfigListFlags(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "gossl.cnf")
+	if err := os.WriteFile(filename, []byte("[check]\nblacklist = md5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var blacklist []string
+	root := &Command{
+		Name:  "tool",
+		Flags: ConfigFlags,
+		Subcommands: []*Command{
+			{
+				Name: "check",
+				Flags: func(set *flag.FlagSet) {
+					List(set, "blacklist", "Blacklist")
+				},
+				Run: func(ctx *CommandContext) error {
+					blacklist = ctx.Strings("blacklist")
+					return nil
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"tool", "-config", filename, "check"}, "md5"},
+		{[]string{"tool", "-config", filename, "check", "-blacklist", "sha1"}, "sha1"},
+		{[]string{"tool", "-config", filename, "check", "-blacklist", "sha1", "-blacklist", "sha224"}, "sha1,sha224"},
+		{[]string{"tool", "check", "-blacklist", "sha1"}, "sha1"},
+	}
+
+	for _, c := range cases {
+		t.Setenv(ConfigEnv, "")
+		if err := Execute(root, c.args); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got := strings.Join(blacklist, ","); got != c.expected {
+			t.Errorf("wrong -blacklist of %v: %s, expected %s", c.args, got, c.expected)
+		}
+	}
+}
@@ -1,75 +1,448 @@
 package clicontext
 
 import (
+	"bytes"
+	"encoding/pem"
 	"flag"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flily/go-ssl/common/encoder"
+	"github.com/flily/go-ssl/common/prettyprint"
+)
+
+// Input and output formats of -inform and -outform.
+const (
+	FormatAuto = "auto"
+	FormatPEM  = "pem"
+	FormatDER  = "der"
+	FormatText = "text"
+	FormatJSON = "json"
 )
 
+type choiceValue struct {
+	value   string
+	choices []string
+}
+
+func (v *choiceValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return v.value
+}
+
+// Set accepts a choice in any case, choices differ only in case are chosen by exact match.
+func (v *choiceValue) Set(s string) error {
+	for _, choice := range v.choices {
+		if s == choice {
+			v.value = choice
+			return nil
+		}
+	}
+
+	for _, choice := range v.choices {
+		if strings.EqualFold(s, choice) {
+			v.value = choice
+			return nil
+		}
+	}
+
+	return fmt.Errorf("must be one of %s", strings.Join(v.choices, ", "))
+}
+
+func (v *choiceValue) Get() any {
+	return v.value
+}
+
+func (v *choiceValue) Choices() []string {
+	return v.choices
+}
+
+// Choice defines a string flag only accepting one of choices, case insensitive.
+func Choice(set *flag.FlagSet, name string, value string, usage string, choices ...string) {
+	set.Var(&choiceValue{value, choices}, name, usage)
+}
+
+type listValue []string
+
+func (v *listValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return strings.Join(*v, ",")
+}
+
+func (v *listValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+func (v *listValue) Get() any {
+	return []string(*v)
+}
+
+// List defines a string flag which may be given multiple times, values are kept in order.
+func List(set *flag.FlagSet, name string, usage string) {
+	set.Var(&listValue{}, name, usage)
+}
+
+// InputFlags defines -in and -inform.
+func InputFlags(set *flag.FlagSet) {
+	set.String("in", "-", "Input file, - for stdin")
+	Choice(set, "inform", FormatAuto, "Input format", FormatAuto, FormatPEM, FormatDER)
+}
+
+// OutputFlags defines -out, and -outform with default format form if any format is given.
+func OutputFlags(set *flag.FlagSet, form string, formats ...string) {
+	set.String("out", "-", "Output file, - for stdout")
+	if len(formats) > 0 {
+		Choice(set, "outform", form, "Output format", formats...)
+	}
+}
+
+// Context is the I/O layer of a command, built from -in, -inform, -out and -outform.
 type Context struct {
-	In          *os.File
 	InFilename  string
-	Out         *os.File
+	InForm      string
 	OutFilename string
-	Set         *flag.FlagSet
+	OutForm     string
+	Renderer    prettyprint.Renderer
+
+	// Color and Width are color and number of columns of text output, see prettyprint.Terminal.
+	Color bool
+	Width int
+}
+
+// IsDocument reports whether output is a rendered document, i.e. output format is text or json.
+func (c *Context) IsDocument() bool {
+	return c.OutForm == FormatText || c.OutForm == FormatJSON
+}
+
+func (c *CommandContext) flagString(name string, value string) string {
+	if c.HasFlag(name) {
+		return c.String(name)
+	}
+
+	return value
 }
 
-func New(name string) *Context {
+// RenderFlags defines -json and -color, usually as global flags of the root command.
+func RenderFlags(set *flag.FlagSet) {
+	set.Bool("json", false, "Show information in JSON instead of text")
+	Choice(set, "color", ColorAuto, "Color text output: auto, always or never",
+		ColorAuto, ColorAlways, ColorNever)
+}
+
+// Renderer returns renderer of documents, JSON if -json is given.
+func (c *CommandContext) Renderer() prettyprint.Renderer {
+	return prettyprint.NewRenderer(c.HasFlag("json") && c.Bool("json"))
+}
+
+// IO returns the I/O context of the command, flags not defined by the command take defaults.
+func (c *CommandContext) IO() *Context {
 	ctx := &Context{
-		In:          os.Stdin,
-		InFilename:  "-",
-		Out:         os.Stdout,
-		OutFilename: "-",
-		Set:         flag.NewFlagSet(name, flag.ExitOnError),
+		InFilename:  c.flagString("in", "-"),
+		InForm:      c.flagString("inform", FormatAuto),
+		OutFilename: c.flagString("out", "-"),
+		OutForm:     c.flagString("outform", ""),
+		Renderer:    c.Renderer(),
+	}
+
+	if ctx.OutForm == FormatJSON {
+		ctx.Renderer = prettyprint.NewRenderer(true)
+	}
+
+	terminal := ctx.OutFilename == "-" && IsTerminal(os.Stdout)
+	ctx.Color = c.useColor(terminal)
+	if terminal {
+		ctx.Width = TerminalWidth(os.Stdout)
 	}
 
 	return ctx
 }
 
-func (c *Context) SetIn(inFile string) error {
-	if inFile == "-" {
-		c.In = os.Stdin
-		c.InFilename = "-"
+// ReadFile reads the whole file, - for stdin, or an in-memory file. Errors are I/O errors.
+func ReadFile(filename string) ([]byte, error) {
+	if name, ok := memoryName(filename); ok {
+		return readMemoryFile(name)
+	}
+
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+
+	if err != nil {
+		return nil, NewError(ErrorKindIO, err)
+	}
+
+	return data, nil
+}
+
+// LoadContainer loads keys or certificates from file in form of pem, der or auto. Objects
+// parsed from in-memory files are cached.
+func LoadContainer(filename string, form string) (*encoder.Container, error) {
+	name, memory := memoryName(filename)
+	if memory {
+		if container, found := memoryStore.container(name, form); found {
+			return container, nil
+		}
+	}
+
+	data, err := ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := parseContainer(filename, form, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if memory {
+		memoryStore.cacheContainer(name, form, data, container)
+	}
+
+	return container, nil
+}
+
+func parseContainer(filename string, form string, data []byte) (*encoder.Container, error) {
+	isPEM := false
+	if block, _ := pem.Decode(data); block != nil {
+		isPEM = true
+	}
+
+	var container *encoder.Container
+	var err error
+	switch form {
+	case FormatPEM:
+		if !isPEM {
+			return nil, InputFormatErrorf("%s: no PEM block found", filename)
+		}
+
+		container, err = encoder.ParseContainerChain(data)
+
+	case FormatDER:
+		container, err = encoder.NewDERContainer(data)
+
+	default:
+		container, err = encoder.ParseContainerChain(data)
+	}
+
+	if err != nil {
+		return nil, InputError(err)
+	}
+
+	return container, nil
+}
+
+func (c *Context) ReadInput() ([]byte, error) {
+	return ReadFile(c.InFilename)
+}
+
+func (c *Context) LoadContainer() (*encoder.Container, error) {
+	return LoadContainer(c.InFilename, c.InForm)
+}
+
+// Output is a file written atomically, content is written into a temporary file in the same
+// directory, and renamed to the target name on Commit. - is stdout. Content of in-memory
+// files is stored on Commit.
+type Output struct {
+	file     *os.File
+	filename string
+	finished bool
+	memory   *bytes.Buffer
+}
+
+// CreateOutput creates an output file, private files are only accessible by owner.
+func CreateOutput(filename string, private bool) (*Output, error) {
+	if _, ok := memoryName(filename); ok {
+		o := &Output{
+			filename: filename,
+			memory:   &bytes.Buffer{},
+		}
+
+		return o, nil
+	}
+
+	if filename == "-" {
+		o := &Output{
+			file:     os.Stdout,
+			filename: filename,
+		}
+
+		return o, nil
+	}
+
+	dir, base := filepath.Split(filename)
+	if len(dir) <= 0 {
+		dir = "."
+	}
+
+	file, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return nil, NewError(ErrorKindIO, err)
+	}
+
+	var perm os.FileMode = 0644
+	if private {
+		perm = 0600
+	}
+
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, NewError(ErrorKindIO, err)
+	}
+
+	o := &Output{
+		file:     file,
+		filename: filename,
+	}
+
+	return o, nil
+}
+
+func (o *Output) Write(p []byte) (int, error) {
+	if o.memory != nil {
+		return o.memory.Write(p)
+	}
+
+	return o.file.Write(p)
+}
+
+// Commit makes written content visible with the target name.
+func (o *Output) Commit() error {
+	if o.finished || o.file == os.Stdout {
+		o.finished = true
 		return nil
 	}
 
-	in, err := os.Open(inFile)
+	if o.memory != nil {
+		o.finished = true
+		name, _ := memoryName(o.filename)
+		memoryStore.Set(name, o.memory.Bytes())
+		return nil
+	}
+
+	o.finished = true
+	tmp := o.file.Name()
+	err := o.file.Sync()
+	if err == nil {
+		err = o.file.Close()
+	} else {
+		o.file.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, o.filename)
+	}
+
 	if err != nil {
-		return err
+		os.Remove(tmp)
+		return NewError(ErrorKindIO, err)
 	}
 
-	c.In = in
-	c.InFilename = inFile
 	return nil
 }
 
-func (c *Context) SetOut(outFile string) error {
-	if outFile == "-" {
-		c.Out = os.Stdout
-		c.OutFilename = "-"
-		return nil
+// Abort discards written content if not committed.
+func (o *Output) Abort() {
+	if o.finished || o.file == os.Stdout {
+		return
 	}
 
-	out, err := os.Create(outFile)
+	o.finished = true
+	if o.memory != nil {
+		return
+	}
+
+	o.file.Close()
+	os.Remove(o.file.Name())
+}
+
+func (c *Context) CreateOutput(private bool) (*Output, error) {
+	return CreateOutput(c.OutFilename, private)
+}
+
+// WriteOutput writes data to output file.
+func (c *Context) WriteOutput(data []byte, private bool) error {
+	out, err := c.CreateOutput(private)
 	if err != nil {
 		return err
 	}
 
-	c.Out = out
-	c.OutFilename = outFile
-	return nil
+	defer out.Abort()
+	if _, err := out.Write(data); err != nil {
+		return NewError(ErrorKindIO, err)
+	}
+
+	return out.Commit()
 }
 
-func (c *Context) Close() {
-	c.In.Close()
-	c.Out.Close()
+// EncodeObject encodes a DER encoded object in DER if form is der, or PEM otherwise.
+func EncodeObject(form string, pemType string, der []byte) []byte {
+	if form == FormatDER {
+		return der
+	}
+
+	return encoder.PEMEncode(pemType, der)
 }
 
-func (c *Context) CloseNonDefault() {
-	if c.InFilename != "-" {
-		c.In.Close()
+// WriteObject writes a DER encoded object in PEM or DER according to OutForm, PEM by default.
+func (c *Context) WriteObject(pemType string, der []byte, private bool) error {
+	switch c.OutForm {
+	case FormatPEM, FormatDER, "":
+		return c.WriteOutput(EncodeObject(c.OutForm, pemType, der), private)
+	}
+
+	return UsageErrorf("output format %s is not supported for %s", c.OutForm, strings.ToLower(pemType))
+}
+
+// Render writes output rendered by render, e.g. text description of objects. Output rendered
+// before render fails is still written, e.g. diagnostics of failed checks.
+func (c *Context) Render(private bool, render func(w io.Writer) error) error {
+	out, err := c.CreateOutput(private)
+	if err != nil {
+		return err
+	}
+
+	defer out.Abort()
+	w := io.Writer(out)
+	if c.Color || c.Width > 0 {
+		w = prettyprint.NewTerminal(out, c.Color, c.Width)
 	}
 
-	if c.OutFilename != "-" {
-		c.Out.Close()
+	renderErr := render(w)
+	if err := out.Commit(); err != nil && renderErr == nil {
+		return err
 	}
+
+	return renderErr
+}
+
+// RenderDocument writes doc with the renderer, in text or JSON.
+func (c *Context) RenderDocument(doc prettyprint.Document) error {
+	return c.renderDocument(false, doc)
+}
+
+// RenderPrivateDocument writes doc containing private keys, the output file is only
+// accessible by owner.
+func (c *Context) RenderPrivateDocument(doc prettyprint.Document) error {
+	return c.renderDocument(true, doc)
+}
+
+func (c *Context) renderDocument(private bool, doc prettyprint.Document) error {
+	return c.Render(private, func(w io.Writer) error {
+		return c.Renderer.Render(w, doc)
+	})
 }
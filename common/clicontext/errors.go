@@ -0,0 +1,153 @@
+package clicontext
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// ErrorKind classifies errors returned by commands, each kind has its own exit code.
+type ErrorKind int
+
+const (
+	ErrorKindGeneric ErrorKind = iota
+	ErrorKindUsage
+	ErrorKindIO
+	ErrorKindInputFormat
+	ErrorKindVerification
+)
+
+// Exit codes of gossl. 0 is success.
+const (
+	ExitCodeGeneric      = 1
+	ExitCodeUsage        = 2
+	ExitCodeIO           = 3
+	ExitCodeInputFormat  = 4
+	ExitCodeVerification = 5
+)
+
+var errorKindExitCodes = map[ErrorKind]int{
+	ErrorKindGeneric:      ExitCodeGeneric,
+	ErrorKindUsage:        ExitCodeUsage,
+	ErrorKindIO:           ExitCodeIO,
+	ErrorKindInputFormat:  ExitCodeInputFormat,
+	ErrorKindVerification: ExitCodeVerification,
+}
+
+var errorKindNames = map[ErrorKind]string{
+	ErrorKindGeneric:      "error",
+	ErrorKindUsage:        "usage error",
+	ErrorKindIO:           "I/O error",
+	ErrorKindInputFormat:  "input format error",
+	ErrorKindVerification: "verification failure",
+}
+
+func (k ErrorKind) String() string {
+	name, found := errorKindNames[k]
+	if found {
+		return name
+	}
+
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+func (k ErrorKind) ExitCode() int {
+	code, found := errorKindExitCodes[k]
+	if found {
+		return code
+	}
+
+	return ExitCodeGeneric
+}
+
+// Error is an error of a kind. Silent errors only set exit status, the message is not
+// printed, e.g. failures of checks with -status.
+type Error struct {
+	Kind   ErrorKind
+	Err    error
+	Silent bool
+}
+
+func NewError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{
+		Kind: kind,
+		Err:  err,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// SilentError returns err of the same kind, which is not printed.
+func SilentError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{
+		Kind:   ErrorKindOf(err),
+		Err:    err,
+		Silent: true,
+	}
+}
+
+// IsSilent returns true if err should not be printed.
+func IsSilent(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Silent
+}
+
+func UsageErrorf(format string, args ...any) error {
+	return NewError(ErrorKindUsage, fmt.Errorf(format, args...))
+}
+
+func InputFormatErrorf(format string, args ...any) error {
+	return NewError(ErrorKindInputFormat, fmt.Errorf(format, args...))
+}
+
+func VerificationErrorf(format string, args ...any) error {
+	return NewError(ErrorKindVerification, fmt.Errorf(format, args...))
+}
+
+// InputError classifies an error from loading input. File system errors are I/O errors,
+// errors already classified are kept, and the others are input format errors.
+func InputError(err error) error {
+	if err == nil || ErrorKindOf(err) != ErrorKindGeneric {
+		return err
+	}
+
+	return NewError(ErrorKindInputFormat, err)
+}
+
+// ErrorKindOf returns kind of err, file system errors are I/O errors if not classified.
+func ErrorKindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return ErrorKindIO
+	}
+
+	return ErrorKindGeneric
+}
+
+// ExitCode returns process exit code for err, 0 if err is nil.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	return ErrorKindOf(err).ExitCode()
+}
@@ -0,0 +1,45 @@
+package clicontext
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	_, pathErr := os.Open("/nonexistent/gossl")
+
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{nil, 0},
+		{errors.New("error"), ExitCodeGeneric},
+		{UsageErrorf("usage"), ExitCodeUsage},
+		{pathErr, ExitCodeIO},
+		{InputError(pathErr), ExitCodeIO},
+		{InputError(errors.New("bad data")), ExitCodeInputFormat},
+		{InputError(VerificationErrorf("bad key")), ExitCodeVerification},
+		{fmt.Errorf("wrapped: %w", InputFormatErrorf("bad data")), ExitCodeInputFormat},
+	}
+
+	for _, c := range cases {
+		if code := ExitCode(c.err); code != c.code {
+			t.Errorf("wrong exit code of %v: %d, expected %d", c.err, code, c.code)
+		}
+
+		if IsSilent(c.err) {
+			t.Errorf("error %v is silent", c.err)
+		}
+
+		silent := SilentError(c.err)
+		if code := ExitCode(silent); code != c.code {
+			t.Errorf("wrong exit code of silent %v: %d, expected %d", c.err, code, c.code)
+		}
+
+		if c.err != nil && !IsSilent(fmt.Errorf("wrapped: %w", silent)) {
+			t.Errorf("silent error %v is not silent", c.err)
+		}
+	}
+}
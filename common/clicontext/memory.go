@@ -0,0 +1,156 @@
+package clicontext
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/flily/go-ssl/common/encoder"
+)
+
+// MemoryPrefix marks names of in-memory files, e.g. @key, used in place of file names when
+// a memory store is enabled.
+const MemoryPrefix = "@"
+
+type memoryFile struct {
+	data       []byte
+	containers map[string]*encoder.Container
+}
+
+// MemoryStore keeps files in memory, with parsed keys and certificates cached, so commands
+// of a shell session share them without reading and parsing files again.
+type MemoryStore struct {
+	lock  sync.Mutex
+	files map[string]*memoryFile
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		files: make(map[string]*memoryFile),
+	}
+
+	return s
+}
+
+var memoryStore *MemoryStore
+
+// SetMemoryStore enables in-memory files of store, nil disables them.
+func SetMemoryStore(store *MemoryStore) {
+	memoryStore = store
+}
+
+// memoryName returns name of an in-memory file, if enabled and filename refers one.
+func memoryName(filename string) (string, bool) {
+	if memoryStore == nil || !strings.HasPrefix(filename, MemoryPrefix) {
+		return "", false
+	}
+
+	return filename[len(MemoryPrefix):], true
+}
+
+func (s *MemoryStore) Get(name string) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	file, found := s.files[name]
+	if !found {
+		return nil, false
+	}
+
+	return file.data, true
+}
+
+// Set stores data as name, cached objects of the old content are dropped.
+func (s *MemoryStore) Set(name string, data []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.files[name] = &memoryFile{
+		data:       data,
+		containers: make(map[string]*encoder.Container),
+	}
+}
+
+func (s *MemoryStore) Delete(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, found := s.files[name]
+	delete(s.files, name)
+	return found
+}
+
+// Names returns names of all files, sorted.
+func (s *MemoryStore) Names() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (s *MemoryStore) container(name string, form string) (*encoder.Container, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	file, found := s.files[name]
+	if !found {
+		return nil, false
+	}
+
+	container, found := file.containers[form]
+	return container, found
+}
+
+func (s *MemoryStore) cacheContainer(name string, form string, data []byte,
+	container *encoder.Container) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Content may be replaced while parsing.
+	file, found := s.files[name]
+	if found && bytes.Equal(file.data, data) {
+		file.containers[form] = container
+	}
+}
+
+func readMemoryFile(name string) ([]byte, error) {
+	data, found := memoryStore.Get(name)
+	if !found {
+		return nil, NewError(ErrorKindIO, fmt.Errorf("%s%s: no such variable", MemoryPrefix, name))
+	}
+
+	return data, nil
+}
+
+// OpenFile opens a file for reading, - for stdin, or an in-memory file. Errors are I/O errors.
+func OpenFile(filename string) (io.ReadCloser, error) {
+	if name, ok := memoryName(filename); ok {
+		data, err := readMemoryFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	if filename == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, NewError(ErrorKindIO, err)
+	}
+
+	return file, nil
+}
@@ -0,0 +1,53 @@
+package clicontext
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+
+	"github.com/flily/go-ssl/common/encoder"
+)
+
+func TestMemoryFiles(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetMemoryStore(NewMemoryStore())
+	defer SetMemoryStore(nil)
+
+	cio := &Context{OutFilename: "@key", OutForm: FormatPEM}
+	if err := cio.WriteObject("PRIVATE KEY", der, true); err != nil {
+		t.Fatalf("write to memory failed: %s", err)
+	}
+
+	container, err := LoadContainer("@key", FormatAuto)
+	if err != nil {
+		t.Fatalf("load from memory failed: %s", err)
+	}
+
+	if container.KeyType() != encoder.KeyTypeECPrivateKey {
+		t.Errorf("wrong key type: %s", container.KeyTypeString())
+	}
+
+	if cached, _ := LoadContainer("@key", FormatAuto); cached != container {
+		t.Errorf("parsed key is not cached")
+	}
+
+	if _, err := ReadFile("@missing"); ErrorKindOf(err) != ErrorKindIO {
+		t.Errorf("missing in-memory file should be an I/O error: %v", err)
+	}
+
+	SetMemoryStore(nil)
+	if _, err := ReadFile("@key"); err == nil {
+		t.Errorf("in-memory files should be disabled")
+	}
+}
@@ -0,0 +1,220 @@
+package clicontext
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Command describes a command or a group of subcommands. Flags registers options of the
+// command into a flag set, parsed values are read with CommandContext.String etc. in Run.
+type Command struct {
+	Name    string
+	Aliases []string
+	Short   string
+	Long    string
+
+	// Usage describes positional arguments, e.g. "[file ...]".
+	Usage string
+
+	Flags       func(set *flag.FlagSet)
+	Run         CommandEntryFunc
+	Subcommands []*Command
+
+	// Default is name of the subcommand to run when no subcommand is given.
+	Default string
+
+	// FlagValues lists enumerated values of flags, used by shell completion. String flags
+	// without enumerated values are completed as file names.
+	FlagValues map[string][]string
+
+	// FileArgs reports whether positional arguments are file names. Commands of file
+	// arguments with subcommands run Run if the first argument is not a subcommand.
+	FileArgs bool
+}
+
+// Lookup finds a subcommand by name or alias.
+func (c *Command) Lookup(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+
+		for _, alias := range sub.Aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+
+	return nil
+}
+
+// Find walks down the command tree by names, returns nil if any name is unknown.
+func (c *Command) Find(names ...string) *Command {
+	cmd := c
+	for _, name := range names {
+		cmd = cmd.Lookup(name)
+		if cmd == nil {
+			return nil
+		}
+	}
+
+	return cmd
+}
+
+// SortedSubcommands returns subcommands ordered by name.
+func (c *Command) SortedSubcommands() []*Command {
+	result := make([]*Command, len(c.Subcommands))
+	copy(result, c.Subcommands)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// NewFlagSet creates a flag set with all flags of the command registered. Errors and usage
+// are not printed by the flag set, but by Execute.
+func (c *Command) NewFlagSet(name string) *flag.FlagSet {
+	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+	if c.Flags != nil {
+		c.Flags(set)
+	}
+
+	return set
+}
+
+func (c *Command) usageLine(path string) string {
+	parts := []string{path}
+	if len(c.Subcommands) > 0 {
+		parts = append(parts, "<command>")
+	}
+
+	if c.Flags != nil {
+		parts = append(parts, "[options]")
+	}
+
+	if len(c.Usage) > 0 {
+		parts = append(parts, c.Usage)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// WriteUsage writes a brief usage of the command.
+func (c *Command) WriteUsage(w io.Writer, path string) {
+	fmt.Fprintf(w, "Usage: %s\n", c.usageLine(path))
+	if len(c.Subcommands) > 0 || c.Flags != nil {
+		fmt.Fprintf(w, "Run '%s -h' for more information.\n", path)
+	}
+}
+
+// WriteHelp writes full help of the command, including description, flags and subcommands.
+func (c *Command) WriteHelp(w io.Writer, path string) {
+	fmt.Fprintf(w, "Usage: %s\n", c.usageLine(path))
+
+	description := c.Long
+	if len(description) <= 0 {
+		description = c.Short
+	}
+
+	if len(description) > 0 {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimRight(description, "\n"))
+	}
+
+	if len(c.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(c.Aliases, ", "))
+	}
+
+	if c.Flags != nil {
+		fmt.Fprintf(w, "\nOptions:\n")
+		writeFlags(w, c.NewFlagSet(path))
+	}
+
+	if len(c.Subcommands) > 0 {
+		fmt.Fprintf(w, "\nCommands:\n")
+		writeCommandList(w, c.SortedSubcommands())
+		fmt.Fprintf(w, "\nRun '%s <command> -h' for help of a command.\n", path)
+	}
+}
+
+func writeCommandList(w io.Writer, commands []*Command) {
+	width := 0
+	for _, cmd := range commands {
+		if len(cmd.Name) > width {
+			width = len(cmd.Name)
+		}
+	}
+
+	for _, cmd := range commands {
+		short := cmd.Short
+		if len(cmd.Aliases) > 0 {
+			short = fmt.Sprintf("%s (aliases: %s)", short, strings.Join(cmd.Aliases, ", "))
+		}
+
+		fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.Name, short)
+	}
+}
+
+func writeFlags(w io.Writer, set *flag.FlagSet) {
+	set.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		if choices, ok := f.Value.(*choiceValue); ok {
+			name = strings.Join(choices.Choices(), "|")
+		}
+
+		if len(name) > 0 {
+			fmt.Fprintf(w, "  -%s %s\n", f.Name, name)
+		} else {
+			fmt.Fprintf(w, "  -%s\n", f.Name)
+		}
+
+		if !isZeroValue(f) {
+			if _, ok := f.Value.(*choiceValue); ok || name == "string" {
+				usage = fmt.Sprintf("%s (default %q)", usage, f.DefValue)
+			} else {
+				usage = fmt.Sprintf("%s (default %s)", usage, f.DefValue)
+			}
+		}
+
+		fmt.Fprintf(w, "        %s\n", usage)
+	})
+}
+
+func isZeroValue(f *flag.Flag) bool {
+	switch f.DefValue {
+	case "", "false", "0":
+		return true
+	}
+
+	return false
+}
+
+// HelpCommand creates a `help` command rendering help of any command in the tree.
+func HelpCommand() *Command {
+	return &Command{
+		Name:  "help",
+		Short: "Show help of a command",
+		Usage: "[command ...]",
+		Run: func(ctx *CommandContext) error {
+			cmd := ctx.Root()
+			path := []string{cmd.Name}
+			for _, name := range ctx.Args {
+				cmd = cmd.Lookup(name)
+				if cmd == nil {
+					return UsageErrorf("unknown command: %s", strings.Join(ctx.Args, " "))
+				}
+
+				path = append(path, cmd.Name)
+			}
+
+			cmd.WriteHelp(os.Stdout, strings.Join(path, " "))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,316 @@
+package clicontext
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flily/go-ssl/common/prettyprint"
+)
+
+func TestCommandExecute(t *testing.T) {
+	var got string
+	root := &Command{
+		Name: "tool",
+		Subcommands: []*Command{
+			{
+				Name:    "show",
+				Aliases: []string{"s"},
+				Short:   "Show something",
+				Flags: func(set *flag.FlagSet) {
+					set.String("in", "-", "Input file")
+					set.Int("n", 1, "Count")
+				},
+				Run: func(ctx *CommandContext) error {
+					got = strings.Join([]string{
+						ctx.CurrentCommand(), ctx.String("in"),
+						strings.Repeat("x", ctx.Int("n")),
+						strings.Join(ctx.Args, ","),
+					}, "|")
+					return nil
+				},
+			},
+			{Name: "add", Short: "Add something"},
+		},
+	}
+
+	err := Execute(root, []string{"tool", "s", "-in", "a.pem", "-n", "2", "b", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := "tool show|a.pem|xx|b,c"; got != expected {
+		t.Errorf("wrong result: %s, expected %s", got, expected)
+	}
+
+	if err := Execute(root, []string{"tool", "unknown"}); err == nil {
+		t.Errorf("unknown command should fail")
+	}
+
+	if err := Execute(root, []string{"tool", "show", "-unknown"}); err == nil {
+		t.Errorf("unknown flag should fail")
+	}
+
+	buffer := &bytes.Buffer{}
+	root.WriteHelp(buffer, "tool")
+	help := buffer.String()
+	if strings.Index(help, "add") > strings.Index(help, "show") {
+		t.Errorf("subcommands are not sorted:\n%s", help)
+	}
+}
+
+func TestWriteCompletion(t *testing.T) {
+	root := &Command{
+		Name: "tool",
+		Subcommands: []*Command{
+			{
+				Name:    "gen",
+				Aliases: []string{"g"},
+				Flags: func(set *flag.FlagSet) {
+					set.String("curve", "p256", "Curve name")
+					set.String("out", "-", "Output file")
+				},
+				FlagValues: map[string][]string{
+					"curve": {"p256", "p384"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		writer   func(w *bytes.Buffer)
+		expected []string
+	}{
+		{
+			func(w *bytes.Buffer) { WriteBashCompletion(w, root) },
+			[]string{`"tool/g") cmdpath="tool gen"`, `compgen -W 'p256 p384'`, `"tool gen/-out")`},
+		},
+		{
+			func(w *bytes.Buffer) { WriteZshCompletion(w, root) },
+			[]string{`#compdef tool`, `compadd -- p256 p384`, `'-out:Output file'`},
+		},
+		{
+			func(w *bytes.Buffer) { WriteFishCompletion(w, root) },
+			[]string{`-o 'curve' -d 'Curve name' -x -a 'p256 p384'`, `-o 'out' -d 'Output file' -r -F`},
+		},
+	}
+
+	for _, c := range cases {
+		buffer := &bytes.Buffer{}
+		c.writer(buffer)
+		for _, s := range c.expected {
+			if !strings.Contains(buffer.String(), s) {
+				t.Errorf("%q not found in script:\n%s", s, buffer.String())
+			}
+		}
+	}
+}
+
+func TestGlobalFlags(t *testing.T) {
+	var json, set bool
+	root := &Command{
+		Name:  "tool",
+		Flags: RenderFlags,
+		Subcommands: []*Command{
+			{
+				Name: "show",
+				Run: func(ctx *CommandContext) error {
+					json, set = ctx.Bool("json"), ctx.IsSet("json")
+					return nil
+				},
+			},
+		},
+	}
+
+	if err := Execute(root, []string{"tool", "-json", "show"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !json || !set {
+		t.Errorf("global flag -json is not found in subcommand")
+	}
+
+	if err := Execute(root, []string{"tool", "show"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if json || set {
+		t.Errorf("global flag -json should not be set")
+	}
+}
+
+func TestListFlag(t *testing.T) {
+	var values []string
+	root := &Command{
+		Name: "tool",
+		Flags: func(set *flag.FlagSet) {
+			List(set, "opt", "Options")
+		},
+		Run: func(ctx *CommandContext) error {
+			values = ctx.Strings("opt")
+			return nil
+		},
+	}
+
+	if err := Execute(root, []string{"tool", "-opt", "a:1", "-opt", "b:2"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if strings.Join(values, ",") != "a:1,b:2" {
+		t.Errorf("wrong values: %v", values)
+	}
+
+	if err := Execute(root, []string{"tool"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(values) != 0 {
+		t.Errorf("values should be empty: %v", values)
+	}
+}
+
+func TestFileArgsWithSubcommands(t *testing.T) {
+	var got string
+	root := &Command{
+		Name:     "tool",
+		FileArgs: true,
+		Run: func(ctx *CommandContext) error {
+			got = "files:" + strings.Join(ctx.Args, ",")
+			return nil
+		},
+		Subcommands: []*Command{
+			{
+				Name: "sub",
+				Run: func(ctx *CommandContext) error {
+					got = "sub:" + strings.Join(ctx.Args, ",")
+					return nil
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"tool", "a", "b"}, "files:a,b"},
+		{[]string{"tool"}, "files:"},
+		{[]string{"tool", "sub", "a"}, "sub:a"},
+	}
+
+	for _, c := range cases {
+		if err := Execute(root, c.args); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got != c.expected {
+			t.Errorf("wrong result of %v: %s, expected %s", c.args, got, c.expected)
+		}
+	}
+}
+
+func TestChoiceFlag(t *testing.T) {
+	var got string
+	root := &Command{
+		Name: "tool",
+		Flags: func(set *flag.FlagSet) {
+			Choice(set, "encoding", "hex", "Encoding", "hex", "HEX", "base64")
+		},
+		Run: func(ctx *CommandContext) error {
+			got = ctx.String("encoding")
+			return nil
+		},
+	}
+
+	cases := []struct {
+		value    string
+		expected string
+	}{
+		{"hex", "hex"},
+		{"HEX", "HEX"},
+		{"Hex", "hex"},
+		{"BASE64", "base64"},
+	}
+
+	for _, c := range cases {
+		if err := Execute(root, []string{"tool", "-encoding", c.value}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got != c.expected {
+			t.Errorf("wrong choice of %s: %s, expected %s", c.value, got, c.expected)
+		}
+	}
+
+	if err := Execute(root, []string{"tool", "-encoding", "base32"}); err == nil {
+		t.Errorf("invalid choice should fail")
+	}
+}
+
+func TestOutputFormJSON(t *testing.T) {
+	var cio *Context
+	root := &Command{
+		Name:  "tool",
+		Flags: RenderFlags,
+		Subcommands: []*Command{
+			{
+				Name: "show",
+				Flags: func(set *flag.FlagSet) {
+					OutputFlags(set, FormatText, FormatText, FormatJSON, FormatPEM, FormatDER)
+				},
+				Run: func(ctx *CommandContext) error {
+					cio = ctx.IO()
+					return nil
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		args     []string
+		document bool
+		json     bool
+	}{
+		{[]string{"tool", "show"}, true, false},
+		{[]string{"tool", "-json", "show"}, true, true},
+		{[]string{"tool", "show", "-outform", "json"}, true, true},
+		{[]string{"tool", "show", "-outform", "pem"}, false, false},
+	}
+
+	for _, c := range cases {
+		if err := Execute(root, c.args); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if cio.IsDocument() != c.document {
+			t.Errorf("document output of %v is %v, expected %v", c.args, cio.IsDocument(), c.document)
+		}
+
+		if _, json := cio.Renderer.(prettyprint.JSONRenderer); json != c.json {
+			t.Errorf("JSON output of %v is %v, expected %v", c.args, json, c.json)
+		}
+	}
+}
+
+func TestRenderKeepsOutputOnError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.txt")
+	cio := &Context{OutFilename: filename}
+	err := cio.Render(false, func(w io.Writer) error {
+		fmt.Fprintf(w, "checking: failed\n")
+		return VerificationErrorf("bad parameters")
+	})
+
+	if ErrorKindOf(err) != ErrorKindVerification {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	data, readErr := os.ReadFile(filename)
+	if readErr != nil || string(data) != "checking: failed\n" {
+		t.Errorf("wrong output kept: %q, %v", data, readErr)
+	}
+}
@@ -0,0 +1,46 @@
+package clicontext
+
+import (
+	"os"
+	"strconv"
+)
+
+// Values of -color.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// NoColorEnv disables colors of auto mode if not empty, see https://no-color.org.
+const NoColorEnv = "NO_COLOR"
+
+// IsTerminal reports whether file is a terminal.
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// TerminalWidth returns number of columns of terminal file, $COLUMNS takes precedence.
+// 0 is returned if unknown.
+func TerminalWidth(file *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	return terminalWidth(file)
+}
+
+// useColor reports whether output is colored, terminal tells whether output is a terminal.
+// In auto mode, only terminals are colored unless $NO_COLOR is set.
+func (c *CommandContext) useColor(terminal bool) bool {
+	switch c.flagString("color", ColorAuto) {
+	case ColorAlways:
+		return true
+
+	case ColorNever:
+		return false
+	}
+
+	return terminal && len(os.Getenv(NoColorEnv)) <= 0
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package clicontext
+
+import (
+	"os"
+)
+
+func terminalWidth(file *os.File) int {
+	return 0
+}
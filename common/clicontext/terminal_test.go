@@ -0,0 +1,65 @@
+package clicontext
+
+import (
+	"testing"
+)
+
+func TestColorFlag(t *testing.T) {
+	cases := []struct {
+		args     []string
+		noColor  string
+		expected bool
+	}{
+		{[]string{"tool", "show"}, "", false},
+		{[]string{"tool", "-color", "always", "show"}, "", true},
+		{[]string{"tool", "-color", "always", "show"}, "1", true},
+		{[]string{"tool", "-color", "never", "show"}, "", false},
+	}
+
+	for _, c := range cases {
+		t.Setenv(NoColorEnv, c.noColor)
+		var cio *Context
+		root := &Command{
+			Name:  "tool",
+			Flags: RenderFlags,
+			Subcommands: []*Command{
+				{
+					Name: "show",
+					Run: func(ctx *CommandContext) error {
+						cio = ctx.IO()
+						return nil
+					},
+				},
+			},
+		}
+
+		if err := Execute(root, c.args); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if cio.Color != c.expected {
+			t.Errorf("color of %v is %v, expected %v", c.args, cio.Color, c.expected)
+		}
+	}
+}
+
+func TestColorAuto(t *testing.T) {
+	cases := []struct {
+		terminal bool
+		noColor  string
+		expected bool
+	}{
+		{true, "", true},
+		{true, "1", false},
+		{false, "", false},
+	}
+
+	for _, c := range cases {
+		t.Setenv(NoColorEnv, c.noColor)
+		ctx := &CommandContext{}
+		if color := ctx.useColor(c.terminal); color != c.expected {
+			t.Errorf("color of terminal %v with NO_COLOR=%q is %v, expected %v",
+				c.terminal, c.noColor, color, c.expected)
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultSection is the section of entries before any section header. Lookups fall back
+// to it, as openssl.cnf does.
+const DefaultSection = "default"
+
+// EnvSection is the pseudo section of environment variables in variable expansion,
+// e.g. $ENV::HOME.
+const EnvSection = "ENV"
+
+type Entry struct {
+	Name  string
+	Value string
+}
+
+// Section is a named list of entries, in the order they first appear.
+type Section struct {
+	Name    string
+	Entries []Entry
+}
+
+// Get returns value of entry name in the section.
+func (s *Section) Get(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	for _, entry := range s.Entries {
+		if entry.Name == name {
+			return entry.Value, true
+		}
+	}
+
+	return "", false
+}
+
+func (s *Section) set(name string, value string) {
+	for i, entry := range s.Entries {
+		if entry.Name == name {
+			s.Entries[i].Value = value
+			return
+		}
+	}
+
+	s.Entries = append(s.Entries, Entry{name, value})
+}
+
+// Config is a configuration file in the common subset of openssl.cnf, i.e. sections,
+// name = value entries, variable expansion and .include directives.
+type Config struct {
+	Filename string
+	sections map[string]*Section
+}
+
+func New() *Config {
+	c := &Config{
+		sections: make(map[string]*Section),
+	}
+
+	return c
+}
+
+// Section returns section name, or nil if not defined.
+func (c *Config) Section(name string) *Section {
+	if c == nil {
+		return nil
+	}
+
+	return c.sections[name]
+}
+
+// Sections returns names of all sections, sorted.
+func (c *Config) Sections() []string {
+	names := make([]string, 0, len(c.sections))
+	for name := range c.sections {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Get returns value of name in section, or in the default section if not found.
+func (c *Config) Get(section string, name string) (string, bool) {
+	if value, found := c.Section(section).Get(name); found {
+		return value, true
+	}
+
+	return c.Section(DefaultSection).Get(name)
+}
+
+// GetString returns value of name in section, or value if not found.
+func (c *Config) GetString(section string, name string, value string) string {
+	if v, found := c.Get(section, name); found {
+		return v
+	}
+
+	return value
+}
+
+func (c *Config) section(name string) *Section {
+	s, found := c.sections[name]
+	if !found {
+		s = &Section{Name: name}
+		c.sections[name] = s
+	}
+
+	return s
+}
+
+// Load reads configuration file filename.
+func Load(filename string) (*Config, error) {
+	c := New()
+	c.Filename = filename
+	p := &parser{config: c, section: DefaultSection}
+	if err := p.loadFile(filename, 0); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Parse reads configuration from r, name is used in error messages and as base of
+// relative .include paths.
+func Parse(r io.Reader, name string) (*Config, error) {
+	c := New()
+	c.Filename = name
+	p := &parser{config: c, section: DefaultSection}
+	if err := p.parse(r, name, 0); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// ParseString reads configuration from string s.
+func ParseString(s string) (*Config, error) {
+	return Parse(strings.NewReader(s), "<string>")
+}
+
+const maxIncludeDepth = 8
+
+type parser struct {
+	config  *Config
+	section string
+}
+
+func (p *parser) loadFile(filename string, depth int) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		return p.parse(file, filename, depth)
+	}
+
+	// Directories include all *.cnf and *.conf files in name order.
+	entries, err := os.ReadDir(filename)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".cnf" && ext != ".conf") {
+			continue
+		}
+
+		if err := p.loadFile(filepath.Join(filename, entry.Name()), depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) parse(r io.Reader, name string, depth int) error {
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		start := lineno
+		line := scanner.Text()
+		for continued(line) && scanner.Scan() {
+			lineno++
+			line = line[:len(line)-1] + scanner.Text()
+		}
+
+		if err := p.parseLine(stripComment(line), name, depth); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, start, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// continued reports whether line ends with a backslash not escaped.
+func continued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+// stripComment removes # comment not quoted or escaped.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '\\':
+			i++
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+func (p *parser) parseLine(line string, name string, depth int) error {
+	line = strings.TrimSpace(line)
+	if len(line) <= 0 {
+		return nil
+	}
+
+	if line[0] == '[' {
+		end := strings.IndexByte(line, ']')
+		if end < 0 {
+			return fmt.Errorf("missing close bracket of section")
+		}
+
+		section := strings.TrimSpace(line[1:end])
+		if len(section) <= 0 || !isName(section) {
+			return fmt.Errorf("invalid section name '%s'", section)
+		}
+
+		p.section = section
+		p.config.section(section)
+		return nil
+	}
+
+	if directive, arg, found := cutDirective(line); found {
+		switch directive {
+		case ".include":
+			return p.include(arg, name, depth)
+		case ".pragma":
+			return nil
+		}
+
+		return fmt.Errorf("unknown directive %s", directive)
+	}
+
+	key, raw, found := strings.Cut(line, "=")
+	if !found {
+		return fmt.Errorf("missing equal sign")
+	}
+
+	key = strings.TrimSpace(key)
+	section := p.section
+	if s, k, found := strings.Cut(key, "::"); found {
+		section, key = s, k
+	}
+
+	if len(key) <= 0 || !isName(key) || !isName(section) {
+		return fmt.Errorf("invalid name '%s'", key)
+	}
+
+	value, err := p.expand(strings.TrimSpace(raw))
+	if err != nil {
+		return err
+	}
+
+	p.config.section(section).set(key, value)
+	return nil
+}
+
+// cutDirective splits `.include path` or `.include = path`.
+func cutDirective(line string) (string, string, bool) {
+	if line[0] != '.' {
+		return "", "", false
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return line, "", true
+	}
+
+	directive := line[:end]
+	if directive != ".include" && directive != ".pragma" {
+		return "", "", false
+	}
+
+	arg := strings.TrimSpace(line[end:])
+	arg = strings.TrimSpace(strings.TrimPrefix(arg, "="))
+	return directive, arg, true
+}
+
+func (p *parser) include(arg string, name string, depth int) error {
+	if depth >= maxIncludeDepth {
+		return fmt.Errorf("too many levels of .include")
+	}
+
+	filename, err := p.expand(arg)
+	if err != nil {
+		return err
+	}
+
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(filepath.Dir(name), filename)
+	}
+
+	return p.loadFile(filename, depth+1)
+}
+
+func isNameChar(ch byte) bool {
+	return ch == '_' || ch == '.' || ch == '-' ||
+		(ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isVariableChar(ch byte) bool {
+	return ch == '_' ||
+		(ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// expand processes quotes, escapes and variables of a raw value.
+func (p *parser) expand(raw string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch ch {
+		case '"', '\'':
+			end := strings.IndexByte(raw[i+1:], ch)
+			if end < 0 {
+				return "", fmt.Errorf("missing close quote")
+			}
+
+			quoted := raw[i+1 : i+1+end]
+			if ch == '"' {
+				quoted = unescape(quoted)
+			}
+
+			b.WriteString(quoted)
+			i += end + 1
+
+		case '\\':
+			if i+1 < len(raw) {
+				i++
+				b.WriteString(unescape(raw[i-1 : i+1]))
+			}
+
+		case '$':
+			value, n, err := p.variable(raw[i+1:])
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(value)
+			i += n
+
+		default:
+			b.WriteByte(ch)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch != '\\' || i+1 >= len(s) {
+			b.WriteByte(ch)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// variable resolves variable reference s after $, i.e. name, {name}, (name) or
+// section::name, returns value and length of reference consumed.
+func (p *parser) variable(s string) (string, int, error) {
+	var close byte
+	start := 0
+	if len(s) > 0 && (s[0] == '{' || s[0] == '(') {
+		close = '}'
+		if s[0] == '(' {
+			close = ')'
+		}
+
+		start = 1
+	}
+
+	scan := func(from int) int {
+		i := from
+		for i < len(s) && isVariableChar(s[i]) {
+			i++
+		}
+
+		return i
+	}
+
+	end := scan(start)
+	section, name := p.section, s[start:end]
+	if strings.HasPrefix(s[end:], "::") {
+		section = name
+		nameStart := end + 2
+		end = scan(nameStart)
+		name = s[nameStart:end]
+	}
+
+	if len(name) <= 0 {
+		return "", 0, fmt.Errorf("invalid variable reference")
+	}
+
+	if close != 0 {
+		if end >= len(s) || s[end] != close {
+			return "", 0, fmt.Errorf("missing close brace of variable %s", name)
+		}
+
+		end++
+	}
+
+	value, found := p.config.Get(section, name)
+	if !found && section == EnvSection {
+		value, found = os.LookupEnv(name)
+	}
+
+	if !found {
+		return "", 0, fmt.Errorf("variable %s has no value", name)
+	}
+
+	return value, end, nil
+}
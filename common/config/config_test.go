@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Setenv("GOSSL_TEST_HOME", "/home/gossl")
+
+	text := `
+# comment
+dir = /etc/ssl  # trailing comment
+name = "quoted # not comment"
+
+[ req ]
+default_bits = 2048
+keyfile = $dir/private/key.pem
+home = ${ENV::GOSSL_TEST_HOME}
+bits = $(default_bits)
+escaped = a\$b\#c
+long = first \
+       second
+
+[other]
+copied = $req::default_bits
+req::extra = 1
+`
+
+	c, err := ParseString(text)
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	cases := []struct {
+		section string
+		name    string
+		value   string
+	}{
+		{DefaultSection, "dir", "/etc/ssl"},
+		{DefaultSection, "name", "quoted # not comment"},
+		{"req", "default_bits", "2048"},
+		{"req", "keyfile", "/etc/ssl/private/key.pem"},
+		{"req", "home", "/home/gossl"},
+		{"req", "bits", "2048"},
+		{"req", "escaped", "a$b#c"},
+		{"req", "long", "first        second"},
+		{"req", "extra", "1"},
+		{"req", "dir", "/etc/ssl"},
+		{"other", "copied", "2048"},
+	}
+
+	for _, c2 := range cases {
+		value, found := c.Get(c2.section, c2.name)
+		if !found || value != c2.value {
+			t.Errorf("wrong value of %s::%s: '%s' (%v), expected '%s'",
+				c2.section, c2.name, value, found, c2.value)
+		}
+	}
+
+	if _, found := c.Section("req").Get("dir"); found {
+		t.Errorf("dir should only be found in default section")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []string{
+		"[req\n",
+		"name value\n",
+		"a = $undefined\n",
+		"a = ${b\n",
+		"a = \"open\n",
+		".unknown x\n",
+	}
+
+	for _, text := range cases {
+		if _, err := ParseString(text); err == nil {
+			t.Errorf("parse of %q should fail", text)
+		}
+	}
+}
+
+func TestLoadInclude(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.cnf")
+	sub := filepath.Join(dir, "conf.d")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		main:                              "a = 1\n.include conf.d\n[s]\nc = $b\n",
+		filepath.Join(sub, "b.cnf"):       "b = 2\n",
+		filepath.Join(sub, "ignored.txt"): "b = 3\n",
+	}
+
+	for filename, content := range files {
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := Load(main)
+	if err != nil {
+		t.Fatalf("load failed: %s", err)
+	}
+
+	if value, _ := c.Get("s", "c"); value != "2" {
+		t.Errorf("wrong value of s::c: '%s'", value)
+	}
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	oidEmailAddress    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+	oidDomainComponent = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 25}
+
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtensionExtKeyUsage      = asn1.ObjectIdentifier{2, 5, 29, 37}
+)
+
+// addAttribute adds a distinguished name attribute by its short or long name.
+func addAttribute(name *pkix.Name, attr string, value string) error {
+	switch attr {
+	case "C", "countryName":
+		name.Country = append(name.Country, value)
+	case "ST", "stateOrProvinceName":
+		name.Province = append(name.Province, value)
+	case "L", "localityName":
+		name.Locality = append(name.Locality, value)
+	case "street", "streetAddress":
+		name.StreetAddress = append(name.StreetAddress, value)
+	case "postalCode":
+		name.PostalCode = append(name.PostalCode, value)
+	case "O", "organizationName":
+		name.Organization = append(name.Organization, value)
+	case "OU", "organizationalUnitName":
+		name.OrganizationalUnit = append(name.OrganizationalUnit, value)
+	case "CN", "commonName":
+		name.CommonName = value
+	case "serialNumber":
+		name.SerialNumber = value
+	case "emailAddress":
+		name.ExtraNames = append(name.ExtraNames, pkix.AttributeTypeAndValue{
+			Type: oidEmailAddress, Value: value})
+	case "DC", "domainComponent":
+		name.ExtraNames = append(name.ExtraNames, pkix.AttributeTypeAndValue{
+			Type: oidDomainComponent, Value: value})
+	default:
+		return fmt.Errorf("unknown distinguished name attribute %s", attr)
+	}
+
+	return nil
+}
+
+// ParseSubject parses subject in form of /type0=value0/type1=value1, e.g. /C=US/CN=name.
+func ParseSubject(s string) (pkix.Name, error) {
+	var name pkix.Name
+	if !strings.HasPrefix(s, "/") {
+		return name, fmt.Errorf("subject must start with '/'")
+	}
+
+	for _, part := range strings.Split(s[1:], "/") {
+		if len(part) <= 0 {
+			continue
+		}
+
+		attr, value, found := strings.Cut(part, "=")
+		if !found {
+			return name, fmt.Errorf("missing value of %s in subject", part)
+		}
+
+		if err := addAttribute(&name, attr, value); err != nil {
+			return name, err
+		}
+	}
+
+	return name, nil
+}
+
+// DistinguishedName builds a name from section, as distinguished_name of req section. If
+// prompt is true, entries are prompts and values are taken from name_default entries.
+func (c *Config) DistinguishedName(section string, prompt bool) (pkix.Name, error) {
+	var name pkix.Name
+	s := c.Section(section)
+	if s == nil {
+		return name, fmt.Errorf("section %s not found", section)
+	}
+
+	for _, entry := range s.Entries {
+		attr := entry.Name
+		if prompt {
+			var found bool
+			attr, found = strings.CutSuffix(attr, "_default")
+			if !found {
+				continue
+			}
+		} else if strings.HasSuffix(attr, "_min") || strings.HasSuffix(attr, "_max") {
+			continue
+		}
+
+		// Prefix N. allows an attribute to appear more than once, e.g. 0.OU and 1.OU
+		if prefix, rest, found := strings.Cut(attr, "."); found {
+			if _, err := strconv.Atoi(prefix); err == nil {
+				attr = rest
+			}
+		}
+
+		if len(entry.Value) <= 0 {
+			continue
+		}
+
+		if err := addAttribute(&name, attr, entry.Value); err != nil {
+			return name, fmt.Errorf("section %s: %w", section, err)
+		}
+	}
+
+	return name, nil
+}
+
+// Extensions are X.509 v3 extensions defined in an extension section, e.g. x509_extensions
+// or req_extensions of req section.
+type Extensions struct {
+	BasicConstraintsValid    bool
+	BasicConstraintsCritical bool
+	IsCA                     bool
+	MaxPathLen               int
+	MaxPathLenZero           bool
+
+	KeyUsage         x509.KeyUsage
+	KeyUsageCritical bool
+
+	ExtKeyUsage         []x509.ExtKeyUsage
+	ExtKeyUsageCritical bool
+
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []net.IP
+	URIs           []*url.URL
+
+	CRLDistributionPoints []string
+	OCSPServer            []string
+	IssuingCertificateURL []string
+}
+
+var keyUsageNames = map[string]x509.KeyUsage{
+	"digitalSignature": x509.KeyUsageDigitalSignature,
+	"nonRepudiation":   x509.KeyUsageContentCommitment,
+	"keyEncipherment":  x509.KeyUsageKeyEncipherment,
+	"dataEncipherment": x509.KeyUsageDataEncipherment,
+	"keyAgreement":     x509.KeyUsageKeyAgreement,
+	"keyCertSign":      x509.KeyUsageCertSign,
+	"cRLSign":          x509.KeyUsageCRLSign,
+	"encipherOnly":     x509.KeyUsageEncipherOnly,
+	"decipherOnly":     x509.KeyUsageDecipherOnly,
+}
+
+var extKeyUsageNames = map[string]x509.ExtKeyUsage{
+	"anyExtendedKeyUsage": x509.ExtKeyUsageAny,
+	"serverAuth":          x509.ExtKeyUsageServerAuth,
+	"clientAuth":          x509.ExtKeyUsageClientAuth,
+	"codeSigning":         x509.ExtKeyUsageCodeSigning,
+	"emailProtection":     x509.ExtKeyUsageEmailProtection,
+	"timeStamping":        x509.ExtKeyUsageTimeStamping,
+	"OCSPSigning":         x509.ExtKeyUsageOCSPSigning,
+	"ipsecEndSystem":      x509.ExtKeyUsageIPSECEndSystem,
+	"ipsecTunnel":         x509.ExtKeyUsageIPSECTunnel,
+	"ipsecUser":           x509.ExtKeyUsageIPSECUser,
+}
+
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) > 0 {
+			result = append(result, part)
+		}
+	}
+
+	return result
+}
+
+// cutCritical removes leading critical from list, reports whether it is found.
+func cutCritical(list []string) ([]string, bool) {
+	if len(list) > 0 && list[0] == "critical" {
+		return list[1:], true
+	}
+
+	return list, false
+}
+
+// Extensions reads extensions from section, in format of openssl x509v3_config.
+func (c *Config) Extensions(section string) (*Extensions, error) {
+	s := c.Section(section)
+	if s == nil {
+		return nil, fmt.Errorf("extension section %s not found", section)
+	}
+
+	e := &Extensions{}
+	for _, entry := range s.Entries {
+		if err := c.addExtension(e, entry.Name, entry.Value); err != nil {
+			return nil, fmt.Errorf("section %s: %s: %w", section, entry.Name, err)
+		}
+	}
+
+	return e, nil
+}
+
+func (c *Config) addExtension(e *Extensions, name string, value string) error {
+	list, critical := cutCritical(splitList(value))
+	switch name {
+	case "basicConstraints":
+		e.BasicConstraintsValid = true
+		e.BasicConstraintsCritical = critical
+		for _, item := range list {
+			k, v, _ := strings.Cut(item, ":")
+			switch k {
+			case "CA":
+				e.IsCA = strings.EqualFold(v, "true")
+			case "pathlen":
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					return fmt.Errorf("invalid pathlen %s", v)
+				}
+
+				e.MaxPathLen = n
+				e.MaxPathLenZero = n == 0
+			default:
+				return fmt.Errorf("unknown value %s", item)
+			}
+		}
+
+	case "keyUsage":
+		e.KeyUsageCritical = critical
+		for _, item := range list {
+			usage, found := keyUsageNames[item]
+			if !found {
+				return fmt.Errorf("unknown key usage %s", item)
+			}
+
+			e.KeyUsage |= usage
+		}
+
+	case "extendedKeyUsage":
+		e.ExtKeyUsageCritical = critical
+		for _, item := range list {
+			usage, found := extKeyUsageNames[item]
+			if !found {
+				return fmt.Errorf("unknown extended key usage %s", item)
+			}
+
+			e.ExtKeyUsage = append(e.ExtKeyUsage, usage)
+		}
+
+	case "subjectAltName":
+		return c.addAltNames(e, list)
+
+	case "crlDistributionPoints":
+		for _, item := range list {
+			uri, found := strings.CutPrefix(item, "URI:")
+			if !found {
+				return fmt.Errorf("unsupported distribution point %s", item)
+			}
+
+			e.CRLDistributionPoints = append(e.CRLDistributionPoints, uri)
+		}
+
+	case "authorityInfoAccess":
+		for _, item := range list {
+			method, location, _ := strings.Cut(item, ";")
+			uri, found := strings.CutPrefix(location, "URI:")
+			if !found {
+				return fmt.Errorf("unsupported access location %s", location)
+			}
+
+			switch method {
+			case "OCSP":
+				e.OCSPServer = append(e.OCSPServer, uri)
+			case "caIssuers":
+				e.IssuingCertificateURL = append(e.IssuingCertificateURL, uri)
+			default:
+				return fmt.Errorf("unsupported access method %s", method)
+			}
+		}
+
+	case "subjectKeyIdentifier", "authorityKeyIdentifier":
+		// Key identifiers are always generated from keys when signing.
+
+	default:
+		return fmt.Errorf("unsupported extension")
+	}
+
+	return nil
+}
+
+func (c *Config) addAltNames(e *Extensions, list []string) error {
+	for _, item := range list {
+		if section, found := strings.CutPrefix(item, "@"); found {
+			s := c.Section(section)
+			if s == nil {
+				return fmt.Errorf("section %s not found", section)
+			}
+
+			names := make([]string, 0, len(s.Entries))
+			for _, entry := range s.Entries {
+				kind, _, _ := strings.Cut(entry.Name, ".")
+				names = append(names, kind+":"+entry.Value)
+			}
+
+			if err := c.addAltNames(e, names); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		kind, value, found := strings.Cut(item, ":")
+		if !found {
+			return fmt.Errorf("invalid alternative name %s", item)
+		}
+
+		switch kind {
+		case "DNS":
+			e.DNSNames = append(e.DNSNames, value)
+		case "email":
+			e.EmailAddresses = append(e.EmailAddresses, value)
+		case "IP":
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return fmt.Errorf("invalid IP address %s", value)
+			}
+
+			e.IPAddresses = append(e.IPAddresses, ip)
+		case "URI":
+			uri, err := url.Parse(value)
+			if err != nil {
+				return err
+			}
+
+			e.URIs = append(e.URIs, uri)
+		default:
+			return fmt.Errorf("unsupported alternative name type %s", kind)
+		}
+	}
+
+	return nil
+}
+
+// ApplyCertificate sets extensions to a certificate template.
+func (e *Extensions) ApplyCertificate(template *x509.Certificate) {
+	template.BasicConstraintsValid = e.BasicConstraintsValid
+	template.IsCA = e.IsCA
+	template.MaxPathLen = e.MaxPathLen
+	template.MaxPathLenZero = e.MaxPathLenZero
+	template.KeyUsage = e.KeyUsage
+	template.ExtKeyUsage = e.ExtKeyUsage
+	template.DNSNames = e.DNSNames
+	template.EmailAddresses = e.EmailAddresses
+	template.IPAddresses = e.IPAddresses
+	template.URIs = e.URIs
+	template.CRLDistributionPoints = e.CRLDistributionPoints
+	template.OCSPServer = e.OCSPServer
+	template.IssuingCertificateURL = e.IssuingCertificateURL
+}
+
+type basicConstraints struct {
+	IsCA       bool `asn1:"optional"`
+	MaxPathLen int  `asn1:"optional,default:-1"`
+}
+
+// ApplyRequest sets extensions to a certificate request template. Extensions without
+// fields in x509.CertificateRequest are encoded into ExtraExtensions.
+func (e *Extensions) ApplyRequest(template *x509.CertificateRequest) error {
+	template.DNSNames = e.DNSNames
+	template.EmailAddresses = e.EmailAddresses
+	template.IPAddresses = e.IPAddresses
+	template.URIs = e.URIs
+
+	if e.BasicConstraintsValid {
+		constraints := basicConstraints{IsCA: e.IsCA, MaxPathLen: -1}
+		if e.MaxPathLen > 0 || e.MaxPathLenZero {
+			constraints.MaxPathLen = e.MaxPathLen
+		}
+
+		value, err := asn1.Marshal(constraints)
+		if err != nil {
+			return err
+		}
+
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
+			Id: oidExtensionBasicConstraints, Critical: e.BasicConstraintsCritical, Value: value})
+	}
+
+	if e.KeyUsage != 0 {
+		value, err := marshalKeyUsage(e.KeyUsage)
+		if err != nil {
+			return err
+		}
+
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
+			Id: oidExtensionKeyUsage, Critical: e.KeyUsageCritical, Value: value})
+	}
+
+	if len(e.ExtKeyUsage) > 0 {
+		value, err := marshalExtKeyUsage(e.ExtKeyUsage)
+		if err != nil {
+			return err
+		}
+
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
+			Id: oidExtensionExtKeyUsage, Critical: e.ExtKeyUsageCritical, Value: value})
+	}
+
+	return nil
+}
+
+func marshalKeyUsage(usage x509.KeyUsage) ([]byte, error) {
+	var bits [2]byte
+	n := 0
+	for i := 0; i < 9; i++ {
+		if usage&(1<<i) != 0 {
+			bits[i/8] |= 0x80 >> (i % 8)
+			n = i + 1
+		}
+	}
+
+	length := (n + 7) / 8
+	return asn1.Marshal(asn1.BitString{Bytes: bits[:length], BitLength: n})
+}
+
+var extKeyUsageOIDs = map[x509.ExtKeyUsage]asn1.ObjectIdentifier{
+	x509.ExtKeyUsageAny:             {2, 5, 29, 37, 0},
+	x509.ExtKeyUsageServerAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	x509.ExtKeyUsageClientAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	x509.ExtKeyUsageCodeSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	x509.ExtKeyUsageEmailProtection: {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	x509.ExtKeyUsageIPSECEndSystem:  {1, 3, 6, 1, 5, 5, 7, 3, 5},
+	x509.ExtKeyUsageIPSECTunnel:     {1, 3, 6, 1, 5, 5, 7, 3, 6},
+	x509.ExtKeyUsageIPSECUser:       {1, 3, 6, 1, 5, 5, 7, 3, 7},
+	x509.ExtKeyUsageTimeStamping:    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	x509.ExtKeyUsageOCSPSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+}
+
+func marshalExtKeyUsage(usages []x509.ExtKeyUsage) ([]byte, error) {
+	oids := make([]asn1.ObjectIdentifier, 0, len(usages))
+	for _, usage := range usages {
+		oids = append(oids, extKeyUsageOIDs[usage])
+	}
+
+	return asn1.Marshal(oids)
+}
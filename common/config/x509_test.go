@@ -0,0 +1,116 @@
+package config
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+const x509TestConfig = `
+[ req ]
+prompt = no
+distinguished_name = dn
+req_extensions = v3_req
+
+[ dn ]
+C = US
+O = Example
+0.OU = Web
+1.OU = Ops
+CN = www.example.com
+emailAddress = admin@example.com
+
+[ dn_prompt ]
+countryName = Country Name
+countryName_default = CN
+commonName = Common Name
+commonName_max = 64
+
+[ v3_req ]
+basicConstraints = critical, CA:true, pathlen:0
+keyUsage = critical, digitalSignature, keyCertSign
+extendedKeyUsage = serverAuth, clientAuth
+subjectAltName = DNS:www.example.com, @alt_names
+
+[ alt_names ]
+DNS.1 = example.com
+IP.1 = 127.0.0.1
+email.1 = admin@example.com
+`
+
+func TestDistinguishedName(t *testing.T) {
+	c, err := ParseString(x509TestConfig)
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	name, err := c.DistinguishedName("dn", false)
+	if err != nil {
+		t.Fatalf("distinguished name failed: %s", err)
+	}
+
+	expected := "1.2.840.113549.1.9.1=admin@example.com,CN=www.example.com,OU=Web+OU=Ops,O=Example,C=US"
+	if s := name.String(); s != expected {
+		t.Errorf("wrong name: %s", s)
+	}
+
+	name, err = c.DistinguishedName("dn_prompt", true)
+	if err != nil {
+		t.Fatalf("distinguished name failed: %s", err)
+	}
+
+	if s := name.String(); s != "C=CN" {
+		t.Errorf("wrong name: %s", s)
+	}
+
+	subject, err := ParseSubject("/C=US/O=Example/CN=www.example.com")
+	if err != nil {
+		t.Fatalf("parse subject failed: %s", err)
+	}
+
+	if s := subject.String(); s != "CN=www.example.com,O=Example,C=US" {
+		t.Errorf("wrong subject: %s", s)
+	}
+
+	if _, err := ParseSubject("/X=1"); err == nil {
+		t.Errorf("unknown attribute should fail")
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	c, err := ParseString(x509TestConfig)
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	e, err := c.Extensions("v3_req")
+	if err != nil {
+		t.Fatalf("extensions failed: %s", err)
+	}
+
+	if !e.BasicConstraintsValid || !e.BasicConstraintsCritical || !e.IsCA || !e.MaxPathLenZero {
+		t.Errorf("wrong basic constraints: %+v", e)
+	}
+
+	if e.KeyUsage != x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign || !e.KeyUsageCritical {
+		t.Errorf("wrong key usage: %d", e.KeyUsage)
+	}
+
+	if len(e.ExtKeyUsage) != 2 || len(e.DNSNames) != 2 || len(e.IPAddresses) != 1 ||
+		len(e.EmailAddresses) != 1 {
+		t.Errorf("wrong extensions: %+v", e)
+	}
+
+	template := &x509.CertificateRequest{}
+	if err := e.ApplyRequest(template); err != nil {
+		t.Fatalf("apply failed: %s", err)
+	}
+
+	if len(template.ExtraExtensions) != 3 {
+		t.Errorf("wrong number of extra extensions: %d", len(template.ExtraExtensions))
+	}
+
+	c.Section("v3_req").Entries = append(c.Section("v3_req").Entries, Entry{"unknown", "x"})
+	if _, err := c.Extensions("v3_req"); err == nil {
+		t.Errorf("unsupported extension should fail")
+	}
+}
@@ -0,0 +1,62 @@
+package encoder
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+func readASN1Whole(data []byte) (asn1.ASN1Object, error) {
+	obj, next, err := asn1.ReadASN1Object(data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if next != len(data) {
+		return nil, fmt.Errorf("trailing data after ASN.1 object: %d/%d bytes parsed",
+			next, len(data))
+	}
+
+	return obj, nil
+}
+
+func asn1Sequence(obj asn1.ASN1Object, minSize int, maxSize int) (*asn1.ASN1Sequence, error) {
+	seq, ok := obj.(*asn1.ASN1Sequence)
+	if !ok {
+		return nil, fmt.Errorf("expect a sequence, got %s", obj.Tag())
+	}
+
+	if len(*seq) < minSize || len(*seq) > maxSize {
+		return nil, fmt.Errorf("invalid number of sequence elements: %d", len(*seq))
+	}
+
+	return seq, nil
+}
+
+func asn1Integer(obj asn1.ASN1Object) (*big.Int, error) {
+	i, ok := obj.(*asn1.ASN1Integer)
+	if !ok {
+		return nil, fmt.Errorf("expect an integer, got %s", obj.Tag())
+	}
+
+	return i.Value(), nil
+}
+
+func asn1OctetString(obj asn1.ASN1Object) ([]byte, error) {
+	s, ok := obj.(*asn1.ASN1OctetString)
+	if !ok || s.PC != asn1.TagPrimitive {
+		return nil, fmt.Errorf("expect a primitive octet string, got %s", obj.Tag())
+	}
+
+	return s.Bytes(), nil
+}
+
+func asn1ObjectIdentifier(obj asn1.ASN1Object) (*asn1.ASN1ObjectIdentifier, error) {
+	oid, ok := obj.(*asn1.ASN1ObjectIdentifier)
+	if !ok {
+		return nil, fmt.Errorf("expect an object identifier, got %s", obj.Tag())
+	}
+
+	return oid, nil
+}
@@ -0,0 +1,203 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+var (
+	pemBeginMarker = []byte("-----BEGIN ")
+	pemEndMarker   = []byte("-----END ")
+)
+
+// ParseError is an error of a single block in a bundle. Line is where the PEM block starts,
+// starting from 1, and is 0 for DER data.
+type ParseError struct {
+	Index   int
+	Line    int
+	Offset  int
+	PEMType string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	position := fmt.Sprintf("line %d", e.Line)
+	if e.Line <= 0 {
+		position = fmt.Sprintf("offset %d", e.Offset)
+	}
+
+	if len(e.PEMType) > 0 {
+		return fmt.Sprintf("%s: block %d (%s): %s", position, e.Index, e.PEMType, e.Err)
+	}
+
+	return fmt.Sprintf("%s: block %d: %s", position, e.Index, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newOpaqueContainer(block *pem.Block) *Container {
+	c := &Container{
+		format:  KeyFileFormatOpaque,
+		isPEM:   true,
+		pemType: block.Type,
+		keyType: KeyTypeOpaque,
+		binary:  block.Bytes,
+	}
+
+	return c
+}
+
+func lineNumber(data []byte, offset int) int {
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// pemBlockEnd returns where the PEM block beginning at start ends, i.e. after the line of its
+// END marker. A block without END marker ends where the next block begins, or at end of data.
+func pemBlockEnd(data []byte, start int) int {
+	begin := start + len(pemBeginMarker)
+	next := bytes.Index(data[begin:], pemBeginMarker)
+	if next >= 0 {
+		next += begin
+	} else {
+		next = len(data)
+	}
+
+	end := bytes.Index(data[begin:next], pemEndMarker)
+	if end < 0 {
+		return next
+	}
+
+	end += begin
+	eol := bytes.IndexByte(data[end:], '\n')
+	if eol < 0 {
+		return len(data)
+	}
+
+	return end + eol + 1
+}
+
+func isKnownPEMType(pemType string) bool {
+	if _, found := pemTypeFormats[pemType]; found {
+		return true
+	}
+
+	return pemType == "TRUSTED CERTIFICATE"
+}
+
+func appendContainer(head *Container, tail *Container, c *Container) (*Container, *Container) {
+	if head == nil {
+		return c, c
+	}
+
+	tail.next = c
+	return head, c
+}
+
+// ParseContainerChainLenient parses a bundle, e.g. a CA trust store. Garbage between PEM blocks
+// is skipped, blocks of unknown PEM types or failed to parse are kept as opaque containers with
+// their PEM type, and errors of each block are returned instead of aborting. The returned chain
+// is nil if nothing is found.
+func ParseContainerChainLenient(data []byte) (*Container, []*ParseError) {
+	if bytes.Index(data, pemBeginMarker) < 0 {
+		return parseDERLenient(data)
+	}
+
+	var head, tail *Container
+	errs := make([]*ParseError, 0)
+	offset := 0
+	index := 0
+	for {
+		start := bytes.Index(data[offset:], pemBeginMarker)
+		if start < 0 {
+			break
+		}
+
+		start += offset
+		offset = pemBlockEnd(data, start)
+		block, _ := pem.Decode(data[start:offset])
+		if block == nil {
+			errs = append(errs, &ParseError{
+				Index:  index,
+				Line:   lineNumber(data, start),
+				Offset: start,
+				Err:    fmt.Errorf("malformed PEM block"),
+			})
+
+			index++
+			continue
+		}
+
+		if !isKnownPEMType(block.Type) {
+			c := newOpaqueContainer(block)
+			head, tail = appendContainer(head, tail, c)
+			index++
+			continue
+		}
+
+		c, err := newPEMContainer(block)
+		if err != nil {
+			errs = append(errs, &ParseError{
+				Index:   index,
+				Line:    lineNumber(data, start),
+				Offset:  start,
+				PEMType: block.Type,
+				Err:     err,
+			})
+
+			c = newOpaqueContainer(block)
+		}
+
+		head, tail = appendContainer(head, tail, c)
+		index++
+	}
+
+	return head, errs
+}
+
+func parseDERLenient(data []byte) (*Container, []*ParseError) {
+	c, err := NewDERContainer(data)
+	if err == nil {
+		return c, nil
+	}
+
+	// try again without trailing junk
+	_, next, asn1Err := asn1.ReadASN1Object(data, 0)
+	if asn1Err == nil && next < len(data) {
+		c, retryErr := NewDERContainer(data[:next])
+		if retryErr == nil {
+			junkErr := &ParseError{
+				Index:  1,
+				Offset: next,
+				Err:    fmt.Errorf("%d bytes of trailing data ignored", len(data)-next),
+			}
+
+			return c, []*ParseError{junkErr}
+		}
+	}
+
+	return nil, []*ParseError{{Err: err}}
+}
+
+func ParseContainerChainLenientFromFile(filename string) (*Container, []*ParseError, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer fd.Close()
+
+	content, err := io.ReadAll(fd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain, errs := ParseContainerChainLenient(content)
+	return chain, errs, nil
+}
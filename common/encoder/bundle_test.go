@@ -0,0 +1,134 @@
+package encoder
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func makeBundleTestCertificate(t *testing.T) []byte {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bundle"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate failed: %s", err)
+	}
+
+	return der
+}
+
+func TestParseContainerChainLenient(t *testing.T) {
+	der := makeBundleTestCertificate(t)
+	data := []byte("# garbage before blocks\n")
+	data = append(data, PEMEncode("CERTIFICATE", der)...)
+	data = append(data, []byte("garbage between blocks\n")...)
+	data = append(data, PEMEncode("VENDOR BLOB", []byte{0x01, 0x02, 0x03})...)
+	data = append(data, PEMEncode("CERTIFICATE", []byte{0x30, 0x00})...)
+	data = append(data, PEMEncode("CERTIFICATE", der)...)
+	data = append(data, []byte("trailing garbage")...)
+
+	if _, err := ParseContainerChain(data); err == nil {
+		t.Errorf("strict parsing should fail on broken block")
+	}
+
+	chain, errs := ParseContainerChainLenient(data)
+	expected := []KeyType{
+		KeyTypeCertificate,
+		KeyTypeOpaque,
+		KeyTypeOpaque,
+		KeyTypeCertificate,
+	}
+
+	i := 0
+	for c := chain; c != nil; c = c.Next() {
+		if i >= len(expected) {
+			t.Fatalf("too many containers parsed")
+		}
+
+		if c.KeyType() != expected[i] {
+			t.Errorf("wrong key type of block %d: %s, expected %s", i, c.KeyType(), expected[i])
+		}
+
+		i++
+	}
+
+	if i != len(expected) {
+		t.Errorf("wrong number of containers: %d, expected %d", i, len(expected))
+	}
+
+	if chain.Next().PEMType() != "VENDOR BLOB" {
+		t.Errorf("wrong PEM type of opaque block: %s", chain.Next().PEMType())
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("wrong number of errors: %v", errs)
+	}
+
+	if errs[0].Index != 2 || errs[0].PEMType != "CERTIFICATE" || errs[0].Line <= 1 {
+		t.Errorf("wrong error reported: %s", errs[0])
+	}
+}
+
+func TestParseContainerChainLenientDERWithTrailingData(t *testing.T) {
+	der := makeBundleTestCertificate(t)
+	data := append(append([]byte{}, der...), []byte("junk")...)
+	if _, err := ParseContainerChain(data); err == nil {
+		t.Errorf("strict parsing should fail on trailing data")
+	}
+
+	chain, errs := ParseContainerChainLenient(data)
+	if chain == nil || chain.KeyType() != KeyTypeCertificate {
+		t.Fatalf("certificate not parsed")
+	}
+
+	if len(errs) != 1 || errs[0].Offset != len(der) {
+		t.Errorf("wrong errors reported: %v", errs)
+	}
+}
+
+func TestParseContainerChainLenientMalformedPEM(t *testing.T) {
+	der := makeBundleTestCertificate(t)
+	data := PEMEncode("CERTIFICATE", der)
+	malformedLine := lineNumber(data, len(data))
+	data = append(data, []byte("-----BEGIN CERTIFICATE-----\n!!! not base64 !!!\n-----END CERTIFICATE-----\n")...)
+	truncatedLine := lineNumber(data, len(data))
+	data = append(data, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n")...)
+	data = append(data, PEMEncode("CERTIFICATE", der)...)
+
+	chain, errs := ParseContainerChainLenient(data)
+	n := 0
+	for c := chain; c != nil; c = c.Next() {
+		if c.KeyType() != KeyTypeCertificate {
+			t.Errorf("wrong key type of container %d: %s", n, c.KeyType())
+		}
+
+		n++
+	}
+
+	if n != 2 {
+		t.Errorf("wrong number of containers: %d, expected 2", n)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("wrong number of errors: %v", errs)
+	}
+
+	if errs[0].Index != 1 || errs[0].Line != malformedLine {
+		t.Errorf("wrong error of malformed block: %s, expected line %d", errs[0], malformedLine)
+	}
+
+	if errs[1].Index != 2 || errs[1].Line != truncatedLine {
+		t.Errorf("wrong error of truncated block: %s, expected line %d", errs[1], truncatedLine)
+	}
+}
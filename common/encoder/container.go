@@ -1,8 +1,11 @@
 package encoder
 
 import (
+	"bytes"
 	"crypto"
+	"crypto/dsa" //nolint:all
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -10,7 +13,7 @@ import (
 	"io"
 	"os"
 
-	"go.mozilla.org/pkcs7"
+	"github.com/flily/go-ssl/modules/asn1"
 )
 
 type KeyType int
@@ -24,6 +27,13 @@ const (
 	KeyTypeECParameters
 	KeyTypeCertificate
 	KeyTypeCertificateRequest
+	KeyTypeCertificateRevocationList
+	KeyTypeOpaque
+	KeyTypeDSAPrivateKey
+	KeyTypeDSAPublicKey
+	KeyTypeDSAParameters
+	KeyTypeEd25519PrivateKey
+	KeyTypeEd25519PublicKey
 )
 
 var keyTypeNameMap = map[KeyType]string{
@@ -35,6 +45,14 @@ var keyTypeNameMap = map[KeyType]string{
 	KeyTypeECParameters:       "EC Parameters",
 	KeyTypeCertificate:        "Certificate",
 	KeyTypeCertificateRequest: "CertificateRequest",
+
+	KeyTypeCertificateRevocationList: "CertificateRevocationList",
+	KeyTypeOpaque:                    "Opaque",
+	KeyTypeDSAPrivateKey:             "DSA PrivateKey",
+	KeyTypeDSAPublicKey:              "DSA PublicKey",
+	KeyTypeDSAParameters:             "DSA Parameters",
+	KeyTypeEd25519PrivateKey:         "Ed25519 PrivateKey",
+	KeyTypeEd25519PublicKey:          "Ed25519 PublicKey",
 }
 
 func (t KeyType) String() string {
@@ -58,6 +76,13 @@ type Container struct {
 	ecdPub  *ecdsa.PublicKey
 	cert    *x509.Certificate
 	request *x509.CertificateRequest
+	crl     *x509.RevocationList
+	ecParam *ECParameters
+	dsaPri  *dsa.PrivateKey
+	dsaPub  *dsa.PublicKey
+	dsaPara *dsa.Parameters
+	edPri   ed25519.PrivateKey
+	edPub   ed25519.PublicKey
 	binary  []byte
 
 	next *Container
@@ -108,7 +133,32 @@ func makeKeyParser[T any](loader func([]byte) (T, error)) func(data []byte) (any
 
 func NewDERContainer(data []byte) (*Container, error) {
 	c := &Container{}
-	err := c.parseDERFormat(data)
+	err := c.parseDERFormat(data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newPEMContainer(block *pem.Block) (*Container, error) {
+	c := &Container{
+		isPEM:   true,
+		pemType: block.Type,
+	}
+
+	var err error
+	switch block.Type {
+	case "EC PARAMETERS":
+		err = c.setECParamter(block.Bytes)
+
+	case "TRUSTED CERTIFICATE":
+		err = c.setTrustedCertificate(block.Bytes)
+
+	default:
+		err = c.parseDERFormat(block.Bytes, block.Type)
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -123,22 +173,12 @@ func ParseContainerChain(data []byte) (*Container, error) {
 		return NewDERContainer(data)
 	}
 
-	c := &Container{
-		isPEM:   true,
-		pemType: block.Type,
-	}
-
-	if block.Type == "EC PARAMETERS" {
-		c.setECParamter(block.Bytes)
-
-	} else {
-		err := c.parseDERFormat(block.Bytes)
-		if err != nil {
-			return nil, err
-		}
+	c, err := newPEMContainer(block)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(rest) > 0 {
+	if len(bytes.TrimSpace(rest)) > 0 {
 		next, err := ParseContainerChain(rest)
 		if err != nil {
 			return nil, err
@@ -166,43 +206,47 @@ func ParseContainerChainFromFile(filename string) (*Container, error) {
 	return ParseContainerChain(content)
 }
 
-func (c *Container) setECParamter(data []byte) {
+func (c *Container) setECParamter(data []byte) error {
+	params, err := ParseECParameters(data)
+	if err != nil {
+		return err
+	}
+
 	c.format = KeyFileFormatECParameters
 	c.keyType = KeyTypeECParameters
+	c.ecParam = params
 	c.binary = data
+	return nil
 }
 
-func (c *Container) parseDERFormat(data []byte) error {
-	tryParsers := []struct {
-		parser func([]byte) (any, error)
-		kind   KeyFileFormat
-	}{
-		{makeKeyParser(x509.ParsePKCS1PrivateKey), KeyFileFormatPKCS1RSAPrivateKey},
-		{makeKeyParser(x509.ParsePKCS1PublicKey), KeyFileFormatPKCS1RSAPublicKey},
-		{makeKeyParser(pkcs7.Parse), KeyFileFormatPKCS7Message},
-		{makeKeyParser(x509.ParsePKCS8PrivateKey), KeyFileFormatPKCS8PrivateKey},
-		{makeKeyParser(x509.ParsePKIXPublicKey), KeyFileFormatPKIXPublicKey},
-		{makeKeyParser(x509.ParseECPrivateKey), KeyFileFormatECPrivateKey},
-		{makeKeyParser(x509.ParseCertificate), KeyFileFormatCertificate},
-		{makeKeyParser(x509.ParseCertificateRequest), KeyFileFormatCertificateRequest},
+// setTrustedCertificate parses OpenSSL `TRUSTED CERTIFICATE`, a certificate followed by
+// auxiliary trust settings.
+func (c *Container) setTrustedCertificate(data []byte) error {
+	_, next, err := asn1.ReadASN1Object(data, 0)
+	if err != nil {
+		return err
 	}
 
-	found := false
-	for _, parser := range tryParsers {
-		key, err := parser.parser(data)
-		if err == nil {
-			_ = c.setKeyWithFormat(key, parser.kind)
-			found = true
-			break
-		}
+	cert, err := x509.ParseCertificate(data[:next])
+	if err != nil {
+		return err
 	}
 
-	if !found {
-		err := fmt.Errorf("can not parse data as DER format")
-		return err
+	return c.setKeyWithFormat(cert, KeyFileFormatCertificate)
+}
+
+func (c *Container) parseDERFormat(data []byte, pemType string) error {
+	detection := DetectDERFirst(data, pemType)
+	best := detection.Best()
+	if best == nil {
+		return detection.Err()
 	}
 
-	return nil
+	if best.Format == KeyFileFormatECParameters {
+		c.binary = data
+	}
+
+	return c.setKeyWithFormat(best.value, best.Format)
 }
 
 func (c *Container) setKeyWithFormat(key any, format KeyFileFormat) error {
@@ -231,6 +275,34 @@ func (c *Container) setKeyWithFormat(key any, format KeyFileFormat) error {
 		c.keyType = KeyTypeCertificateRequest
 		c.request = k
 
+	case *ECParameters:
+		c.keyType = KeyTypeECParameters
+		c.ecParam = k
+
+	case *x509.RevocationList:
+		c.keyType = KeyTypeCertificateRevocationList
+		c.crl = k
+
+	case *dsa.PrivateKey:
+		c.keyType = KeyTypeDSAPrivateKey
+		c.dsaPri = k
+
+	case *dsa.PublicKey:
+		c.keyType = KeyTypeDSAPublicKey
+		c.dsaPub = k
+
+	case *dsa.Parameters:
+		c.keyType = KeyTypeDSAParameters
+		c.dsaPara = k
+
+	case ed25519.PrivateKey:
+		c.keyType = KeyTypeEd25519PrivateKey
+		c.edPri = k
+
+	case ed25519.PublicKey:
+		c.keyType = KeyTypeEd25519PublicKey
+		c.edPub = k
+
 	case []byte:
 		if format != KeyFileFormatECParameters {
 			err := fmt.Errorf("Unknown binary data got: %s",
@@ -251,11 +323,16 @@ func (c *Container) KeyType() KeyType {
 }
 
 func (c *Container) KeyTypeString() string {
+	keyType := c.keyType.String()
+	if c.ecParam != nil && len(c.ecParam.Name) > 0 {
+		keyType = fmt.Sprintf("%s(%s)", keyType, c.ecParam.Name)
+	}
+
 	if c.isPEM {
 		return fmt.Sprintf("PEM[(%s) %s %s]",
-			c.pemType, c.format, c.keyType)
+			c.pemType, c.format, keyType)
 	} else {
-		return fmt.Sprintf("DER[%s %s]", c.format, c.keyType)
+		return fmt.Sprintf("DER[%s %s]", c.format, keyType)
 	}
 }
 
@@ -271,6 +348,12 @@ func (c *Container) PrivateKey() crypto.PrivateKey {
 	case KeyTypeECPrivateKey:
 		return c.ecdPri
 
+	case KeyTypeDSAPrivateKey:
+		return c.dsaPri
+
+	case KeyTypeEd25519PrivateKey:
+		return c.edPri
+
 	default:
 		return nil
 	}
@@ -290,11 +373,38 @@ func (c *Container) PublicKey() crypto.PublicKey {
 	case KeyTypeECPublicKey:
 		return c.ecdPub
 
+	case KeyTypeDSAPrivateKey:
+		return &c.dsaPri.PublicKey
+
+	case KeyTypeDSAPublicKey:
+		return c.dsaPub
+
+	case KeyTypeEd25519PrivateKey:
+		return c.edPri.Public()
+
+	case KeyTypeEd25519PublicKey:
+		return c.edPub
+
 	default:
 		return nil
 	}
 }
 
+// SubjectPublicKey returns public key of keys, as well as subject public key of certificates
+// and certificate requests.
+func (c *Container) SubjectPublicKey() crypto.PublicKey {
+	switch c.keyType {
+	case KeyTypeCertificate:
+		return c.cert.PublicKey
+
+	case KeyTypeCertificateRequest:
+		return c.request.PublicKey
+
+	default:
+		return c.PublicKey()
+	}
+}
+
 func (c *Container) FirstPrivateKey() crypto.PrivateKey {
 	container := c
 	for container != nil {
@@ -323,6 +433,20 @@ func (c *Container) FirstPublicKey() crypto.PublicKey {
 	return nil
 }
 
+func (c *Container) FirstSubjectPublicKey() crypto.PublicKey {
+	container := c
+	for container != nil {
+		key := container.SubjectPublicKey()
+		if key != nil {
+			return key
+		}
+
+		container = container.Next()
+	}
+
+	return nil
+}
+
 func (c *Container) RSAPrivateKey() *rsa.PrivateKey {
 	return c.rsaPri
 }
@@ -339,6 +463,26 @@ func (c *Container) ECDSAPublicKey() *ecdsa.PublicKey {
 	return c.ecdPub
 }
 
+func (c *Container) DSAPrivateKey() *dsa.PrivateKey {
+	return c.dsaPri
+}
+
+func (c *Container) DSAPublicKey() *dsa.PublicKey {
+	return c.dsaPub
+}
+
+func (c *Container) DSAParameters() *dsa.Parameters {
+	return c.dsaPara
+}
+
+func (c *Container) Ed25519PrivateKey() ed25519.PrivateKey {
+	return c.edPri
+}
+
+func (c *Container) Ed25519PublicKey() ed25519.PublicKey {
+	return c.edPub
+}
+
 func (c *Container) Certificate() *x509.Certificate {
 	return c.cert
 }
@@ -346,3 +490,19 @@ func (c *Container) Certificate() *x509.Certificate {
 func (c *Container) CertificateRequest() *x509.CertificateRequest {
 	return c.request
 }
+
+func (c *Container) CertificateRevocationList() *x509.RevocationList {
+	return c.crl
+}
+
+func (c *Container) PEMType() string {
+	return c.pemType
+}
+
+func (c *Container) ECParameters() *ECParameters {
+	return c.ecParam
+}
+
+func (c *Container) Binary() []byte {
+	return c.binary
+}
@@ -0,0 +1,56 @@
+package encoder
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+)
+
+func TestEd25519Container(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("marshal private key failed: %s", err)
+	}
+
+	spki, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("marshal public key failed: %s", err)
+	}
+
+	cases := []struct {
+		pemType string
+		data    []byte
+		keyType KeyType
+	}{
+		{"PRIVATE KEY", pkcs8, KeyTypeEd25519PrivateKey},
+		{"PUBLIC KEY", spki, KeyTypeEd25519PublicKey},
+	}
+
+	for _, c := range cases {
+		container, err := ParseContainerChain(PEMEncode(c.pemType, c.data))
+		if err != nil {
+			t.Errorf("parse %s failed: %s", c.pemType, err)
+			continue
+		}
+
+		if container.KeyType() != c.keyType {
+			t.Errorf("wrong key type of %s: %s, expected %s", c.pemType, container.KeyType(), c.keyType)
+		}
+
+		key, ok := container.FirstPublicKey().(ed25519.PublicKey)
+		if !ok || !key.Equal(public) {
+			t.Errorf("wrong public key of %s", c.pemType)
+		}
+	}
+
+	container, _ := ParseContainerChain(PEMEncode("PRIVATE KEY", pkcs8))
+	if key, ok := container.FirstPrivateKey().(ed25519.PrivateKey); !ok || !key.Equal(private) {
+		t.Errorf("wrong private key")
+	}
+}
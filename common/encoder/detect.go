@@ -0,0 +1,401 @@
+package encoder
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/flily/go-ssl/modules/asn1"
+	"go.mozilla.org/pkcs7"
+)
+
+// FormatCandidate is a possible format of a DER object. Reason is set when the ASN.1 structure
+// suggests the format, and Err records why the parser of the format rejected the data.
+type FormatCandidate struct {
+	Format KeyFileFormat
+	Reason string
+	Err    error
+	value  any
+}
+
+func (c *FormatCandidate) Parsed() bool {
+	return c.Err == nil
+}
+
+func (c *FormatCandidate) String() string {
+	if c.Parsed() {
+		return fmt.Sprintf("%s: ok (%s)", c.Format, c.Reason)
+	}
+
+	return fmt.Sprintf("%s: %s", c.Format, c.Err)
+}
+
+// Detection is the result of format detection for one DER object, or one PEM block.
+type Detection struct {
+	IsPEM   bool
+	PEMType string
+	Data    []byte
+
+	// StructureErr is set when the data is not a valid ASN.1 DER object.
+	StructureErr error
+
+	// Candidates are all formats tried, ordered by likelihood. Formats suggested by the PEM
+	// type come first, then formats suggested by the ASN.1 structure, then the others.
+	Candidates []*FormatCandidate
+}
+
+type derParser struct {
+	kind   KeyFileFormat
+	parser func([]byte) (any, error)
+}
+
+var derParsers = []derParser{
+	{KeyFileFormatPKCS1RSAPrivateKey, makeKeyParser(x509.ParsePKCS1PrivateKey)},
+	{KeyFileFormatPKCS1RSAPublicKey, makeKeyParser(x509.ParsePKCS1PublicKey)},
+	{KeyFileFormatPKCS7Message, makeKeyParser(pkcs7.Parse)},
+	{KeyFileFormatPKCS8PrivateKey, parsePKCS8PrivateKey},
+	{KeyFileFormatPKIXPublicKey, makeKeyParser(x509.ParsePKIXPublicKey)},
+	{KeyFileFormatECPrivateKey, makeKeyParser(x509.ParseECPrivateKey)},
+	{KeyFileFormatCertificate, makeKeyParser(x509.ParseCertificate)},
+	{KeyFileFormatCertificateRequest, makeKeyParser(x509.ParseCertificateRequest)},
+	{KeyFileFormatECParameters, makeKeyParser(ParseECParameters)},
+	{KeyFileFormatCertificateRevocationList, makeKeyParser(x509.ParseRevocationList)},
+	{KeyFileFormatDSAPrivateKey, makeKeyParser(ParseDSAPrivateKey)},
+	{KeyFileFormatDSAParameters, makeKeyParser(ParseDSAParameters)},
+}
+
+var pemTypeFormats = map[string]KeyFileFormat{
+	"RSA PRIVATE KEY":         KeyFileFormatPKCS1RSAPrivateKey,
+	"RSA PUBLIC KEY":          KeyFileFormatPKCS1RSAPublicKey,
+	"PKCS7":                   KeyFileFormatPKCS7Message,
+	"PRIVATE KEY":             KeyFileFormatPKCS8PrivateKey,
+	"PUBLIC KEY":              KeyFileFormatPKIXPublicKey,
+	"EC PRIVATE KEY":          KeyFileFormatECPrivateKey,
+	"EC PARAMETERS":           KeyFileFormatECParameters,
+	"CERTIFICATE":             KeyFileFormatCertificate,
+	"CERTIFICATE REQUEST":     KeyFileFormatCertificateRequest,
+	"NEW CERTIFICATE REQUEST": KeyFileFormatCertificateRequest,
+	"X509 CRL":                KeyFileFormatCertificateRevocationList,
+	"DSA PRIVATE KEY":         KeyFileFormatDSAPrivateKey,
+	"DSA PARAMETERS":          KeyFileFormatDSAParameters,
+}
+
+// DetectFormat detects formats of all PEM blocks in data, or data itself as DER.
+func DetectFormat(data []byte) []*Detection {
+	results := make([]*Detection, 0)
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return append(results, DetectDER(data, ""))
+	}
+
+	for block != nil {
+		d := DetectDER(block.Bytes, block.Type)
+		d.IsPEM = true
+		results = append(results, d)
+		block, rest = pem.Decode(rest)
+	}
+
+	return results
+}
+
+// DetectDER detects format of DER data, pemType is used as a hint if not empty. All parsers
+// are tried, so that ambiguous data and failures of every format are reported.
+func DetectDER(data []byte, pemType string) *Detection {
+	return detectDER(data, pemType, true)
+}
+
+// DetectDERFirst detects format of DER data as DetectDER, but stops at the first candidate
+// accepted by its parser. Candidates after Best are not tried, so Ambiguous is always false.
+func DetectDERFirst(data []byte, pemType string) *Detection {
+	return detectDER(data, pemType, false)
+}
+
+func detectDER(data []byte, pemType string, all bool) *Detection {
+	d := &Detection{
+		PEMType: pemType,
+		Data:    data,
+	}
+
+	reasons := make(map[KeyFileFormat]string)
+	order := make([]KeyFileFormat, 0, len(derParsers))
+	if format, found := pemTypeFormats[pemType]; found {
+		reasons[format] = fmt.Sprintf("PEM type %s", pemType)
+		order = append(order, format)
+	}
+
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		d.StructureErr = err
+
+	} else {
+		for _, s := range structureCandidates(obj) {
+			if reason, found := reasons[s.Format]; found {
+				reasons[s.Format] = reason + ", " + s.Reason
+			} else {
+				reasons[s.Format] = s.Reason
+				order = append(order, s.Format)
+			}
+		}
+	}
+
+	for _, p := range derParsers {
+		if _, found := reasons[p.kind]; !found {
+			order = append(order, p.kind)
+		}
+	}
+
+	for _, format := range order {
+		c := &FormatCandidate{
+			Format: format,
+			Reason: reasons[format],
+		}
+
+		for _, p := range derParsers {
+			if p.kind == format {
+				c.value, c.Err = p.parser(data)
+				break
+			}
+		}
+
+		if c.Err == nil && len(c.Reason) <= 0 {
+			c.Reason = "accepted by parser"
+		}
+
+		d.Candidates = append(d.Candidates, c)
+		if c.Err == nil && !all {
+			break
+		}
+	}
+
+	return d
+}
+
+// Matches returns candidates accepted by their parsers, ordered by likelihood.
+func (d *Detection) Matches() []*FormatCandidate {
+	matches := make([]*FormatCandidate, 0)
+	for _, c := range d.Candidates {
+		if c.Parsed() {
+			matches = append(matches, c)
+		}
+	}
+
+	return matches
+}
+
+// Best returns the most likely candidate accepted by its parser, nil if none is accepted.
+func (d *Detection) Best() *FormatCandidate {
+	matches := d.Matches()
+	if len(matches) <= 0 {
+		return nil
+	}
+
+	return matches[0]
+}
+
+// Format returns the most likely format, KeyFileFormatInvalid if nothing is accepted.
+func (d *Detection) Format() KeyFileFormat {
+	best := d.Best()
+	if best == nil {
+		return KeyFileFormatInvalid
+	}
+
+	return best.Format
+}
+
+// Ambiguous reports whether more than one parser accepted the data.
+func (d *Detection) Ambiguous() bool {
+	return len(d.Matches()) > 1
+}
+
+// Err summaries why the data is rejected, nil if any parser accepted the data. Failures of
+// formats suggested by PEM type or ASN.1 structure are included, others are in Candidates.
+func (d *Detection) Err() error {
+	if d.Best() != nil {
+		return nil
+	}
+
+	if d.StructureErr != nil {
+		return fmt.Errorf("can not parse data as DER format: %s", d.StructureErr)
+	}
+
+	messages := make([]string, 0, len(d.Candidates))
+	for _, c := range d.Candidates {
+		if len(c.Reason) > 0 {
+			messages = append(messages, c.String())
+		}
+	}
+
+	if len(messages) <= 0 {
+		return fmt.Errorf("can not parse data as DER format: unknown ASN.1 structure")
+	}
+
+	return fmt.Errorf("can not parse data as DER format: %s", strings.Join(messages, "; "))
+}
+
+type structureCandidate struct {
+	Format KeyFileFormat
+	Reason string
+}
+
+func oidHasPrefix(oid *asn1.ASN1ObjectIdentifier, prefix *asn1.ASN1ObjectIdentifier) bool {
+	if len(*oid) < len(*prefix) {
+		return false
+	}
+
+	for i, id := range *prefix {
+		if (*oid)[i] != id {
+			return false
+		}
+	}
+
+	return true
+}
+
+func oidDescription(oid *asn1.ASN1ObjectIdentifier) string {
+	name, found := asn1.GetKnownOIDName(oid)
+	if !found || len(name) <= 0 {
+		return oid.String()
+	}
+
+	return name
+}
+
+func isTag(obj asn1.ASN1Object, class asn1.TagClass, number uint64) bool {
+	tag := obj.Tag()
+	return tag.Class == class && tag.Number == number
+}
+
+func isUniversal(obj asn1.ASN1Object, number uint64) bool {
+	return isTag(obj, asn1.TagClassUniversal, number)
+}
+
+func integerEquals(obj asn1.ASN1Object, n int64) bool {
+	i, err := asn1Integer(obj)
+	return err == nil && i.IsInt64() && i.Int64() == n
+}
+
+func algorithmOID(obj asn1.ASN1Object) *asn1.ASN1ObjectIdentifier {
+	seq, err := asn1Sequence(obj, 1, 2)
+	if err != nil {
+		return nil
+	}
+
+	oid, err := asn1ObjectIdentifier((*seq)[0])
+	if err != nil {
+		return nil
+	}
+
+	return oid
+}
+
+// structureCandidates inspects top-level ASN.1 structure and suggests possible formats.
+func structureCandidates(obj asn1.ASN1Object) []structureCandidate {
+	result := make([]structureCandidate, 0)
+	add := func(format KeyFileFormat, reason string, args ...any) {
+		result = append(result, structureCandidate{format, fmt.Sprintf(reason, args...)})
+	}
+
+	if oid, ok := obj.(*asn1.ASN1ObjectIdentifier); ok {
+		add(KeyFileFormatECParameters, "OBJECT IDENTIFIER %s", oidDescription(oid))
+		return result
+	}
+
+	seq, ok := obj.(*asn1.ASN1Sequence)
+	if !ok {
+		return result
+	}
+
+	elements := *seq
+	integers := 0
+	for _, e := range elements {
+		if isUniversal(e, asn1.TagInteger) {
+			integers++
+		}
+	}
+
+	if len(elements) >= 9 && integers == len(elements) && integerEquals(elements[0], 0) {
+		add(KeyFileFormatPKCS1RSAPrivateKey, "SEQUENCE of %d INTEGERs with version 0", integers)
+	}
+
+	if len(elements) == 2 && integers == 2 {
+		add(KeyFileFormatPKCS1RSAPublicKey, "SEQUENCE of 2 INTEGERs")
+	}
+
+	if len(elements) == 6 && integers == 6 && integerEquals(elements[0], 0) {
+		add(KeyFileFormatDSAPrivateKey, "SEQUENCE of 6 INTEGERs with version 0")
+	}
+
+	if len(elements) == 3 && integers == 3 {
+		add(KeyFileFormatDSAParameters, "SEQUENCE of 3 INTEGERs")
+	}
+
+	if len(elements) >= 3 && isUniversal(elements[0], asn1.TagInteger) &&
+		isUniversal(elements[2], asn1.TagOctetString) {
+		if oid := algorithmOID(elements[1]); oid != nil {
+			add(KeyFileFormatPKCS8PrivateKey,
+				"SEQUENCE of version, AlgorithmIdentifier (%s) and OCTET STRING", oidDescription(oid))
+		}
+	}
+
+	if len(elements) == 2 && isUniversal(elements[1], asn1.TagBitString) {
+		if oid := algorithmOID(elements[0]); oid != nil {
+			add(KeyFileFormatPKIXPublicKey,
+				"SEQUENCE of AlgorithmIdentifier (%s) and BIT STRING", oidDescription(oid))
+		}
+	}
+
+	if len(elements) >= 2 && integerEquals(elements[0], 1) &&
+		isUniversal(elements[1], asn1.TagOctetString) {
+		add(KeyFileFormatECPrivateKey, "SEQUENCE of version 1 and OCTET STRING")
+	}
+
+	if len(elements) >= 5 && integerEquals(elements[0], 1) &&
+		isUniversal(elements[1], asn1.TagSequence) {
+		if oid := algorithmOID(elements[1]); oid != nil && oid.Equal(asn1.OidX962PrimeField) {
+			add(KeyFileFormatECParameters, "SEQUENCE of version 1 and prime field")
+		}
+	}
+
+	if len(elements) >= 1 && isUniversal(elements[0], asn1.TagObjectIdentifier) {
+		oid, _ := asn1ObjectIdentifier(elements[0])
+		if oidHasPrefix(oid, asn1.OidRSAPkcs7) {
+			add(KeyFileFormatPKCS7Message, "ContentInfo with content type %s", oid)
+		}
+	}
+
+	if asn1.CanBeX509Certificate(obj) == nil {
+		add(KeyFileFormatCertificate, "SEQUENCE of TBSCertificate, AlgorithmIdentifier and BIT STRING")
+	}
+
+	if len(elements) == 3 && isUniversal(elements[0], asn1.TagSequence) &&
+		isUniversal(elements[2], asn1.TagBitString) {
+		info := *elements[0].(*asn1.ASN1Sequence)
+		if len(info) == 4 && integerEquals(info[0], 0) &&
+			isTag(info[3], asn1.TagClassContextSpecific, 0) {
+			add(KeyFileFormatCertificateRequest,
+				"SEQUENCE of CertificationRequestInfo, AlgorithmIdentifier and BIT STRING")
+		}
+	}
+
+	if len(elements) == 3 && isUniversal(elements[0], asn1.TagSequence) &&
+		isUniversal(elements[2], asn1.TagBitString) {
+		info := *elements[0].(*asn1.ASN1Sequence)
+		i := 0
+		if len(info) > 0 && isUniversal(info[0], asn1.TagInteger) {
+			i = 1
+		}
+
+		if len(info) >= i+4 && isUniversal(info[i], asn1.TagSequence) &&
+			isUniversal(info[i+1], asn1.TagSequence) && isTime(info[i+2]) {
+			add(KeyFileFormatCertificateRevocationList,
+				"SEQUENCE of TBSCertList, AlgorithmIdentifier and BIT STRING")
+		}
+	}
+
+	return result
+}
+
+func isTime(obj asn1.ASN1Object) bool {
+	return isUniversal(obj, asn1.TagUTCTime) || isUniversal(obj, asn1.TagGeneralizedTime)
+}
@@ -0,0 +1,259 @@
+package encoder
+
+import (
+	"crypto/dsa" //nolint:all
+	"crypto/x509"
+	"fmt"
+	"math/big"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+// DSA keys are not supported by x509.ParsePKCS8PrivateKey and x509.MarshalPKIXPublicKey, they
+// are encoded and decoded with the ASN.1 module instead.
+//
+//	Dss-Parms ::= SEQUENCE { p INTEGER, q INTEGER, g INTEGER }
+//
+//	DSAPrivateKey ::= SEQUENCE {
+//	  version INTEGER, p INTEGER, q INTEGER, g INTEGER, y INTEGER, x INTEGER }
+
+func parseDSAParameters(obj asn1.ASN1Object) (*dsa.Parameters, error) {
+	seq, err := asn1Sequence(obj, 3, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]*big.Int, 3)
+	for i := range values {
+		values[i], err = asn1Integer((*seq)[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params := &dsa.Parameters{
+		P: values[0],
+		Q: values[1],
+		G: values[2],
+	}
+
+	return params, nil
+}
+
+func ParseDSAParameters(data []byte) (*dsa.Parameters, error) {
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := parseDSAParameters(obj)
+	if err != nil {
+		return nil, fmt.Errorf("dsa parameters: %s", err)
+	}
+
+	return params, nil
+}
+
+// ParseDSAPrivateKey parses a DSA private key in OpenSSL traditional format.
+func ParseDSAPrivateKey(data []byte) (*dsa.PrivateKey, error) {
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := asn1Sequence(obj, 6, 6)
+	if err != nil {
+		return nil, fmt.Errorf("dsa private key: %s", err)
+	}
+
+	values := make([]*big.Int, 6)
+	for i := range values {
+		values[i], err = asn1Integer((*seq)[i])
+		if err != nil {
+			return nil, fmt.Errorf("dsa private key: %s", err)
+		}
+	}
+
+	if values[0].Sign() != 0 {
+		return nil, fmt.Errorf("dsa private key: unsupported version %s", values[0])
+	}
+
+	key := &dsa.PrivateKey{
+		PublicKey: dsa.PublicKey{
+			Parameters: dsa.Parameters{
+				P: values[1],
+				Q: values[2],
+				G: values[3],
+			},
+			Y: values[4],
+		},
+		X: values[5],
+	}
+
+	return key, nil
+}
+
+// ParsePKCS8DSAPrivateKey parses a DSA private key in PKCS#8 format.
+func ParsePKCS8DSAPrivateKey(data []byte) (*dsa.PrivateKey, error) {
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := asn1Sequence(obj, 3, 4)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs8 dsa private key: %s", err)
+	}
+
+	algorithm, err := asn1Sequence((*seq)[1], 2, 2)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs8 dsa private key: %s", err)
+	}
+
+	oid, err := asn1ObjectIdentifier((*algorithm)[0])
+	if err != nil || !oid.Equal(asn1.OidX957DSA) {
+		return nil, fmt.Errorf("pkcs8 dsa private key: not a DSA key")
+	}
+
+	params, err := parseDSAParameters((*algorithm)[1])
+	if err != nil {
+		return nil, fmt.Errorf("pkcs8 dsa private key: %s", err)
+	}
+
+	content, err := asn1OctetString((*seq)[2])
+	if err != nil {
+		return nil, fmt.Errorf("pkcs8 dsa private key: %s", err)
+	}
+
+	xObj, err := readASN1Whole(content)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs8 dsa private key: %s", err)
+	}
+
+	x, err := asn1Integer(xObj)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs8 dsa private key: %s", err)
+	}
+
+	// Y is derived from G, X and P, which must be in range for a valid result.
+	one := big.NewInt(1)
+	switch {
+	case params.P.Cmp(one) <= 0:
+		return nil, fmt.Errorf("pkcs8 dsa private key: P must be greater than 1")
+
+	case params.G.Sign() <= 0 || params.G.Cmp(params.P) >= 0:
+		return nil, fmt.Errorf("pkcs8 dsa private key: G must be in range (0, P)")
+
+	case x.Sign() <= 0 || x.Cmp(params.Q) >= 0:
+		return nil, fmt.Errorf("pkcs8 dsa private key: X must be in range (0, Q)")
+	}
+
+	key := &dsa.PrivateKey{
+		PublicKey: dsa.PublicKey{
+			Parameters: *params,
+			Y:          new(big.Int).Exp(params.G, x, params.P),
+		},
+		X: x,
+	}
+
+	return key, nil
+}
+
+// parsePKCS8PrivateKey parses any PKCS#8 private key, including DSA.
+func parsePKCS8PrivateKey(data []byte) (any, error) {
+	key, err := x509.ParsePKCS8PrivateKey(data)
+	if err == nil {
+		return key, nil
+	}
+
+	if dsaKey, dsaErr := ParsePKCS8DSAPrivateKey(data); dsaErr == nil {
+		return dsaKey, nil
+	}
+
+	return nil, err
+}
+
+func newDSAParametersObject(params *dsa.Parameters) *asn1.ASN1Sequence {
+	return asn1.NewSequence(
+		asn1.NewInteger(params.P),
+		asn1.NewInteger(params.Q),
+		asn1.NewInteger(params.G),
+	)
+}
+
+func newDSAAlgorithmObject(params *dsa.Parameters) *asn1.ASN1Sequence {
+	return asn1.NewSequence(asn1.OidX957DSA, newDSAParametersObject(params))
+}
+
+func MarshalDSAParameters(params *dsa.Parameters) ([]byte, error) {
+	return asn1.Marshal(newDSAParametersObject(params))
+}
+
+// MarshalDSAPrivateKey encodes a DSA private key in OpenSSL traditional format.
+func MarshalDSAPrivateKey(key *dsa.PrivateKey) ([]byte, error) {
+	seq := asn1.NewSequence(
+		asn1.NewIntegerFromInt64(0),
+		asn1.NewInteger(key.P),
+		asn1.NewInteger(key.Q),
+		asn1.NewInteger(key.G),
+		asn1.NewInteger(key.Y),
+		asn1.NewInteger(key.X),
+	)
+
+	return asn1.Marshal(seq)
+}
+
+func MarshalPKCS8DSAPrivateKey(key *dsa.PrivateKey) ([]byte, error) {
+	x, err := asn1.Marshal(asn1.NewInteger(key.X))
+	if err != nil {
+		return nil, err
+	}
+
+	seq := asn1.NewSequence(
+		asn1.NewIntegerFromInt64(0),
+		newDSAAlgorithmObject(&key.Parameters),
+		asn1.NewOctetStringFromBytes(x),
+	)
+
+	return asn1.Marshal(seq)
+}
+
+func MarshalPKIXDSAPublicKey(key *dsa.PublicKey) ([]byte, error) {
+	y, err := asn1.Marshal(asn1.NewInteger(key.Y))
+	if err != nil {
+		return nil, err
+	}
+
+	seq := asn1.NewSequence(
+		newDSAAlgorithmObject(&key.Parameters),
+		asn1.NewBitStringFromBytes(y),
+	)
+
+	return asn1.Marshal(seq)
+}
+
+// CheckDSAParameters performs basic sanity checks on DSA domain parameters.
+func CheckDSAParameters(params *dsa.Parameters) error {
+	if !params.P.ProbablyPrime(20) {
+		return fmt.Errorf("dsa parameters: p is not a prime")
+	}
+
+	if !params.Q.ProbablyPrime(20) {
+		return fmt.Errorf("dsa parameters: q is not a prime")
+	}
+
+	pm1 := new(big.Int).Sub(params.P, big.NewInt(1))
+	if new(big.Int).Mod(pm1, params.Q).Sign() != 0 {
+		return fmt.Errorf("dsa parameters: q does not divide p-1")
+	}
+
+	if params.G.Cmp(big.NewInt(1)) <= 0 || params.G.Cmp(params.P) >= 0 {
+		return fmt.Errorf("dsa parameters: g is out of range")
+	}
+
+	if new(big.Int).Exp(params.G, params.Q, params.P).Cmp(big.NewInt(1)) != 0 {
+		return fmt.Errorf("dsa parameters: order of g is not q")
+	}
+
+	return nil
+}
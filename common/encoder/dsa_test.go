@@ -0,0 +1,120 @@
+package encoder
+
+import (
+	"crypto/dsa" //nolint:all
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestDSAKeyRoundTrip(t *testing.T) {
+	key := &dsa.PrivateKey{}
+	if err := dsa.GenerateParameters(&key.Parameters, rand.Reader, dsa.L1024N160); err != nil {
+		t.Fatalf("generate parameters failed: %s", err)
+	}
+
+	if err := dsa.GenerateKey(key, rand.Reader); err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	if err := CheckDSAParameters(&key.Parameters); err != nil {
+		t.Errorf("check parameters failed: %s", err)
+	}
+
+	pkcs8, err := MarshalPKCS8DSAPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal pkcs8 failed: %s", err)
+	}
+
+	traditional, err := MarshalDSAPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal private key failed: %s", err)
+	}
+
+	spki, err := MarshalPKIXDSAPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key failed: %s", err)
+	}
+
+	params, err := MarshalDSAParameters(&key.Parameters)
+	if err != nil {
+		t.Fatalf("marshal parameters failed: %s", err)
+	}
+
+	cases := []struct {
+		pemType string
+		data    []byte
+		keyType KeyType
+	}{
+		{"PRIVATE KEY", pkcs8, KeyTypeDSAPrivateKey},
+		{"DSA PRIVATE KEY", traditional, KeyTypeDSAPrivateKey},
+		{"PUBLIC KEY", spki, KeyTypeDSAPublicKey},
+		{"DSA PARAMETERS", params, KeyTypeDSAParameters},
+	}
+
+	for _, c := range cases {
+		for _, data := range [][]byte{PEMEncode(c.pemType, c.data), c.data} {
+			container, err := ParseContainerChain(data)
+			if err != nil {
+				t.Errorf("parse %s failed: %s", c.pemType, err)
+				continue
+			}
+
+			if container.KeyType() != c.keyType {
+				t.Errorf("wrong key type of %s: %s, expected %s",
+					c.pemType, container.KeyType(), c.keyType)
+			}
+		}
+	}
+
+	parsed, err := ParsePKCS8DSAPrivateKey(pkcs8)
+	if err != nil {
+		t.Fatalf("parse pkcs8 failed: %s", err)
+	}
+
+	if parsed.X.Cmp(key.X) != 0 || parsed.Y.Cmp(key.Y) != 0 {
+		t.Errorf("wrong key parsed from pkcs8")
+	}
+
+	f1, _ := PublicKeyFingerprint(&key.PublicKey)
+	f2, _ := PublicKeyFingerprint(&parsed.PublicKey)
+	if len(f1) <= 0 || FingerprintString(f1) != FingerprintString(f2) {
+		t.Errorf("wrong fingerprint: %x and %x", f1, f2)
+	}
+}
+
+func TestParseInvalidPKCS8DSAPrivateKey(t *testing.T) {
+	key := &dsa.PrivateKey{}
+	if err := dsa.GenerateParameters(&key.Parameters, rand.Reader, dsa.L1024N160); err != nil {
+		t.Fatalf("generate parameters failed: %s", err)
+	}
+
+	if err := dsa.GenerateKey(key, rand.Reader); err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	cases := []struct {
+		name   string
+		modify func(k *dsa.PrivateKey)
+	}{
+		{"zero P", func(k *dsa.PrivateKey) { k.P = big.NewInt(0) }},
+		{"P of 1", func(k *dsa.PrivateKey) { k.P = big.NewInt(1) }},
+		{"zero G", func(k *dsa.PrivateKey) { k.G = big.NewInt(0) }},
+		{"G of P", func(k *dsa.PrivateKey) { k.G = new(big.Int).Set(k.P) }},
+		{"zero X", func(k *dsa.PrivateKey) { k.X = big.NewInt(0) }},
+		{"X of Q", func(k *dsa.PrivateKey) { k.X = new(big.Int).Set(k.Q) }},
+	}
+
+	for _, c := range cases {
+		modified := *key
+		c.modify(&modified)
+		der, err := MarshalPKCS8DSAPrivateKey(&modified)
+		if err != nil {
+			t.Fatalf("%s: marshal pkcs8 failed: %s", c.name, err)
+		}
+
+		if _, err := ParsePKCS8DSAPrivateKey(der); err == nil {
+			t.Errorf("%s: invalid key is accepted", c.name)
+		}
+	}
+}
@@ -0,0 +1,531 @@
+package encoder
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+// NamedCurve describes a well-known prime field curve, y^2 = x^3 + ax + b (mod p).
+type NamedCurve struct {
+	Name    string
+	Aliases []string
+	OID     *asn1.ASN1ObjectIdentifier
+	Curve   elliptic.Curve // nil if not implemented by Go standard library
+	P       *big.Int
+	A       *big.Int
+	B       *big.Int
+	Gx      *big.Int
+	Gy      *big.Int
+	N       *big.Int
+	H       *big.Int
+}
+
+func mustHexInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex integer: " + s)
+	}
+
+	return n
+}
+
+func newNISTCurve(name string, aliases []string, oid *asn1.ASN1ObjectIdentifier, curve elliptic.Curve) *NamedCurve {
+	params := curve.Params()
+	c := &NamedCurve{
+		Name:    name,
+		Aliases: aliases,
+		OID:     oid,
+		Curve:   curve,
+		P:       params.P,
+		A:       new(big.Int).Sub(params.P, big.NewInt(3)),
+		B:       params.B,
+		Gx:      params.Gx,
+		Gy:      params.Gy,
+		N:       params.N,
+		H:       big.NewInt(1),
+	}
+
+	return c
+}
+
+var namedCurves = []*NamedCurve{
+	{
+		Name:    "prime192v1",
+		Aliases: []string{"secp192r1", "p192", "p-192"},
+		OID:     asn1.OidPrimeCurveP192v1,
+		P:       mustHexInt("fffffffffffffffffffffffffffffffeffffffffffffffff"),
+		A:       mustHexInt("fffffffffffffffffffffffffffffffefffffffffffffffc"),
+		B:       mustHexInt("64210519e59c80e70fa7e9ab72243049feb8deecc146b9b1"),
+		Gx:      mustHexInt("188da80eb03090f67cbf20eb43a18800f4ff0afd82ff1012"),
+		Gy:      mustHexInt("07192b95ffc8da78631011ed6b24cdd573f977a11e794811"),
+		N:       mustHexInt("ffffffffffffffffffffffff99def836146bc9b1b4d22831"),
+		H:       big.NewInt(1),
+	},
+	newNISTCurve("secp224r1", []string{"p224", "p-224"},
+		asn1.OidCerticomCurveAnsiP224r1, elliptic.P224()),
+	newNISTCurve("prime256v1", []string{"secp256r1", "p256", "p-256"},
+		asn1.OidPrimeCurveP256v1, elliptic.P256()),
+	newNISTCurve("secp384r1", []string{"p384", "p-384"},
+		asn1.OidCerticomCurveAnsiP384r1, elliptic.P384()),
+	newNISTCurve("secp521r1", []string{"p521", "p-521"},
+		asn1.OidCerticomCurveAnsiP521r1, elliptic.P521()),
+	{
+		Name: "secp256k1",
+		OID:  asn1.OidCerticomCurveAnsiP256k1,
+		P:    mustHexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"),
+		A:    big.NewInt(0),
+		B:    big.NewInt(7),
+		Gx:   mustHexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+		Gy:   mustHexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+		N:    mustHexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+		H:    big.NewInt(1),
+	},
+}
+
+// Marshal encodes the curve as DER ECParameters in namedCurve form.
+func (c *NamedCurve) Marshal() ([]byte, error) {
+	return asn1.Marshal(c.OID)
+}
+
+// NamedCurves returns all known named curves.
+func NamedCurves() []*NamedCurve {
+	return namedCurves
+}
+
+// NamedCurveNames returns all names and aliases of known named curves, sorted.
+func NamedCurveNames() []string {
+	names := make([]string, 0, len(namedCurves)*3)
+	for _, c := range namedCurves {
+		names = append(names, c.Name)
+		names = append(names, c.Aliases...)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// LookupNamedCurve finds a named curve by its name or alias, case insensitive.
+func LookupNamedCurve(name string) *NamedCurve {
+	name = strings.ToLower(name)
+	for _, c := range namedCurves {
+		if c.Name == name {
+			return c
+		}
+
+		for _, alias := range c.Aliases {
+			if alias == name {
+				return c
+			}
+		}
+	}
+
+	return nil
+}
+
+// LookupNamedCurveByOID finds a named curve by its object identifier.
+func LookupNamedCurveByOID(oid *asn1.ASN1ObjectIdentifier) *NamedCurve {
+	for _, c := range namedCurves {
+		if c.OID.Equal(oid) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// ECParameters is the decoded content of an `EC PARAMETERS` block, defined in RFC 3279 and
+// SEC 1 C.2, which is either a namedCurve OID or an explicit specifiedECDomain.
+//
+//	ECParameters ::= CHOICE {
+//	  namedCurve     OBJECT IDENTIFIER,
+//	  implicitCurve  NULL,
+//	  specifiedCurve SpecifiedECDomain }
+type ECParameters struct {
+	Named     bool
+	OID       *asn1.ASN1ObjectIdentifier
+	Name      string
+	FieldType *asn1.ASN1ObjectIdentifier
+
+	// Curve is the matched known curve, nil if the parameters do not match any known curve.
+	Curve *NamedCurve
+
+	P    *big.Int
+	A    *big.Int
+	B    *big.Int
+	Gx   *big.Int
+	Gy   *big.Int
+	N    *big.Int
+	H    *big.Int
+	Seed []byte
+}
+
+func ParseECParameters(data []byte) (*ECParameters, error) {
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o := obj.(type) {
+	case *asn1.ASN1ObjectIdentifier:
+		return parseNamedECParameters(o)
+
+	case *asn1.ASN1Sequence:
+		return parseSpecifiedECParameters(o)
+
+	case *asn1.ASN1Null:
+		return nil, fmt.Errorf("ec parameters: implicitCA is not supported")
+	}
+
+	return nil, fmt.Errorf("ec parameters: unexpected %s", obj.Tag())
+}
+
+func parseNamedECParameters(oid *asn1.ASN1ObjectIdentifier) (*ECParameters, error) {
+	p := &ECParameters{
+		Named: true,
+		OID:   oid,
+	}
+
+	curve := LookupNamedCurveByOID(oid)
+	if curve == nil {
+		name, found := asn1.GetKnownOIDName(oid)
+		if !found || len(name) <= 0 {
+			return nil, fmt.Errorf("ec parameters: unknown curve %s", oid)
+		}
+
+		p.Name = name
+		return p, nil
+	}
+
+	p.setCurve(curve)
+	return p, nil
+}
+
+func (p *ECParameters) setCurve(curve *NamedCurve) {
+	p.Curve = curve
+	p.Name = curve.Name
+	p.OID = curve.OID
+	p.FieldType = asn1.OidX962PrimeField
+	p.P = curve.P
+	p.A = curve.A
+	p.B = curve.B
+	p.Gx = curve.Gx
+	p.Gy = curve.Gy
+	p.N = curve.N
+	p.H = curve.H
+}
+
+//	SpecifiedECDomain ::= SEQUENCE {
+//	  version   INTEGER { ecdpVer1(1) },
+//	  fieldID   FieldID {{FieldTypes}},
+//	  curve     Curve,
+//	  base      ECPoint,
+//	  order     INTEGER,
+//	  cofactor  INTEGER OPTIONAL }
+func parseSpecifiedECParameters(seq *asn1.ASN1Sequence) (*ECParameters, error) {
+	if _, err := asn1Sequence(seq, 5, 7); err != nil {
+		return nil, fmt.Errorf("ec parameters: %s", err)
+	}
+
+	version, err := asn1Integer((*seq)[0])
+	if err != nil {
+		return nil, fmt.Errorf("ec parameters: version: %s", err)
+	}
+
+	if version.Int64() < 1 || version.Int64() > 3 {
+		return nil, fmt.Errorf("ec parameters: unsupported version %s", version)
+	}
+
+	p := &ECParameters{}
+	if err := p.parseFieldID((*seq)[1]); err != nil {
+		return nil, fmt.Errorf("ec parameters: field: %s", err)
+	}
+
+	if err := p.parseCurve((*seq)[2]); err != nil {
+		return nil, fmt.Errorf("ec parameters: curve: %s", err)
+	}
+
+	base, err := asn1OctetString((*seq)[3])
+	if err != nil {
+		return nil, fmt.Errorf("ec parameters: base: %s", err)
+	}
+
+	p.Gx, p.Gy, err = p.decodePoint(base)
+	if err != nil {
+		return nil, fmt.Errorf("ec parameters: base: %s", err)
+	}
+
+	p.N, err = asn1Integer((*seq)[4])
+	if err != nil {
+		return nil, fmt.Errorf("ec parameters: order: %s", err)
+	}
+
+	if len(*seq) > 5 {
+		p.H, err = asn1Integer((*seq)[5])
+		if err != nil {
+			return nil, fmt.Errorf("ec parameters: cofactor: %s", err)
+		}
+	}
+
+	for _, curve := range namedCurves {
+		if p.matchCurve(curve) {
+			p.Curve = curve
+			p.Name = curve.Name
+			p.OID = curve.OID
+			break
+		}
+	}
+
+	return p, nil
+}
+
+//	FieldID ::= SEQUENCE {
+//	  fieldType  OBJECT IDENTIFIER,
+//	  parameters ANY DEFINED BY fieldType }
+func (p *ECParameters) parseFieldID(obj asn1.ASN1Object) error {
+	seq, err := asn1Sequence(obj, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	p.FieldType, err = asn1ObjectIdentifier((*seq)[0])
+	if err != nil {
+		return err
+	}
+
+	if !p.FieldType.Equal(asn1.OidX962PrimeField) {
+		return fmt.Errorf("unsupported field type %s", p.FieldType)
+	}
+
+	p.P, err = asn1Integer((*seq)[1])
+	if err != nil {
+		return err
+	}
+
+	// Points are decoded with square roots modulo p, which require an odd prime.
+	if p.P.Cmp(big.NewInt(3)) <= 0 || p.P.Bit(0) != 1 || !p.P.ProbablyPrime(20) {
+		return fmt.Errorf("field prime %s is not an odd prime greater than 3", p.P)
+	}
+
+	return nil
+}
+
+//	Curve ::= SEQUENCE {
+//	  a    FieldElement,
+//	  b    FieldElement,
+//	  seed BIT STRING OPTIONAL }
+func (p *ECParameters) parseCurve(obj asn1.ASN1Object) error {
+	seq, err := asn1Sequence(obj, 2, 3)
+	if err != nil {
+		return err
+	}
+
+	a, err := asn1OctetString((*seq)[0])
+	if err != nil {
+		return err
+	}
+
+	b, err := asn1OctetString((*seq)[1])
+	if err != nil {
+		return err
+	}
+
+	p.A = new(big.Int).SetBytes(a)
+	p.B = new(big.Int).SetBytes(b)
+
+	if len(*seq) > 2 {
+		seed, ok := (*seq)[2].(*asn1.ASN1BitString)
+		if !ok {
+			return fmt.Errorf("expect seed as bit string, got %s", (*seq)[2].Tag())
+		}
+
+		p.Seed = seed.Data
+	}
+
+	return nil
+}
+
+func (p *ECParameters) decodePoint(data []byte) (*big.Int, *big.Int, error) {
+	byteLength := (p.P.BitLen() + 7) / 8
+	if len(data) == 1+2*byteLength && data[0] == 0x04 {
+		x := new(big.Int).SetBytes(data[1 : 1+byteLength])
+		y := new(big.Int).SetBytes(data[1+byteLength:])
+		return x, y, nil
+	}
+
+	if len(data) == 1+byteLength && (data[0] == 0x02 || data[0] == 0x03) {
+		x := new(big.Int).SetBytes(data[1:])
+		y := new(big.Int).ModSqrt(p.curveRight(x), p.P)
+		if y == nil {
+			return nil, nil, fmt.Errorf("compressed point is not on curve")
+		}
+
+		if y.Bit(0) != uint(data[0]&1) {
+			y.Sub(p.P, y)
+		}
+
+		return x, y, nil
+	}
+
+	return nil, nil, fmt.Errorf("invalid point encoding")
+}
+
+func (p *ECParameters) matchCurve(c *NamedCurve) bool {
+	if c.P.Cmp(p.P) != 0 || c.A.Cmp(p.A) != 0 || c.B.Cmp(p.B) != 0 {
+		return false
+	}
+
+	if c.Gx.Cmp(p.Gx) != 0 || c.Gy.Cmp(p.Gy) != 0 || c.N.Cmp(p.N) != 0 {
+		return false
+	}
+
+	return p.H == nil || c.H.Cmp(p.H) == 0
+}
+
+// BitSize returns the size of the underlying field in bits, 0 if unknown.
+func (p *ECParameters) BitSize() int {
+	if p.P == nil {
+		return 0
+	}
+
+	return p.P.BitLen()
+}
+
+// EllipticCurve returns the curve implementation of Go standard library, nil if not available.
+func (p *ECParameters) EllipticCurve() elliptic.Curve {
+	if p.Curve == nil {
+		return nil
+	}
+
+	return p.Curve.Curve
+}
+
+// curveRight computes x^3 + ax + b mod p.
+func (p *ECParameters) curveRight(x *big.Int) *big.Int {
+	r := new(big.Int).Exp(x, big.NewInt(3), p.P)
+	ax := new(big.Int).Mul(p.A, x)
+	r.Add(r, ax)
+	r.Add(r, p.B)
+	return r.Mod(r, p.P)
+}
+
+func (p *ECParameters) isOnCurve(x *big.Int, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(p.P) >= 0 || y.Sign() < 0 || y.Cmp(p.P) >= 0 {
+		return false
+	}
+
+	left := new(big.Int).Mul(y, y)
+	left.Mod(left, p.P)
+	return left.Cmp(p.curveRight(x)) == 0
+}
+
+// pointAdd adds two affine points, nil stands for the point at infinity.
+func (p *ECParameters) pointAdd(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1 == nil {
+		return x2, y2
+	}
+
+	if x2 == nil {
+		return x1, y1
+	}
+
+	lambda := new(big.Int)
+	if x1.Cmp(x2) == 0 {
+		sum := new(big.Int).Add(y1, y2)
+		if sum.Mod(sum, p.P).Sign() == 0 {
+			return nil, nil
+		}
+
+		// lambda = (3 * x1^2 + a) / (2 * y1)
+		num := new(big.Int).Mul(x1, x1)
+		num.Mul(num, big.NewInt(3))
+		num.Add(num, p.A)
+		den := new(big.Int).Lsh(y1, 1)
+		lambda.Mul(num, den.ModInverse(den, p.P))
+
+	} else {
+		// lambda = (y2 - y1) / (x2 - x1)
+		num := new(big.Int).Sub(y2, y1)
+		den := new(big.Int).Sub(x2, x1)
+		den.Mod(den, p.P)
+		lambda.Mul(num, den.ModInverse(den, p.P))
+	}
+
+	lambda.Mod(lambda, p.P)
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, p.P)
+
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, lambda)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, p.P)
+	return x3, y3
+}
+
+func (p *ECParameters) scalarMult(x, y *big.Int, k *big.Int) (*big.Int, *big.Int) {
+	var rx, ry *big.Int
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		rx, ry = p.pointAdd(rx, ry, rx, ry)
+		if k.Bit(i) == 1 {
+			rx, ry = p.pointAdd(rx, ry, x, y)
+		}
+	}
+
+	return rx, ry
+}
+
+// Check validates the curve parameters: the field is prime, the curve is non-singular, the
+// generator is on the curve with the given prime order, and the cofactor matches Hasse bound.
+func (p *ECParameters) Check() error {
+	if p.P == nil {
+		return fmt.Errorf("ec parameters: curve %s is not supported", p.Name)
+	}
+
+	if !p.P.ProbablyPrime(20) {
+		return fmt.Errorf("ec parameters: field prime p is not a prime")
+	}
+
+	if p.A.Cmp(p.P) >= 0 || p.B.Cmp(p.P) >= 0 {
+		return fmt.Errorf("ec parameters: coefficient a or b is not in field")
+	}
+
+	// 4a^3 + 27b^2 != 0 (mod p)
+	d := new(big.Int).Exp(p.A, big.NewInt(3), p.P)
+	d.Mul(d, big.NewInt(4))
+	b2 := new(big.Int).Mul(p.B, p.B)
+	b2.Mul(b2, big.NewInt(27))
+	d.Add(d, b2)
+	if d.Mod(d, p.P).Sign() == 0 {
+		return fmt.Errorf("ec parameters: curve is singular")
+	}
+
+	if !p.isOnCurve(p.Gx, p.Gy) {
+		return fmt.Errorf("ec parameters: generator is not on curve")
+	}
+
+	if !p.N.ProbablyPrime(20) {
+		return fmt.Errorf("ec parameters: order n is not a prime")
+	}
+
+	if x, _ := p.scalarMult(p.Gx, p.Gy, p.N); x != nil {
+		return fmt.Errorf("ec parameters: order n of generator is incorrect")
+	}
+
+	if p.H != nil {
+		// h = floor((sqrt(p) + 1)^2 / n), approximated by round((p + 1) / n)
+		h := new(big.Int).Add(p.P, big.NewInt(1))
+		h.Add(h, new(big.Int).Rsh(p.N, 1))
+		h.Div(h, p.N)
+		if h.Cmp(p.H) != 0 {
+			return fmt.Errorf("ec parameters: invalid cofactor %s, expected %s", p.H, h)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package encoder
+
+import (
+	"encoding/pem"
+	"testing"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+const explicitP256Parameters = `-----BEGIN EC PARAMETERS-----
+MIH3AgEBMCwGByqGSM49AQECIQD/////AAAAAQAAAAAAAAAAAAAAAP//////////
+/////zBbBCD/////AAAAAQAAAAAAAAAAAAAAAP///////////////AQgWsY12Ko6
+k+ez671VdpiGvGUdBrDMU7D2O848PifSYEsDFQDEnTYIhucEk2pmeOETnSa3gZ9+
+kARBBGsX0fLhLEJH+Lzm5WOkQPJ3A32BLeszoPShOUXYmMKWT+NC4v4af5uO5+tK
+fA+eFivOM1drMV7Oy7ZAaDe/UfUCIQD/////AAAAAP//////////vOb6racXnoTz
+ucrC/GMlUQIBAQ==
+-----END EC PARAMETERS-----`
+
+func TestParseNamedECParameters(t *testing.T) {
+	for _, curve := range NamedCurves() {
+		der, err := curve.Marshal()
+		if err != nil {
+			t.Fatalf("marshal curve %s failed: %s", curve.Name, err)
+		}
+
+		params, err := ParseECParameters(der)
+		if err != nil {
+			t.Fatalf("parse curve %s failed: %s", curve.Name, err)
+		}
+
+		if !params.Named || params.Curve != curve {
+			t.Errorf("wrong curve parsed: %+v, expected %s", params, curve.Name)
+		}
+
+		if err := params.Check(); err != nil {
+			t.Errorf("check curve %s failed: %s", curve.Name, err)
+		}
+	}
+}
+
+func TestParseExplicitECParameters(t *testing.T) {
+	block, _ := pem.Decode([]byte(explicitP256Parameters))
+	params, err := ParseECParameters(block.Bytes)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if params.Named {
+		t.Errorf("explicit parameters parsed as named curve")
+	}
+
+	if params.Name != "prime256v1" || !params.OID.Equal(asn1.OidPrimeCurveP256v1) {
+		t.Errorf("wrong curve matched: %s %s", params.Name, params.OID)
+	}
+
+	if len(params.Seed) != 20 {
+		t.Errorf("wrong seed length: %d", len(params.Seed))
+	}
+
+	if err := params.Check(); err != nil {
+		t.Errorf("unexpected check error: %s", err)
+	}
+
+	params.Gy.Add(params.Gy, params.H)
+	if err := params.Check(); err == nil {
+		t.Errorf("check passed with generator not on curve")
+	}
+}
+
+func TestContainerECParameters(t *testing.T) {
+	c, err := ParseContainerChain([]byte(explicitP256Parameters))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c.KeyType() != KeyTypeECParameters {
+		t.Errorf("wrong key type: %s", c.KeyType())
+	}
+
+	if c.ECParameters() == nil || c.ECParameters().Curve == nil {
+		t.Errorf("EC parameters not decoded")
+	}
+}
+
+func TestParseMalformedECParameters(t *testing.T) {
+	cases := []struct {
+		name  string
+		prime int64
+	}{
+		{"even prime", 16},
+		{"composite", 15},
+		{"too small", 3},
+		{"zero", 0},
+	}
+
+	for _, c := range cases {
+		der, err := asn1.Marshal(asn1.NewSequence(
+			asn1.NewIntegerFromInt64(1),
+			asn1.NewSequence(asn1.OidX962PrimeField, asn1.NewIntegerFromInt64(c.prime)),
+			asn1.NewSequence(asn1.NewOctetStringFromBytes([]byte{1}), asn1.NewOctetStringFromBytes([]byte{2})),
+			asn1.NewOctetStringFromBytes([]byte{0x02, 0x05}),
+			asn1.NewIntegerFromInt64(7),
+		))
+		if err != nil {
+			t.Fatalf("%s: marshal failed: %s", c.name, err)
+		}
+
+		if _, err := ParseECParameters(der); err == nil {
+			t.Errorf("%s: parameters with field prime %d are accepted", c.name, c.prime)
+		}
+
+		if _, err := ParseContainerChain(der); err == nil {
+			t.Errorf("%s: container of field prime %d is parsed", c.name, c.prime)
+		}
+	}
+}
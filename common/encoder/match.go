@@ -0,0 +1,244 @@
+package encoder
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/dsa" //nolint:all
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// PublicKeyFingerprint returns SHA-256 digest of the DER encoded SubjectPublicKeyInfo.
+func PublicKeyFingerprint(key crypto.PublicKey) ([]byte, error) {
+	var der []byte
+	var err error
+	if k, ok := key.(*dsa.PublicKey); ok {
+		der, err = MarshalPKIXDSAPublicKey(k)
+	} else {
+		der, err = x509.MarshalPKIXPublicKey(key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(der)
+	return digest[:], nil
+}
+
+func FingerprintString(fingerprint []byte) string {
+	parts := make([]string, len(fingerprint))
+	for i, b := range fingerprint {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+func PublicKeyDescription(key crypto.PublicKey) string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d bit", k.N.BitLen())
+
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("EC %s", k.Curve.Params().Name)
+
+	case ed25519.PublicKey:
+		return "Ed25519"
+
+	case *dsa.PublicKey:
+		return fmt.Sprintf("DSA %d bit", k.P.BitLen())
+	}
+
+	return fmt.Sprintf("%T", key)
+}
+
+// MatchEntry is an object carrying a public key, found in a source file.
+type MatchEntry struct {
+	Source      string
+	Index       int
+	Container   *Container
+	PublicKey   crypto.PublicKey
+	Fingerprint []byte
+}
+
+func (e *MatchEntry) KeyType() KeyType {
+	return e.Container.KeyType()
+}
+
+func (e *MatchEntry) IsPrivateKey() bool {
+	return e.Container.PrivateKey() != nil
+}
+
+func (e *MatchEntry) Description() string {
+	switch e.KeyType() {
+	case KeyTypeCertificate:
+		return fmt.Sprintf("%s [%s]", e.KeyType(), e.Container.Certificate().Subject)
+
+	case KeyTypeCertificateRequest:
+		return fmt.Sprintf("%s [%s]", e.KeyType(), e.Container.CertificateRequest().Subject)
+	}
+
+	return e.KeyType().String()
+}
+
+// CollectMatchEntries returns all objects carrying a public key in a container chain.
+func CollectMatchEntries(source string, chain *Container) ([]*MatchEntry, error) {
+	entries := make([]*MatchEntry, 0)
+	index := 0
+	for c := chain; c != nil; c = c.Next() {
+		key := c.SubjectPublicKey()
+		if key != nil {
+			fingerprint, err := PublicKeyFingerprint(key)
+			if err != nil {
+				return nil, fmt.Errorf("%s #%d: %s", source, index, err)
+			}
+
+			entry := &MatchEntry{
+				Source:      source,
+				Index:       index,
+				Container:   c,
+				PublicKey:   key,
+				Fingerprint: fingerprint,
+			}
+			entries = append(entries, entry)
+		}
+
+		index++
+	}
+
+	return entries, nil
+}
+
+// KeyGroup is a set of objects sharing the same public key.
+type KeyGroup struct {
+	Fingerprint []byte
+	PublicKey   crypto.PublicKey
+	Entries     []*MatchEntry
+}
+
+func (g *KeyGroup) count(pred func(*MatchEntry) bool) int {
+	n := 0
+	for _, entry := range g.Entries {
+		if pred(entry) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (g *KeyGroup) PrivateKeyCount() int {
+	return g.count(func(e *MatchEntry) bool { return e.IsPrivateKey() })
+}
+
+func (g *KeyGroup) CertificateCount() int {
+	return g.count(func(e *MatchEntry) bool {
+		return e.KeyType() == KeyTypeCertificate || e.KeyType() == KeyTypeCertificateRequest
+	})
+}
+
+// IsMatched reports whether the group has both private key and certificate.
+func (g *KeyGroup) IsMatched() bool {
+	return g.PrivateKeyCount() > 0 && g.CertificateCount() > 0
+}
+
+// Status describes whether the group is a complete key pair.
+func (g *KeyGroup) Status() string {
+	hasPrivate := g.PrivateKeyCount() > 0
+	hasCertificate := g.CertificateCount() > 0
+	switch {
+	case g.IsMatched():
+		return "matched"
+
+	case hasPrivate:
+		return "private key without certificate"
+
+	case hasCertificate:
+		return "certificate without private key"
+	}
+
+	return "public key only"
+}
+
+// GroupByPublicKey groups entries by public key fingerprint, in order of first appearance.
+func GroupByPublicKey(entries []*MatchEntry) []*KeyGroup {
+	groups := make([]*KeyGroup, 0)
+	index := make(map[string]*KeyGroup)
+	for _, entry := range entries {
+		group, found := index[string(entry.Fingerprint)]
+		if !found {
+			group = &KeyGroup{
+				Fingerprint: entry.Fingerprint,
+				PublicKey:   entry.PublicKey,
+			}
+			index[string(entry.Fingerprint)] = group
+			groups = append(groups, group)
+		}
+
+		group.Entries = append(group.Entries, entry)
+	}
+
+	return groups
+}
+
+// FindSourceMismatches returns sources containing both private keys and certificates, where a
+// private key matches none of the certificates, e.g. a combined PEM with a wrong key.
+func FindSourceMismatches(entries []*MatchEntry) []string {
+	bySource := make(map[string][]*MatchEntry)
+	sources := make([]string, 0)
+	for _, entry := range entries {
+		if _, found := bySource[entry.Source]; !found {
+			sources = append(sources, entry.Source)
+		}
+
+		bySource[entry.Source] = append(bySource[entry.Source], entry)
+	}
+
+	mismatches := make([]string, 0)
+	for _, source := range sources {
+		if sourceHasMismatch(bySource[source]) {
+			mismatches = append(mismatches, source)
+		}
+	}
+
+	return mismatches
+}
+
+func sourceHasMismatch(entries []*MatchEntry) bool {
+	certificates := make([]*MatchEntry, 0)
+	for _, entry := range entries {
+		if entry.KeyType() == KeyTypeCertificate || entry.KeyType() == KeyTypeCertificateRequest {
+			certificates = append(certificates, entry)
+		}
+	}
+
+	if len(certificates) == 0 {
+		return false
+	}
+
+	for _, entry := range entries {
+		if !entry.IsPrivateKey() {
+			continue
+		}
+
+		matched := false
+		for _, cert := range certificates {
+			if bytes.Equal(cert.Fingerprint, entry.Fingerprint) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return true
+		}
+	}
+
+	return false
+}
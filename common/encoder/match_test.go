@@ -0,0 +1,86 @@
+package encoder
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func makeMatchTestChain(t *testing.T, key *ecdsa.PrivateKey, certKey *ecdsa.PrivateKey) *Container {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key failed: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "match"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template,
+		&certKey.PublicKey, certKey)
+	if err != nil {
+		t.Fatalf("create certificate failed: %s", err)
+	}
+
+	data := append(PEMEncode("EC PRIVATE KEY", keyDER), PEMEncode("CERTIFICATE", certDER)...)
+	chain, err := ParseContainerChain(data)
+	if err != nil {
+		t.Fatalf("parse chain failed: %s", err)
+	}
+
+	return chain
+}
+
+func TestGroupByPublicKey(t *testing.T) {
+	key1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	good, err := CollectMatchEntries("good.pem", makeMatchTestChain(t, key1, key1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	bad, err := CollectMatchEntries("bad.pem", makeMatchTestChain(t, key2, key1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, entry := range good {
+		if entry.Container.KeyType() == KeyTypeCertificate && entry.Container.PublicKey() != nil {
+			t.Errorf("public key of certificate is not nil, only subject public key is")
+		}
+	}
+
+	entries := append(good, bad...)
+	if len(entries) != 4 {
+		t.Fatalf("wrong number of entries: %d", len(entries))
+	}
+
+	groups := GroupByPublicKey(entries)
+	if len(groups) != 2 {
+		t.Fatalf("wrong number of groups: %d", len(groups))
+	}
+
+	if len(groups[0].Entries) != 3 || !groups[0].IsMatched() {
+		t.Errorf("wrong first group: %d entries, status %s",
+			len(groups[0].Entries), groups[0].Status())
+	}
+
+	if len(groups[1].Entries) != 1 || groups[1].IsMatched() {
+		t.Errorf("wrong second group: %d entries, status %s",
+			len(groups[1].Entries), groups[1].Status())
+	}
+
+	mismatches := FindSourceMismatches(entries)
+	if len(mismatches) != 1 || mismatches[0] != "bad.pem" {
+		t.Errorf("wrong mismatches: %v", mismatches)
+	}
+}
@@ -0,0 +1,105 @@
+package encoder
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+// Encrypted PKCS#8 private keys are in PBES2 of PKCS#5, with key derived by PBKDF2 and data
+// encrypted in AES-CBC, as written by openssl pkcs8 -v2.
+//
+//	EncryptedPrivateKeyInfo ::= SEQUENCE {
+//	  encryptionAlgorithm AlgorithmIdentifier, encryptedData OCTET STRING }
+//
+//	PBES2-params ::= SEQUENCE {
+//	  keyDerivationFunc AlgorithmIdentifier, encryptionScheme AlgorithmIdentifier }
+//
+//	PBKDF2-params ::= SEQUENCE {
+//	  salt OCTET STRING, iterationCount INTEGER, keyLength INTEGER OPTIONAL,
+//	  prf AlgorithmIdentifier DEFAULT hmacWithSHA1 }
+
+type pbes2Cipher struct {
+	Name    string
+	OID     *asn1.ASN1ObjectIdentifier
+	KeySize int
+}
+
+var pbes2Ciphers = []pbes2Cipher{
+	{"aes-128-cbc", asn1.OidNISTAES128CBC, 16},
+	{"aes-192-cbc", asn1.OidNISTAES192CBC, 24},
+	{"aes-256-cbc", asn1.OidNISTAES256CBC, 32},
+}
+
+// PBES2CipherNames returns names of ciphers of encrypted PKCS#8 private keys.
+func PBES2CipherNames() []string {
+	names := make([]string, len(pbes2Ciphers))
+	for i, c := range pbes2Ciphers {
+		names[i] = c.Name
+	}
+
+	return names
+}
+
+func findPBES2Cipher(match func(c *pbes2Cipher) bool) *pbes2Cipher {
+	for i := range pbes2Ciphers {
+		if match(&pbes2Ciphers[i]) {
+			return &pbes2Ciphers[i]
+		}
+	}
+
+	return nil
+}
+
+// EncryptPKCS8PrivateKey encrypts a PKCS#8 private key in PBES2 with PBKDF2-HMAC-SHA256 of
+// iterations and cipher of name, e.g. aes-256-cbc.
+func EncryptPKCS8PrivateKey(random io.Reader, der []byte, password []byte, name string, iterations int) ([]byte, error) {
+	c := findPBES2Cipher(func(c *pbes2Cipher) bool { return c.Name == name })
+	if c == nil {
+		return nil, fmt.Errorf("pkcs8: unsupported cipher %s", name)
+	}
+
+	if iterations <= 0 {
+		return nil, fmt.Errorf("pkcs8: iterations must be positive, got %d", iterations)
+	}
+
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	for _, buffer := range [][]byte{salt, iv} {
+		if _, err := io.ReadFull(random, buffer); err != nil {
+			return nil, err
+		}
+	}
+
+	key := pbkdf2.Key(password, salt, iterations, c.KeySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	encrypted := append(bytes.Clone(der), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	kdf := asn1.NewSequence(
+		asn1.OidRSAPkcs5PBKDF2,
+		asn1.NewSequence(
+			asn1.NewOctetStringFromBytes(salt),
+			asn1.NewIntegerFromInt64(int64(iterations)),
+			asn1.NewSequence(asn1.OidHMACWithSHA256, asn1.NewNull()),
+		),
+	)
+
+	scheme := asn1.NewSequence(c.OID, asn1.NewOctetStringFromBytes(iv))
+	return asn1.Marshal(asn1.NewSequence(
+		asn1.NewSequence(asn1.OidRSAPkcs5PBES2, asn1.NewSequence(kdf, scheme)),
+		asn1.NewOctetStringFromBytes(encrypted),
+	))
+}
@@ -0,0 +1,82 @@
+package encoder
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+// decryptPKCS8 decrypts data written by EncryptPKCS8PrivateKey, fields are checked against
+// cipher of name.
+func decryptPKCS8(t *testing.T, data []byte, password []byte, name string) []byte {
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		t.Fatalf("%s: read encrypted key failed: %s", name, err)
+	}
+
+	info, _ := asn1Sequence(obj, 2, 2)
+	algorithm, _ := asn1Sequence((*info)[0], 2, 2)
+	params, _ := asn1Sequence((*algorithm)[1], 2, 2)
+	kdf, _ := asn1Sequence((*params)[0], 2, 2)
+	kdfParams, _ := asn1Sequence((*kdf)[1], 3, 3)
+	scheme, _ := asn1Sequence((*params)[1], 2, 2)
+	if info == nil || algorithm == nil || params == nil || kdf == nil || kdfParams == nil || scheme == nil {
+		t.Fatalf("%s: wrong structure of encrypted key", name)
+	}
+
+	pbes2, _ := asn1ObjectIdentifier((*algorithm)[0])
+	pbkdf2OID, _ := asn1ObjectIdentifier((*kdf)[0])
+	schemeOID, _ := asn1ObjectIdentifier((*scheme)[0])
+	c := findPBES2Cipher(func(c *pbes2Cipher) bool { return c.Name == name })
+	if !pbes2.Equal(asn1.OidRSAPkcs5PBES2) || !pbkdf2OID.Equal(asn1.OidRSAPkcs5PBKDF2) || !schemeOID.Equal(c.OID) {
+		t.Fatalf("%s: wrong algorithms %s, %s, %s", name, pbes2, pbkdf2OID, schemeOID)
+	}
+
+	salt, _ := asn1OctetString((*kdfParams)[0])
+	iterations, _ := asn1Integer((*kdfParams)[1])
+	iv, _ := asn1OctetString((*scheme)[1])
+	encrypted, _ := asn1OctetString((*info)[1])
+	if len(salt) != 16 || iterations.Int64() != 1000 || len(iv) != aes.BlockSize || len(encrypted)%aes.BlockSize != 0 {
+		t.Fatalf("%s: wrong parameters of encrypted key", name)
+	}
+
+	block, _ := aes.NewCipher(pbkdf2.Key(password, salt, int(iterations.Int64()), c.KeySize, sha256.New))
+	plain := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, encrypted)
+	padding := int(plain[len(plain)-1])
+	if padding <= 0 || padding > aes.BlockSize {
+		t.Fatalf("%s: wrong padding %d", name, padding)
+	}
+
+	return plain[:len(plain)-padding]
+}
+
+func TestEncryptPKCS8PrivateKey(t *testing.T) {
+	data := []byte("not a key, but any data works")
+	password := []byte("secret")
+	for _, name := range PBES2CipherNames() {
+		encrypted, err := EncryptPKCS8PrivateKey(rand.Reader, data, password, name, 1000)
+		if err != nil {
+			t.Fatalf("%s: encrypt failed: %s", name, err)
+		}
+
+		if decrypted := decryptPKCS8(t, encrypted, password, name); !bytes.Equal(decrypted, data) {
+			t.Errorf("%s: decrypted data is different", name)
+		}
+	}
+
+	if _, err := EncryptPKCS8PrivateKey(rand.Reader, data, password, "des-ede3-cbc", 1000); err == nil {
+		t.Errorf("unsupported cipher is accepted")
+	}
+
+	if _, err := EncryptPKCS8PrivateKey(rand.Reader, data, password, "aes-256-cbc", 0); err == nil {
+		t.Errorf("zero iterations is accepted")
+	}
+}
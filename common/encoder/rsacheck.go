@@ -0,0 +1,355 @@
+package encoder
+
+import (
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+// RSA private keys are parsed with the ASN.1 module without validation, since crypto/rsa
+// rejects inconsistent keys which are exactly what a check has to report.
+//
+//	RSAPrivateKey ::= SEQUENCE {
+//	  version INTEGER, modulus INTEGER, publicExponent INTEGER, privateExponent INTEGER,
+//	  prime1 INTEGER, prime2 INTEGER, exponent1 INTEGER, exponent2 INTEGER,
+//	  coefficient INTEGER, otherPrimeInfos OtherPrimeInfos OPTIONAL }
+//
+//	OtherPrimeInfo ::= SEQUENCE { prime INTEGER, exponent INTEGER, coefficient INTEGER }
+
+// RSAKeyComponents are integers of a RSA key as stored. Exponents are d mod (r - 1) of each
+// prime r. Coefficients are q^-1 mod p of the first two primes, and then inverse of product
+// of preceding primes modulo each other prime. Private fields are nil for public keys.
+type RSAKeyComponents struct {
+	N            *big.Int
+	E            *big.Int
+	D            *big.Int
+	Primes       []*big.Int
+	Exponents    []*big.Int
+	Coefficients []*big.Int
+}
+
+func (k *RSAKeyComponents) IsPrivate() bool {
+	return k.D != nil
+}
+
+func parsePKCS1RSAComponents(data []byte) (*RSAKeyComponents, error) {
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := asn1Sequence(obj, 9, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]*big.Int, 9)
+	for i := range values {
+		values[i], err = asn1Integer((*seq)[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key := &RSAKeyComponents{
+		N:            values[1],
+		E:            values[2],
+		D:            values[3],
+		Primes:       []*big.Int{values[4], values[5]},
+		Exponents:    []*big.Int{values[6], values[7]},
+		Coefficients: []*big.Int{values[8]},
+	}
+
+	if len(*seq) <= 9 {
+		return key, nil
+	}
+
+	others, err := asn1Sequence((*seq)[9], 1, 1<<16)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range *others {
+		info, err := asn1Sequence(other, 3, 3)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, list := range []*[]*big.Int{&key.Primes, &key.Exponents, &key.Coefficients} {
+			value, err := asn1Integer((*info)[i])
+			if err != nil {
+				return nil, err
+			}
+
+			*list = append(*list, value)
+		}
+	}
+
+	return key, nil
+}
+
+func parsePKCS8RSAComponents(data []byte) (*RSAKeyComponents, error) {
+	obj, err := readASN1Whole(data)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := asn1Sequence(obj, 3, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, err := asn1Sequence((*seq)[1], 1, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	oid, err := asn1ObjectIdentifier((*algorithm)[0])
+	if err != nil || !oid.Equal(asn1.OidRSAPkcs1RSAEncryption) {
+		return nil, fmt.Errorf("not a RSA key")
+	}
+
+	content, err := asn1OctetString((*seq)[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePKCS1RSAComponents(content)
+}
+
+// ParseRSAKeyComponents parses a RSA private key in PKCS#1 or PKCS#8 without validating it,
+// or a RSA public key in PKCS#1 or SPKI.
+func ParseRSAKeyComponents(data []byte) (*RSAKeyComponents, error) {
+	if key, err := parsePKCS1RSAComponents(data); err == nil {
+		return key, nil
+	}
+
+	if key, err := parsePKCS8RSAComponents(data); err == nil {
+		return key, nil
+	}
+
+	public, err := x509.ParsePKCS1PublicKey(data)
+	if err != nil {
+		key, pkixErr := x509.ParsePKIXPublicKey(data)
+		if pkixErr != nil {
+			return nil, fmt.Errorf("rsa key: unknown key format")
+		}
+
+		var ok bool
+		if public, ok = key.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("rsa key: not a RSA public key")
+		}
+	}
+
+	components := &RSAKeyComponents{
+		N: public.N,
+		E: big.NewInt(int64(public.E)),
+	}
+
+	return components, nil
+}
+
+// Status of RSA key checks. Weak keys are consistent but not secure.
+const (
+	RSACheckOK      = "ok"
+	RSACheckFailed  = "failed"
+	RSACheckWeak    = "weak"
+	RSACheckSkipped = "skipped"
+)
+
+// RSACheck is result of a check of RSA key, Detail explains status other than ok.
+type RSACheck struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+func rsaCheck(name string, status string, format string, args ...any) RSACheck {
+	return RSACheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)}
+}
+
+// rocaPrimes are small primes of the fingerprint of ROCA, CVE-2017-15361. Moduli generated
+// by the vulnerable library are congruent to a power of 65537 modulo all of them.
+var rocaPrimes = []int64{
+	3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73, 79, 83, 89,
+	97, 101, 103, 107, 109, 113, 127, 131, 137, 139, 149, 151, 157, 163, 167,
+}
+
+// IsROCAModulus returns true if n has the fingerprint of keys vulnerable to ROCA. Random
+// moduli have the fingerprint in a negligible probability.
+func IsROCAModulus(n *big.Int) bool {
+	m := new(big.Int)
+	for _, p := range rocaPrimes {
+		residue := m.Mod(n, big.NewInt(p)).Int64()
+		found := false
+		for power, g := int64(1), int64(65537%p); ; {
+			if power == residue {
+				found = true
+				break
+			}
+
+			power = power * g % p
+			if power == 1 {
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DebianWeakKeyFingerprint returns fingerprint of n in blacklists of openssl-vulnkey, i.e.
+// last 20 hex digits of SHA-1 of "Modulus=N\n", for keys generated by Debian OpenSSL with
+// predictable random numbers, CVE-2008-0166.
+func DebianWeakKeyFingerprint(n *big.Int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("Modulus=%X\n", n)))
+	return hex.EncodeToString(sum[:])[20:]
+}
+
+func checkRSAPublicKey(key *RSAKeyComponents, blacklist map[string]bool) []RSACheck {
+	checks := make([]RSACheck, 0, 10)
+	bits := key.N.BitLen()
+	switch {
+	case key.N.Bit(0) == 0 || bits < 2:
+		checks = append(checks, rsaCheck("modulus", RSACheckFailed, "modulus is not a positive odd number"))
+
+	case bits < 2048:
+		checks = append(checks, rsaCheck("modulus", RSACheckWeak, "%d bits, less than 2048 bits", bits))
+
+	default:
+		checks = append(checks, rsaCheck("modulus", RSACheckOK, ""))
+	}
+
+	switch {
+	case key.E.Cmp(big.NewInt(3)) < 0 || key.E.Bit(0) == 0:
+		checks = append(checks, rsaCheck("public exponent", RSACheckFailed, "%s is not an odd number at least 3", key.E))
+
+	case key.E.Cmp(big.NewInt(65537)) < 0:
+		checks = append(checks, rsaCheck("public exponent", RSACheckWeak, "%s is less than 65537", key.E))
+
+	default:
+		checks = append(checks, rsaCheck("public exponent", RSACheckOK, ""))
+	}
+
+	if IsROCAModulus(key.N) {
+		checks = append(checks, rsaCheck("ROCA", RSACheckWeak, "modulus has fingerprint of ROCA, CVE-2017-15361"))
+	} else {
+		checks = append(checks, rsaCheck("ROCA", RSACheckOK, ""))
+	}
+
+	switch {
+	case blacklist == nil:
+		checks = append(checks, rsaCheck("Debian weak key", RSACheckSkipped, "no blacklist given"))
+
+	case blacklist[DebianWeakKeyFingerprint(key.N)]:
+		checks = append(checks, rsaCheck("Debian weak key", RSACheckWeak, "key is blacklisted, CVE-2008-0166"))
+
+	default:
+		checks = append(checks, rsaCheck("Debian weak key", RSACheckOK, ""))
+	}
+
+	return checks
+}
+
+func checkRSAPrivateKey(key *RSAKeyComponents) []RSACheck {
+	checks := make([]RSACheck, 0, 5)
+	one := big.NewInt(1)
+
+	status, detail := RSACheckOK, ""
+	for i, prime := range key.Primes {
+		if prime.Cmp(one) <= 0 || !prime.ProbablyPrime(20) {
+			status, detail = RSACheckFailed, fmt.Sprintf("prime %d is not a prime", i+1)
+			break
+		}
+	}
+
+	checks = append(checks, rsaCheck("primes", status, "%s", detail))
+
+	product := big.NewInt(1)
+	for _, prime := range key.Primes {
+		product.Mul(product, prime)
+	}
+
+	if product.Cmp(key.N) != 0 {
+		checks = append(checks, rsaCheck("modulus factorization", RSACheckFailed, "product of primes is not modulus"))
+	} else {
+		checks = append(checks, rsaCheck("modulus factorization", RSACheckOK, ""))
+	}
+
+	// lambda(n) is lcm of r - 1 of all primes.
+	lambda := big.NewInt(1)
+	for _, prime := range key.Primes {
+		pm1 := new(big.Int).Sub(prime, one)
+		if pm1.Sign() <= 0 {
+			continue
+		}
+
+		gcd := new(big.Int).GCD(nil, nil, lambda, pm1)
+		lambda.Mul(lambda, pm1).Div(lambda, gcd)
+	}
+
+	de := new(big.Int).Mul(key.D, key.E)
+	if de.Mod(de, lambda).Cmp(one) != 0 {
+		checks = append(checks, rsaCheck("private exponent", RSACheckFailed, "d * e is not 1 mod lambda(n)"))
+	} else {
+		checks = append(checks, rsaCheck("private exponent", RSACheckOK, ""))
+	}
+
+	status, detail = RSACheckOK, ""
+	for i, prime := range key.Primes {
+		pm1 := new(big.Int).Sub(prime, one)
+		if i >= len(key.Exponents) || pm1.Sign() <= 0 || new(big.Int).Mod(key.D, pm1).Cmp(key.Exponents[i]) != 0 {
+			status, detail = RSACheckFailed, fmt.Sprintf("exponent %d is not d mod (prime %d - 1)", i+1, i+1)
+			break
+		}
+	}
+
+	checks = append(checks, rsaCheck("CRT exponents", status, "%s", detail))
+
+	status, detail = RSACheckOK, ""
+	product.SetInt64(1)
+	for i := 1; i < len(key.Primes); i++ {
+		// Coefficient of the first two primes is q^-1 mod p, others are inverse of product of
+		// preceding primes.
+		base, modulus := key.Primes[1], key.Primes[0]
+		if i > 1 {
+			product.Mul(product, key.Primes[i-1])
+			base, modulus = product, key.Primes[i]
+		} else {
+			product.Set(key.Primes[0])
+		}
+
+		c := new(big.Int)
+		if i-1 < len(key.Coefficients) && modulus.Sign() > 0 {
+			c.Mul(base, key.Coefficients[i-1]).Mod(c, modulus)
+		}
+
+		if c.Cmp(one) != 0 {
+			status, detail = RSACheckFailed, fmt.Sprintf("coefficient %d is not inverse modulo prime", i)
+			break
+		}
+	}
+
+	checks = append(checks, rsaCheck("CRT coefficients", status, "%s", detail))
+	return checks
+}
+
+// CheckRSAKey checks consistency and known weakness of a RSA key. Debian weak keys are only
+// checked with a blacklist of fingerprints given by DebianWeakKeyFingerprint.
+func CheckRSAKey(key *RSAKeyComponents, blacklist map[string]bool) []RSACheck {
+	checks := checkRSAPublicKey(key, blacklist)
+	if key.IsPrivate() {
+		checks = append(checks, checkRSAPrivateKey(key)...)
+	}
+
+	return checks
+}
@@ -0,0 +1,199 @@
+package encoder
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"math/big"
+	"testing"
+
+	"github.com/flily/go-ssl/modules/asn1"
+)
+
+// marshalRSAComponents encodes components in PKCS#1 without validation.
+func marshalRSAComponents(t *testing.T, key *RSAKeyComponents) []byte {
+	values := []*big.Int{big.NewInt(0), key.N, key.E, key.D, key.Primes[0], key.Primes[1],
+		key.Exponents[0], key.Exponents[1], key.Coefficients[0]}
+	objects := make([]asn1.ASN1Object, len(values))
+	for i, value := range values {
+		objects[i] = asn1.NewInteger(value)
+	}
+
+	der, err := asn1.Marshal(asn1.NewSequence(objects...))
+	if err != nil {
+		t.Fatalf("marshal key failed: %s", err)
+	}
+
+	return der
+}
+
+func checkStatus(checks []RSACheck) map[string]string {
+	result := make(map[string]string, len(checks))
+	for _, check := range checks {
+		result[check.Name] = check.Status
+	}
+
+	return result
+}
+
+func TestCheckRSAKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal pkcs8 failed: %s", err)
+	}
+
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key failed: %s", err)
+	}
+
+	inputs := [][]byte{x509.MarshalPKCS1PrivateKey(key), pkcs8, spki, x509.MarshalPKCS1PublicKey(&key.PublicKey)}
+	for i, input := range inputs {
+		components, err := ParseRSAKeyComponents(input)
+		if err != nil {
+			t.Fatalf("parse key %d failed: %s", i, err)
+		}
+
+		if components.IsPrivate() != (i < 2) {
+			t.Errorf("key %d: private is %v", i, components.IsPrivate())
+		}
+
+		checks := CheckRSAKey(components, map[string]bool{})
+		expected := 4
+		if components.IsPrivate() {
+			expected = 9
+		}
+
+		if len(checks) != expected {
+			t.Errorf("key %d: got %d checks, expected %d", i, len(checks), expected)
+		}
+
+		for _, check := range checks {
+			if check.Status != RSACheckOK {
+				t.Errorf("key %d: check %s is %s: %s", i, check.Name, check.Status, check.Detail)
+			}
+		}
+	}
+}
+
+func TestCheckInconsistentRSAKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	one := big.NewInt(1)
+	add := func(v *big.Int) *big.Int {
+		return new(big.Int).Add(v, big.NewInt(2))
+	}
+
+	cases := []struct {
+		check  string
+		modify func(k *RSAKeyComponents)
+	}{
+		{"modulus factorization", func(k *RSAKeyComponents) { k.N = add(k.N) }},
+		{"private exponent", func(k *RSAKeyComponents) { k.D = add(k.D) }},
+		{"primes", func(k *RSAKeyComponents) { k.Primes[0] = new(big.Int).Sub(k.Primes[0], one) }},
+		{"CRT exponents", func(k *RSAKeyComponents) { k.Exponents[1] = add(k.Exponents[1]) }},
+		{"CRT coefficients", func(k *RSAKeyComponents) { k.Coefficients[0] = add(k.Coefficients[0]) }},
+		{"public exponent", func(k *RSAKeyComponents) { k.E = big.NewInt(4) }},
+	}
+
+	for _, c := range cases {
+		components := &RSAKeyComponents{
+			N:            key.N,
+			E:            big.NewInt(int64(key.E)),
+			D:            key.D,
+			Primes:       []*big.Int{key.Primes[0], key.Primes[1]},
+			Exponents:    []*big.Int{key.Precomputed.Dp, key.Precomputed.Dq},
+			Coefficients: []*big.Int{key.Precomputed.Qinv},
+		}
+
+		c.modify(components)
+		parsed, err := ParseRSAKeyComponents(marshalRSAComponents(t, components))
+		if err != nil {
+			t.Fatalf("%s: parse key failed: %s", c.check, err)
+		}
+
+		status := checkStatus(CheckRSAKey(parsed, nil))
+		if status[c.check] != RSACheckFailed {
+			t.Errorf("%s: check is %s, expected failed", c.check, status[c.check])
+		}
+
+		if status["modulus"] != RSACheckWeak || status["Debian weak key"] != RSACheckSkipped {
+			t.Errorf("%s: 1024 bits key without blacklist is %v", c.check, status)
+		}
+	}
+}
+
+func TestCheckMultiPrimeRSAKey(t *testing.T) {
+	key, err := rsa.GenerateMultiPrimeKey(rand.Reader, 4, 2048) //nolint:staticcheck
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	components, err := ParseRSAKeyComponents(x509.MarshalPKCS1PrivateKey(key))
+	if err != nil {
+		t.Fatalf("parse key failed: %s", err)
+	}
+
+	if len(components.Primes) != 4 || len(components.Coefficients) != 3 {
+		t.Fatalf("got %d primes and %d coefficients", len(components.Primes), len(components.Coefficients))
+	}
+
+	for name, status := range checkStatus(CheckRSAKey(components, nil)) {
+		if status != RSACheckOK && status != RSACheckSkipped {
+			t.Errorf("check %s is %s", name, status)
+		}
+	}
+
+	components.Coefficients[2] = new(big.Int).Add(components.Coefficients[2], big.NewInt(1))
+	if status := checkStatus(CheckRSAKey(components, nil)); status["CRT coefficients"] != RSACheckFailed {
+		t.Errorf("modified coefficient of prime 4 is %s", status["CRT coefficients"])
+	}
+}
+
+func TestROCAFingerprint(t *testing.T) {
+	// Primes of vulnerable keys are k * M + (65537^a mod M), M is product of small primes.
+	m := big.NewInt(1)
+	for _, p := range rocaPrimes {
+		m.Mul(m, big.NewInt(p))
+	}
+
+	g := big.NewInt(65537)
+	p := new(big.Int).Exp(g, big.NewInt(1234), m)
+	p.Add(p, new(big.Int).Mul(m, big.NewInt(987654321)))
+	q := new(big.Int).Exp(g, big.NewInt(4321), m)
+	q.Add(q, new(big.Int).Mul(m, big.NewInt(123456789)))
+	if !IsROCAModulus(new(big.Int).Mul(p, q)) {
+		t.Errorf("modulus of ROCA structure is not detected")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	if IsROCAModulus(key.N) {
+		t.Errorf("random modulus is detected as ROCA")
+	}
+}
+
+func TestDebianWeakKeyFingerprint(t *testing.T) {
+	n := big.NewInt(0xc0ffee)
+	expected := "6149ed99475722f13e18"
+	if got := DebianWeakKeyFingerprint(n); got != expected {
+		t.Errorf("fingerprint is %s, expected %s", got, expected)
+	}
+
+	components := &RSAKeyComponents{N: n, E: big.NewInt(65537)}
+	status := checkStatus(CheckRSAKey(components, map[string]bool{expected: true}))
+	if status["Debian weak key"] != RSACheckWeak {
+		t.Errorf("blacklisted key is %s", status["Debian weak key"])
+	}
+}
@@ -0,0 +1,65 @@
+package prettyprint
+
+import (
+	"io"
+)
+
+// Style is a kind of text highlighted in colored output.
+type Style int
+
+const (
+	StyleNone Style = iota
+
+	// StyleTag is ASN.1 types and tags.
+	StyleTag
+	StyleOID
+	StyleString
+
+	// StyleWarning is values need attention, e.g. certificates expiring soon.
+	StyleWarning
+
+	// StyleError is invalid values, e.g. expired certificates.
+	StyleError
+
+	// StyleCritical is critical extensions.
+	StyleCritical
+)
+
+// styleCodes are SGR parameters of styles.
+var styleCodes = map[Style]string{
+	StyleTag:      "36",
+	StyleOID:      "34",
+	StyleString:   "32",
+	StyleWarning:  "33",
+	StyleError:    "31",
+	StyleCritical: "1;35",
+}
+
+// Terminal is a writer with capabilities of the terminal it writes to. Printers created on
+// a terminal color text if Color is true, and wrap hex dumps in Width columns if Width is
+// not 0.
+type Terminal struct {
+	io.Writer
+	Color bool
+	Width int
+}
+
+func NewTerminal(w io.Writer, color bool, width int) *Terminal {
+	t := &Terminal{
+		Writer: w,
+		Color:  color,
+		Width:  width,
+	}
+
+	return t
+}
+
+// Colorize returns s in ANSI color of style, or s itself if color is disabled.
+func Colorize(color bool, style Style, s string) string {
+	code, found := styleCodes[style]
+	if !color || !found || len(s) <= 0 {
+		return s
+	}
+
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
@@ -0,0 +1,96 @@
+package prettyprint
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// IntegerFormat is format of big integers.
+type IntegerFormat string
+
+const (
+	// IntegerHexColon prints bytes in hex separated by colons, a zero byte is padded if the
+	// highest bit is set, as DER encoding does.
+	IntegerHexColon IntegerFormat = "hex-colon"
+	IntegerHex      IntegerFormat = "hex"
+	IntegerDecimal  IntegerFormat = "decimal"
+	IntegerBase64   IntegerFormat = "base64"
+
+	// IntegerOpenSSL prints as openssl -text does, integers fit in 64 bits are printed in
+	// decimal and hex in one line, e.g. 65537 (0x10001), others as IntegerHexColon.
+	IntegerOpenSSL IntegerFormat = "openssl"
+)
+
+// IntegerFormats returns names of all integer formats.
+func IntegerFormats() []string {
+	return []string{
+		string(IntegerHexColon),
+		string(IntegerHex),
+		string(IntegerDecimal),
+		string(IntegerBase64),
+		string(IntegerOpenSSL),
+	}
+}
+
+// padInteger returns value padded with a zero byte if the highest bit is set, so that it is
+// positive in two's complement. Zero is a single zero byte.
+func padInteger(value []byte) []byte {
+	if len(value) <= 0 {
+		return []byte{0x00}
+	}
+
+	if value[0] >= 0x80 {
+		return append([]byte{0x00}, value...)
+	}
+
+	return value
+}
+
+func formatInteger(value []byte, negative bool, format IntegerFormat) string {
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+
+	if len(value) <= 0 {
+		value = []byte{0x00}
+	}
+
+	switch format {
+	case IntegerHex:
+		return sign + hex.EncodeToString(value)
+
+	case IntegerDecimal:
+		return sign + new(big.Int).SetBytes(value).String()
+
+	case IntegerBase64:
+		return sign + base64.StdEncoding.EncodeToString(value)
+
+	case IntegerOpenSSL:
+		n := new(big.Int).SetBytes(value)
+		if n.BitLen() <= 64 {
+			return fmt.Sprintf("%s%d (%s0x%x)", sign, n, sign, n)
+		}
+	}
+
+	parts := make([]string, 0, len(value)+1)
+	for _, c := range padInteger(value) {
+		parts = append(parts, hex.EncodeToString([]byte{c}))
+	}
+
+	return sign + strings.Join(parts, ":")
+}
+
+// FormatInteger formats big-endian unsigned integer value in one line. Leading zero bytes
+// are kept in hex and base64 formats, values of fixed size keep their size.
+func FormatInteger(value []byte, format IntegerFormat) string {
+	return formatInteger(value, false, format)
+}
+
+// FormatBigInt formats n in one line, negative values are prefixed with -.
+func FormatBigInt(n *big.Int, format IntegerFormat) string {
+	return formatInteger(n.Bytes(), n.Sign() < 0, format)
+}
@@ -0,0 +1,49 @@
+package prettyprint
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormatInteger(t *testing.T) {
+	cases := []struct {
+		value    []byte
+		format   IntegerFormat
+		expected string
+	}{
+		{[]byte{0x80}, IntegerHexColon, "00:80"},
+		{[]byte{0x7f, 0xff}, IntegerHexColon, "7f:ff"},
+		{[]byte{0x00, 0x01}, IntegerHex, "0001"},
+		{nil, IntegerHex, "00"},
+		{nil, IntegerDecimal, "0"},
+		{[]byte{0x01, 0x00}, IntegerDecimal, "256"},
+		{[]byte{0xff}, IntegerBase64, "/w=="},
+		{[]byte{0x01, 0x00, 0x01}, IntegerOpenSSL, "65537 (0x10001)"},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, IntegerOpenSSL,
+			"18446744073709551615 (0xffffffffffffffff)"},
+		{[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, IntegerOpenSSL,
+			"01:00:00:00:00:00:00:00:00"},
+	}
+
+	for _, c := range cases {
+		if s := FormatInteger(c.value, c.format); s != c.expected {
+			t.Errorf("format %x as %s: got %s, expected %s", c.value, c.format, s, c.expected)
+		}
+	}
+}
+
+func TestFormatBigInt(t *testing.T) {
+	n := big.NewInt(-255)
+	cases := map[IntegerFormat]string{
+		IntegerHexColon: "-00:ff",
+		IntegerHex:      "-ff",
+		IntegerDecimal:  "-255",
+		IntegerOpenSSL:  "-255 (-0xff)",
+	}
+
+	for format, expected := range cases {
+		if s := FormatBigInt(n, format); s != expected {
+			t.Errorf("format %s: got %s, expected %s", format, s, expected)
+		}
+	}
+}
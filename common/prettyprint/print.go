@@ -2,80 +2,308 @@ package prettyprint
 
 import (
 	"fmt"
+	"io"
+	"math/big"
 	"strings"
 )
 
-func binaryHex(data []byte, lineBytes int, indent string) string {
-	totalLines := (len(data) + lineBytes - 1) / lineBytes
-	lines := make([]string, 0, totalLines)
-	lineBuffer := make([]string, 0, 100)
-	for i := 0; i < len(data); i += 1 {
-		lastByte := ":"
-		if i+1 == len(data) {
-			lastByte = ""
+// Layout controls how binary values are printed.
+type Layout struct {
+	// BytesPerLine is number of bytes in a line, 0 prints all bytes in one line.
+	BytesPerLine int
+
+	// Separator is written between bytes, e.g. ":".
+	Separator string
+
+	Uppercase bool
+
+	// Indent is indentation of value lines, relative to the name line.
+	Indent string
+
+	// MaxBytes is number of bytes printed before the rest are elided, 0 for no limit.
+	MaxBytes int
+
+	// OpenSSL prints name lines as "name:" without sizes, as openssl -text does.
+	OpenSSL bool
+
+	// Integers is format of integers printed by Integer and BigInt.
+	Integers IntegerFormat
+
+	// Color enables ANSI colors of text highlighted by Colorize.
+	Color bool
+
+	// Width is number of columns of output, hex lines are wrapped to fit in it if not 0.
+	Width int
+}
+
+// DefaultLayout prints 15 bytes in a line, in lowercase hex separated by colons.
+func DefaultLayout() Layout {
+	layout := Layout{
+		BytesPerLine: 15,
+		Separator:    ":",
+		Indent:       "    ",
+		Integers:     IntegerHexColon,
+	}
+
+	return layout
+}
+
+// OpenSSLLayout prints values as openssl -text does.
+func OpenSSLLayout() Layout {
+	layout := DefaultLayout()
+	layout.OpenSSL = true
+	layout.Integers = IntegerOpenSSL
+	return layout
+}
+
+// CompactLayout prints values in one line without separators, e.g. message digests.
+func CompactLayout() Layout {
+	return Layout{}
+}
+
+type printerState struct {
+	w       io.Writer
+	err     error
+	midLine bool
+}
+
+// Printer writes names and values to a writer with a layout. Write errors are kept, the
+// first one is returned by Err.
+type Printer struct {
+	state  *printerState
+	layout Layout
+	indent string
+}
+
+func NewPrinter(w io.Writer) *Printer {
+	return NewPrinterWithLayout(w, DefaultLayout())
+}
+
+// NewPrinterWithLayout returns a printer of layout, color and width of the layout are taken
+// from w if it is a Terminal.
+func NewPrinterWithLayout(w io.Writer, layout Layout) *Printer {
+	if t, ok := w.(*Terminal); ok {
+		layout.Color = t.Color
+		layout.Width = t.Width
+	}
+
+	p := &Printer{
+		state:  &printerState{w: w},
+		layout: layout,
+	}
+
+	return p
+}
+
+func (p *Printer) Layout() Layout {
+	return p.layout
+}
+
+// Indent returns a printer writing to the same writer with indent added to every line.
+func (p *Printer) Indent(indent string) *Printer {
+	i := &Printer{
+		state:  p.state,
+		layout: p.layout,
+		indent: p.indent + indent,
+	}
+
+	return i
+}
+
+// Err returns the first error writing to the writer.
+func (p *Printer) Err() error {
+	return p.state.err
+}
+
+func (p *Printer) write(s string) {
+	if p.state.err != nil {
+		return
+	}
+
+	if len(s) > 0 {
+		p.state.midLine = s[len(s)-1] != '\n'
+	}
+
+	_, p.state.err = io.WriteString(p.state.w, s)
+}
+
+// Printf writes formatted text, indent is written at start of every line.
+func (p *Printer) Printf(format string, args ...any) {
+	s := fmt.Sprintf(format, args...)
+	if len(p.indent) <= 0 {
+		p.write(s)
+		return
+	}
+
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if len(line) <= 0 {
+			continue
 		}
 
-		lineBuffer = append(lineBuffer,
-			fmt.Sprintf("%02x%s", data[i], lastByte))
+		if p.state.midLine {
+			p.state.midLine = false
+		} else if line != "\n" {
+			b.WriteString(p.indent)
+		}
+
+		b.WriteString(line)
+	}
 
-		if len(lineBuffer) == lineBytes || len(data) == i+1 {
-			lines = append(lines, indent+strings.Join(lineBuffer, ""))
-			lineBuffer = make([]string, 0, 100)
+	p.write(b.String())
+}
+
+// Colorize returns s in color of style if color of the layout is enabled.
+func (p *Printer) Colorize(style Style, s string) string {
+	return Colorize(p.layout.Color, style, s)
+}
+
+// Hex formats data in one line with separator and case of the layout.
+func (p *Printer) Hex(data []byte) string {
+	digits := "0123456789abcdef"
+	if p.layout.Uppercase {
+		digits = "0123456789ABCDEF"
+	}
+
+	var b strings.Builder
+	b.Grow(len(data) * (2 + len(p.layout.Separator)))
+	for i, c := range data {
+		if i > 0 {
+			b.WriteString(p.layout.Separator)
 		}
+
+		b.WriteByte(digits[c>>4])
+		b.WriteByte(digits[c&0x0f])
 	}
 
-	return strings.Join(lines, "\n")
+	return b.String()
 }
 
-func binaryConcat(values ...[]byte) []byte {
-	length := 0
-	for _, value := range values {
-		length += len(value)
+// hexLines formats data in lines of BytesPerLine bytes, separators are kept at end of lines
+// as openssl does.
+func (p *Printer) hexLines(data []byte) []string {
+	perLine := p.layout.BytesPerLine
+	if p.layout.Width > 0 {
+		// Every byte takes 2 digits and a separator, including the last one in a line.
+		fit := (p.layout.Width - len(p.indent) - len(p.layout.Indent)) / (2 + len(p.layout.Separator))
+		if fit < 1 {
+			fit = 1
+		}
+
+		if perLine <= 0 || fit < perLine {
+			perLine = fit
+		}
 	}
 
-	result := make([]byte, 0, length)
-	for _, value := range values {
-		result = append(result, value...)
+	if perLine <= 0 {
+		perLine = len(data)
 	}
 
-	return result
+	lines := make([]string, 0, (len(data)+perLine-1)/perLine)
+	for start := 0; start < len(data); start += perLine {
+		end := start + perLine
+		if end > len(data) {
+			end = len(data)
+		}
+
+		line := p.Hex(data[start:end])
+		if end < len(data) {
+			line += p.layout.Separator
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
 }
 
-func PrintBinary(name string, value []byte) {
-	fmt.Printf("%s: [%d bytes]\n%s\n",
-		name, len(value), binaryHex(value, 15, "    "))
+// value prints name and value in hex lines, note is appended to the name line.
+func (p *Printer) value(name string, value []byte, note string) {
+	if len(value) == 0 {
+		p.Printf("%s: [empty]\n", name)
+		return
+	}
+
+	if p.layout.OpenSSL {
+		p.Printf("%s:%s\n", name, note)
+	} else {
+		p.Printf("%s: [%d bytes]%s\n", name, len(value), note)
+	}
+
+	shown := value
+	if p.layout.MaxBytes > 0 && len(value) > p.layout.MaxBytes {
+		shown = value[:p.layout.MaxBytes]
+	}
+
+	for _, line := range p.hexLines(shown) {
+		p.Printf("%s%s\n", p.layout.Indent, line)
+	}
+
+	if elided := len(value) - len(shown); elided > 0 {
+		p.Printf("%s... [%d more bytes]\n", p.layout.Indent, elided)
+	}
 }
 
-func PrintBinaryWithIndent(name string, indent string, value []byte) {
-	fmt.Printf("%s: [%d bytes]\n%s\n",
-		indent+name, len(value), binaryHex(value, 15, indent+"    "))
+// Binary prints name and value in hex lines.
+func (p *Printer) Binary(name string, value []byte) {
+	p.value(name, value, "")
 }
 
-func PrintBinariesWithIndent(name string, indent string, values ...[]byte) {
-	data := binaryConcat(values...)
-	fmt.Printf("%s: [%d bytes]\n%s\n",
-		indent+name, len(data), binaryHex(data, 15, indent+"    "))
+// Binaries prints name and concatenation of values.
+func (p *Printer) Binaries(name string, values ...[]byte) {
+	p.Binary(name, binaryConcat(values...))
 }
 
-func PrintBinaries(name string, values ...[]byte) {
-	PrintBinary(name, binaryConcat(values...))
+// Integer prints value as a big-endian unsigned integer in format of the layout.
+func (p *Printer) Integer(name string, value []byte) {
+	p.integer(name, value, false)
 }
 
-// PPrintBinary print value make sure it is positive
-func PPrintBinary(name string, value []byte) {
-	if len(value) == 0 {
-		fmt.Printf("%s: [empty]\n", name)
+// BigInt prints n in format of the layout.
+func (p *Printer) BigInt(name string, n *big.Int) {
+	p.integer(name, n.Bytes(), n.Sign() < 0)
+}
+
+func (p *Printer) integer(name string, value []byte, negative bool) {
+	note := ""
+	if negative {
+		note = " (negative)"
+	}
+
+	format := p.layout.Integers
+	switch format {
+	case "", IntegerHexColon:
+		p.value(name, padInteger(value), note)
 		return
+
+	case IntegerOpenSSL:
+		if new(big.Int).SetBytes(value).BitLen() > 64 {
+			layout := p.layout
+			layout.OpenSSL = true
+			openssl := &Printer{state: p.state, layout: layout, indent: p.indent}
+			openssl.value(name, padInteger(value), note)
+			return
+		}
+	}
+
+	s := formatInteger(value, negative, format)
+	if p.layout.Uppercase && format == IntegerHex {
+		s = strings.ToUpper(s)
 	}
 
-	length := len(value)
-	padded := ""
-	first := value[0]
-	if first > 0x80 {
-		value = append([]byte{0x00}, value...)
-		padded = " (padded)"
+	p.Printf("%s: %s\n", name, s)
+}
+
+func binaryConcat(values ...[]byte) []byte {
+	length := 0
+	for _, value := range values {
+		length += len(value)
 	}
 
-	fmt.Printf("%s: [%d bytes%s]\n%s\n",
-		name, length, padded, binaryHex(value, 15, "    "))
+	result := make([]byte, 0, length)
+	for _, value := range values {
+		result = append(result, value...)
+	}
+
+	return result
 }
@@ -0,0 +1,224 @@
+package prettyprint
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func sequence(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	return data
+}
+
+func TestPrinterLayouts(t *testing.T) {
+	upper := DefaultLayout()
+	upper.Separator = " "
+	upper.Uppercase = true
+	upper.BytesPerLine = 4
+
+	elided := DefaultLayout()
+	elided.MaxBytes = 4
+
+	cases := []struct {
+		layout   Layout
+		value    []byte
+		expected string
+	}{
+		{
+			DefaultLayout(),
+			sequence(17),
+			"data: [17 bytes]\n" +
+				"    00:01:02:03:04:05:06:07:08:09:0a:0b:0c:0d:0e:\n" +
+				"    0f:10\n",
+		},
+		{
+			upper,
+			[]byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+			"data: [6 bytes]\n" +
+				"    AA BB CC DD \n" +
+				"    EE FF\n",
+		},
+		{
+			elided,
+			sequence(10),
+			"data: [10 bytes]\n" +
+				"    00:01:02:03\n" +
+				"    ... [6 more bytes]\n",
+		},
+		{
+			OpenSSLLayout(),
+			[]byte{0x01, 0x02},
+			"data:\n" +
+				"    01:02\n",
+		},
+		{
+			DefaultLayout(),
+			nil,
+			"data: [empty]\n",
+		},
+	}
+
+	for i, c := range cases {
+		var buffer bytes.Buffer
+		p := NewPrinterWithLayout(&buffer, c.layout)
+		p.Binary("data", c.value)
+		if p.Err() != nil {
+			t.Errorf("case %d: print failed: %s", i, p.Err())
+		}
+
+		if buffer.String() != c.expected {
+			t.Errorf("case %d: wrong output:\n%s\nexpected:\n%s", i, buffer.String(), c.expected)
+		}
+	}
+}
+
+func TestPrinterIndent(t *testing.T) {
+	var buffer bytes.Buffer
+	p := NewPrinter(&buffer)
+	p.Printf("Key:\n")
+	q := p.Indent("  ")
+	q.Printf("Size: ")
+	q.Printf("%d\n\n", 2)
+	q.Binary("Value", []byte{0x01, 0x02})
+	p.Printf("End\n")
+
+	expected := "Key:\n" +
+		"  Size: 2\n" +
+		"\n" +
+		"  Value: [2 bytes]\n" +
+		"      01:02\n" +
+		"End\n"
+	if buffer.String() != expected {
+		t.Errorf("wrong output:\n%s\nexpected:\n%s", buffer.String(), expected)
+	}
+}
+
+func TestPrinterInteger(t *testing.T) {
+	var buffer bytes.Buffer
+	p := NewPrinter(&buffer)
+	p.Integer("N", []byte{0x81, 0x02})
+	p.Integer("M", []byte{0x80, 0x00})
+	p.Integer("E", []byte{0x01, 0x00, 0x01})
+	p.BigInt("V", big.NewInt(-0x7f))
+
+	expected := "N: [3 bytes]\n" +
+		"    00:81:02\n" +
+		"M: [3 bytes]\n" +
+		"    00:80:00\n" +
+		"E: [3 bytes]\n" +
+		"    01:00:01\n" +
+		"V: [1 bytes] (negative)\n" +
+		"    7f\n"
+	if buffer.String() != expected {
+		t.Errorf("wrong output:\n%s\nexpected:\n%s", buffer.String(), expected)
+	}
+}
+
+func TestPrinterIntegerFormats(t *testing.T) {
+	modulus := append([]byte{0x80}, sequence(16)...)
+	cases := []struct {
+		format   IntegerFormat
+		expected string
+	}{
+		{
+			IntegerHex,
+			"E: 010001\n" +
+				"N: 80000102030405060708090a0b0c0d0e0f\n",
+		},
+		{
+			IntegerDecimal,
+			"E: 65537\n" +
+				"N: 43556148198980729566118000706662062427663\n",
+		},
+		{
+			IntegerBase64,
+			"E: AQAB\n" +
+				"N: gAABAgMEBQYHCAkKCwwNDg8=\n",
+		},
+		{
+			IntegerOpenSSL,
+			"E: 65537 (0x10001)\n" +
+				"N:\n" +
+				"    00:80:00:01:02:03:04:05:06:07:08:09:0a:0b:0c:\n" +
+				"    0d:0e:0f\n",
+		},
+	}
+
+	for _, c := range cases {
+		var buffer bytes.Buffer
+		layout := DefaultLayout()
+		layout.Integers = c.format
+		p := NewPrinterWithLayout(&buffer, layout)
+		p.Integer("E", []byte{0x01, 0x00, 0x01})
+		p.Integer("N", modulus)
+		if buffer.String() != c.expected {
+			t.Errorf("format %s: wrong output:\n%s\nexpected:\n%s", c.format, buffer.String(), c.expected)
+		}
+	}
+}
+
+func TestPrinterOpenSSLIntegerLayout(t *testing.T) {
+	var buffer bytes.Buffer
+	layout := DefaultLayout()
+	layout.Integers = IntegerOpenSSL
+	layout.Uppercase = true
+	layout.Indent = "  "
+	layout.Width = 20
+	p := NewPrinterWithLayout(&buffer, layout)
+	p.Integer("N", append([]byte{0x80}, sequence(16)...))
+
+	expected := "N:\n" +
+		"  00:80:00:01:02:03:\n" +
+		"  04:05:06:07:08:09:\n" +
+		"  0A:0B:0C:0D:0E:0F\n"
+	if buffer.String() != expected {
+		t.Errorf("wrong output:\n%s\nexpected:\n%s", buffer.String(), expected)
+	}
+}
+
+func TestPrinterCompactHex(t *testing.T) {
+	p := NewPrinterWithLayout(nil, CompactLayout())
+	if s := p.Hex([]byte{0xde, 0xad, 0xbe, 0xef}); s != "deadbeef" {
+		t.Errorf("wrong hex: %s", s)
+	}
+}
+
+type failedWriter struct{}
+
+func (failedWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestPrinterError(t *testing.T) {
+	p := NewPrinter(failedWriter{})
+	p.Printf("first\n")
+	p.Indent("  ").Binary("data", []byte{0x01})
+	if p.Err() == nil || p.Err().Error() != "write failed" {
+		t.Errorf("write error should be kept: %v", p.Err())
+	}
+}
+
+func TestPrinterTerminal(t *testing.T) {
+	var buffer bytes.Buffer
+	p := NewPrinter(NewTerminal(&buffer, true, 20))
+	p.Printf("%s\n", p.Colorize(StyleError, "expired"))
+	p.Indent("  ").Binary("data", sequence(6))
+
+	expected := "\x1b[31mexpired\x1b[0m\n" +
+		"  data: [6 bytes]\n" +
+		"      00:01:02:03:\n" +
+		"      04:05\n"
+	if buffer.String() != expected {
+		t.Errorf("wrong output:\n%q\nexpected:\n%q", buffer.String(), expected)
+	}
+
+	if s := NewPrinter(&buffer).Colorize(StyleError, "expired"); s != "expired" {
+		t.Errorf("text should not be colored: %q", s)
+	}
+}
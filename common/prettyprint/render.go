@@ -0,0 +1,64 @@
+package prettyprint
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+// Document is information shown by a command. It is rendered as text by WriteText, or as
+// JSON encoded with its json tags, both must carry the same information.
+type Document interface {
+	WriteText(w io.Writer) error
+}
+
+// Renderer writes a document in an output format.
+type Renderer interface {
+	Render(w io.Writer, doc Document) error
+}
+
+type TextRenderer struct{}
+
+func (r TextRenderer) Render(w io.Writer, doc Document) error {
+	return doc.WriteText(w)
+}
+
+// JSONRenderer writes document as an indented JSON object, terminated by a newline.
+type JSONRenderer struct{}
+
+func (r JSONRenderer) Render(w io.Writer, doc Document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// NewRenderer returns JSON renderer if json is true, or text renderer otherwise.
+func NewRenderer(json bool) Renderer {
+	if json {
+		return JSONRenderer{}
+	}
+
+	return TextRenderer{}
+}
+
+// Hex is binary data encoded in JSON as a lowercase hex string without separators.
+type Hex []byte
+
+func (h Hex) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+func (h *Hex) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	*h = b
+	return nil
+}
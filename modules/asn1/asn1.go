@@ -1,5 +1,9 @@
 package asn1
 
+import (
+	"fmt"
+)
+
 // asn1 implements DER encoding and decoding of ASN.1 data structures.
 // Reference:
 //   - ITU-T X.680, ISO/IEC 8824-1:2021
@@ -55,6 +59,26 @@ func WriteASN1Objects(buffer []byte, offset int, objects ...ASN1Object) (int, er
 	return offset, nil
 }
 
+func ObjectWireLength(obj ASN1Object) int {
+	length := obj.ContentLength()
+	return obj.Tag().WireLength() + length.WireLength() + length.Int()
+}
+
+func Marshal(objects ...ASN1Object) ([]byte, error) {
+	size := 0
+	for _, obj := range objects {
+		size += ObjectWireLength(obj)
+	}
+
+	buffer := make([]byte, size)
+	next, err := WriteASN1Objects(buffer, 0, objects...)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer[:next], nil
+}
+
 func makeASN1Object(tag *Tag) ASN1Object {
 	var o ASN1Object
 	switch tag.Number {
@@ -103,6 +127,14 @@ func ReadASN1Object(buffer []byte, offset int) (ASN1Object, int, error) {
 		return nil, -1, err
 	}
 
+	if objLength.IsIndefinite() {
+		return nil, -1, fmt.Errorf("asn1: indefinite length at byte %d is not supported in DER", offset)
+	}
+
+	if err := checkBufferSize(buffer, next, objLength.Int()); err != nil {
+		return nil, -1, err
+	}
+
 	info := NewASN1ObjectInfo(tag, objLength)
 	obj := makeASN1Object(tag)
 
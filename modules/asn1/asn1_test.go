@@ -311,3 +311,27 @@ func TestBitStringObjectEncoding(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshal(t *testing.T) {
+	seq := NewSequence(
+		NewIntegerFromInt64(1),
+		NewObjectIdentifier(1, 2, 840, 10045, 3, 1, 7),
+		NewNull(),
+	)
+
+	expected := []byte{
+		0x30, 0x0f,
+		0x02, 0x01, 0x01,
+		0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07,
+		0x05, 0x00,
+	}
+
+	result, err := Marshal(seq)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(result, expected) {
+		t.Errorf("wrong encoding result: %x, expected %x", result, expected)
+	}
+}
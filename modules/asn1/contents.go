@@ -100,6 +100,10 @@ func NewIntegerFromInt64(value int64) *ASN1Integer {
 	return i
 }
 
+func (i *ASN1Integer) Value() *big.Int {
+	return i.value
+}
+
 func (i *ASN1Integer) Tag() *Tag {
 	t := &Tag{
 		Class:  TagClassUniversal,
@@ -305,8 +309,12 @@ func (s *ASN1BitString) ReadContentFrom(buffer []byte, offset int, info *ASN1Obj
 		s.Object, _, err = ReadASN1Object(buffer, offset)
 
 	} else {
-		diff := buffer[offset]
 		length := info.Length.Int()
+		if length < 1 {
+			return fmt.Errorf("asn1: empty bit string at byte %d", offset)
+		}
+
+		diff := buffer[offset]
 		s.Data = make([]byte, length-1)
 		copy(s.Data, buffer[offset+1:offset+length])
 		s.BitLength = (length-1)*8 - int(diff)
@@ -383,6 +391,14 @@ func NewOctetString(value any) *ASN1OctetString {
 	return nil
 }
 
+func (s *ASN1OctetString) Bytes() []byte {
+	return s.valueBytes
+}
+
+func (s *ASN1OctetString) Object() ASN1Object {
+	return s.valueObject
+}
+
 func (s *ASN1OctetString) Tag() *Tag {
 	t := &Tag{
 		Class:  TagClassUniversal,
@@ -55,6 +55,10 @@ func (i *ASN1ObjectIdentifier) ReadContentFrom(buffer []byte, offset int, info *
 		return err
 	}
 
+	if length < 1 {
+		return fmt.Errorf("asn1: empty object identifier at byte %d", offset)
+	}
+
 	firstOctet := buffer[offset]
 	*i = append((*i)[:0], uint64(firstOctet/40), uint64(firstOctet%40))
 	next := offset + 1
@@ -134,6 +138,9 @@ var (
 	OidX962Signature = OidANSIX962.Child(4)            // 1.2.840.10045.4
 	OidX962Module    = OidANSIX962.Child(5)            // 1.2.840.10045.5
 
+	OidX962PrimeField             = OidX962FieldType.Child(1) // 1.2.840.10045.1.1
+	OidX962CharacteristicTwoField = OidX962FieldType.Child(2) // 1.2.840.10045.1.2
+
 	OidECPublicKey                  = OidX962KeyTYpe.Child(1)           // 1.2.840.10045.2.1
 	OidPrimeCurve                   = OidX962Curves.Child(1)            // 1.2.840.10045.3.1
 	OidPrimeCurveP192v1             = OidPrimeCurve.Child(1)            // 1.2.840.10045.3.1.1
@@ -185,8 +192,17 @@ var (
 	OidCerticomCurveAnsiT571k1 = OidCerticomCurve.Child(38)     // 1.3.132.38
 	OidCerticomCurveAnsiT571r1 = OidCerticomCurve.Child(39)     // 1.3.132.39
 
+	OidX957            = OidMemberBody.Child(840, 10040) // 1.2.840.10040
+	OidX957DSA         = OidX957.Child(4, 1)             // 1.2.840.10040.4.1
+	OidX957DSAWithSHA1 = OidX957.Child(4, 3)             // 1.2.840.10040.4.3
+
 	OidRSADsi   = OidMemberBody.Child(840, 113549) // 1.2.840.113549
 	OidRSAPkcs1 = OidRSADsi.Child(1, 1)            // 1.2.840.113549.1.1
+	OidRSAPkcs5 = OidRSADsi.Child(1, 5)            // 1.2.840.113549.1.5
+	OidRSAPkcs7 = OidRSADsi.Child(1, 7)            // 1.2.840.113549.1.7
+
+	OidRSAPkcs5PBKDF2 = OidRSAPkcs5.Child(12) // 1.2.840.113549.1.5.12
+	OidRSAPkcs5PBES2  = OidRSAPkcs5.Child(13) // 1.2.840.113549.1.5.13
 
 	OidRSAPkcs1RSAEncryption        = OidRSAPkcs1.Child(1)  // 1.2.840.113549.1.1.1
 	OidRSAPkcs1MD2WithRSA           = OidRSAPkcs1.Child(2)  // 1.2.840.113549.1.1.2
@@ -203,6 +219,42 @@ var (
 	OidRSAPkcs1Sha512WithRSA        = OidRSAPkcs1.Child(13) // 1.2.840.113549.1.1.13
 	OidRSAPkcs1Sha224WithRSA        = OidRSAPkcs1.Child(14) // 1.2.840.113549.1.1.14
 
+	OidRSADigestAlgorithm = OidRSADsi.Child(2)             // 1.2.840.113549.2
+	OidMD4                = OidRSADigestAlgorithm.Child(4) // 1.2.840.113549.2.4
+	OidMD5                = OidRSADigestAlgorithm.Child(5) // 1.2.840.113549.2.5
+	OidHMACWithSHA1       = OidRSADigestAlgorithm.Child(7) // 1.2.840.113549.2.7
+	OidHMACWithSHA256     = OidRSADigestAlgorithm.Child(9) // 1.2.840.113549.2.9
+
+	OidSHA1      = OidISOIdentifiedOrg.Child(14, 3, 2, 26) // 1.3.14.3.2.26
+	OidRIPEMD160 = OidISOIdentifiedOrg.Child(36, 3, 2, 1)  // 1.3.36.3.2.1
+	OidSM3       = OidMemberBody.Child(156, 10197, 1, 401) // 1.2.156.10197.1.401
+
+	OidBLAKE2b    = OidISOIdentifiedOrg.Child(6, 1, 4, 1, 1722, 12, 2, 1) // 1.3.6.1.4.1.1722.12.2.1
+	OidBLAKE2b256 = OidBLAKE2b.Child(8)                                   // 1.3.6.1.4.1.1722.12.2.1.8
+	OidBLAKE2b384 = OidBLAKE2b.Child(12)                                  // 1.3.6.1.4.1.1722.12.2.1.12
+	OidBLAKE2b512 = OidBLAKE2b.Child(16)                                  // 1.3.6.1.4.1.1722.12.2.1.16
+	OidBLAKE2s    = OidISOIdentifiedOrg.Child(6, 1, 4, 1, 1722, 12, 2, 2) // 1.3.6.1.4.1.1722.12.2.2
+	OidBLAKE2s256 = OidBLAKE2s.Child(8)                                   // 1.3.6.1.4.1.1722.12.2.2.8
+
+	OidNISTAES       = OidJointISOITUT.Child(16, 840, 1, 101, 3, 4, 1) // 2.16.840.1.101.3.4.1
+	OidNISTAES128CBC = OidNISTAES.Child(2)                             // 2.16.840.1.101.3.4.1.2
+	OidNISTAES192CBC = OidNISTAES.Child(22)                            // 2.16.840.1.101.3.4.1.22
+	OidNISTAES256CBC = OidNISTAES.Child(42)                            // 2.16.840.1.101.3.4.1.42
+
+	OidNISTHashAlgorithm = OidJointISOITUT.Child(16, 840, 1, 101, 3, 4, 2) // 2.16.840.1.101.3.4.2
+	OidSHA256            = OidNISTHashAlgorithm.Child(1)                   // 2.16.840.1.101.3.4.2.1
+	OidSHA384            = OidNISTHashAlgorithm.Child(2)                   // 2.16.840.1.101.3.4.2.2
+	OidSHA512            = OidNISTHashAlgorithm.Child(3)                   // 2.16.840.1.101.3.4.2.3
+	OidSHA224            = OidNISTHashAlgorithm.Child(4)                   // 2.16.840.1.101.3.4.2.4
+	OidSHA512224         = OidNISTHashAlgorithm.Child(5)                   // 2.16.840.1.101.3.4.2.5
+	OidSHA512256         = OidNISTHashAlgorithm.Child(6)                   // 2.16.840.1.101.3.4.2.6
+	OidSHA3224           = OidNISTHashAlgorithm.Child(7)                   // 2.16.840.1.101.3.4.2.7
+	OidSHA3256           = OidNISTHashAlgorithm.Child(8)                   // 2.16.840.1.101.3.4.2.8
+	OidSHA3384           = OidNISTHashAlgorithm.Child(9)                   // 2.16.840.1.101.3.4.2.9
+	OidSHA3512           = OidNISTHashAlgorithm.Child(10)                  // 2.16.840.1.101.3.4.2.10
+	OidSHAKE128          = OidNISTHashAlgorithm.Child(11)                  // 2.16.840.1.101.3.4.2.11
+	OidSHAKE256          = OidNISTHashAlgorithm.Child(12)                  // 2.16.840.1.101.3.4.2.12
+
 	OidDirectoryServices = OidJointISOITUT.Child(5) // 2.5
 
 	OidDirectoryAttributeTypes         = OidDirectoryServices.Child(4)        // 2.5.4
@@ -286,6 +338,8 @@ var oidNames = []struct {
 	{OidISO, "iso"},
 	{OidJointISOITUT, "joint-iso-itu-t"},
 
+	{OidX962PrimeField, "Prime Field"},                          // 1.2.840.10045.1.1
+	{OidX962CharacteristicTwoField, "Characteristic Two Field"}, // 1.2.840.10045.1.2
 	{OidECPublicKey, "EC Public Key"},                           // 1.2.840.10045.2.1
 	{OidPrimeCurve, "Prime Curve"},                              // 1.2.840.10045.3.1
 	{OidPrimeCurveP192v1, "Prime Curve P192v1"},                 // 1.2.840.10045.3.1.1
@@ -303,6 +357,54 @@ var oidNames = []struct {
 	{OidSignaureECDSAWithSHA384, "ECDSA with SHA384"},           // 1.2.840.10045.4.3.3
 	{OidSignaureECDSAWithSHA512, "ECDSA with SHA512"},           // 1.2.840.10045.4.3.4
 
+	{OidX957DSA, "DSA"},                   // 1.2.840.10040.4.1
+	{OidX957DSAWithSHA1, "DSA with SHA1"}, // 1.2.840.10040.4.3
+
+	{OidRSAPkcs1RSAEncryption, "RSA Encryption"},          // 1.2.840.113549.1.1.1
+	{OidRSAPkcs1MD2WithRSA, "MD2 with RSA"},               // 1.2.840.113549.1.1.2
+	{OidRSAPkcs1MD4WithRSA, "MD4 with RSA"},               // 1.2.840.113549.1.1.3
+	{OidRSAPkcs1MD5WithRSA, "MD5 with RSA"},               // 1.2.840.113549.1.1.4
+	{OidRSAPkcs1SHA1WithRSA, "SHA1 with RSA"},             // 1.2.840.113549.1.1.5
+	{OidRSAPkcs1RSAOaepEncryptionSET, "RSA OAEP for SET"}, // 1.2.840.113549.1.1.6
+	{OidRSAPkcs1IdRSASEOaep, "RSAES-OAEP"},                // 1.2.840.113549.1.1.7
+	{OidRSAPkcs1IdMgf1, "MGF1"},                           // 1.2.840.113549.1.1.8
+	{OidRSAPkcs1IdPSpecified, "PSpecified"},               // 1.2.840.113549.1.1.9
+	{OidRSAPkcs1RsaSsaPss, "RSASSA-PSS"},                  // 1.2.840.113549.1.1.10
+	{OidRSAPkcs1Sha256WithRSA, "SHA256 with RSA"},         // 1.2.840.113549.1.1.11
+	{OidRSAPkcs1Sha384WithRSA, "SHA384 with RSA"},         // 1.2.840.113549.1.1.12
+	{OidRSAPkcs1Sha512WithRSA, "SHA512 with RSA"},         // 1.2.840.113549.1.1.13
+	{OidRSAPkcs1Sha224WithRSA, "SHA224 with RSA"},         // 1.2.840.113549.1.1.14
+
+	{OidMD4, "MD4"},
+	{OidMD5, "MD5"},
+	{OidSHA1, "SHA1"},
+	{OidRIPEMD160, "RIPEMD160"},
+	{OidSM3, "SM3"},
+	{OidBLAKE2b256, "BLAKE2b-256"},
+	{OidBLAKE2b384, "BLAKE2b-384"},
+	{OidBLAKE2b512, "BLAKE2b-512"},
+	{OidBLAKE2s256, "BLAKE2s-256"},
+	{OidSHA256, "SHA256"},
+	{OidSHA384, "SHA384"},
+	{OidSHA512, "SHA512"},
+	{OidSHA224, "SHA224"},
+	{OidSHA512224, "SHA512-224"},
+	{OidSHA512256, "SHA512-256"},
+	{OidSHA3224, "SHA3-224"},
+	{OidSHA3256, "SHA3-256"},
+	{OidSHA3384, "SHA3-384"},
+	{OidSHA3512, "SHA3-512"},
+	{OidSHAKE128, "SHAKE128"},
+	{OidSHAKE256, "SHAKE256"},
+
+	{OidRSAPkcs5PBKDF2, "PBKDF2"},
+	{OidRSAPkcs5PBES2, "PBES2"},
+	{OidHMACWithSHA1, "HMAC with SHA1"},
+	{OidHMACWithSHA256, "HMAC with SHA256"},
+	{OidNISTAES128CBC, "AES-128-CBC"},
+	{OidNISTAES192CBC, "AES-192-CBC"},
+	{OidNISTAES256CBC, "AES-256-CBC"},
+
 	{OidDirectoryAttributeTypes, "Directory Attribute Types"},
 	{OidObjectClass, "Object Class"},
 	{OidAliasedEntryName, "Aliased Entry Name"},
@@ -50,3 +50,15 @@ func TestObjectIdentifierEncoding(t *testing.T) {
 		}
 	}
 }
+
+func TestObjectIdentifierWithZeroArc(t *testing.T) {
+	expected := []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x22}
+	result, err := Marshal(OidCerticomCurveAnsiP384r1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(result, expected) {
+		t.Errorf("wrong encoding result: %x, expected %x", result, expected)
+	}
+}
@@ -2,6 +2,11 @@ package asn1
 
 func getBase128UintByteSize(n uint64) int {
 	size := 0
+	if n == 0 {
+		// zero still takes one byte, e.g. the 0 arc in OID 1.3.132.0.34
+		return 1
+	}
+
 	for n > 0 {
 		size++
 		n >>= 7
@@ -12,7 +17,7 @@ func getBase128UintByteSize(n uint64) int {
 
 func writeBase128Uint(buffer []byte, offset int, n uint64, size int) int {
 	for i := 0; i < size; i++ {
-		b := n >> uint((size-i-1)*7)
+		b := (n >> uint((size-i-1)*7)) & 0x7f
 		if i < size-1 {
 			b |= 0x80
 		}
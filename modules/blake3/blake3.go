@@ -0,0 +1,279 @@
+// Package blake3 implements the BLAKE3 hash function in hash mode, with extendable output.
+package blake3
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	// Size is default size of BLAKE3 output in bytes.
+	Size = 32
+
+	// BlockSize is size of BLAKE3 blocks in bytes.
+	BlockSize = 64
+
+	chunkSize = 1024
+)
+
+// Flags of compression.
+const (
+	flagChunkStart = 1 << iota
+	flagChunkEnd
+	flagParent
+	flagRoot
+)
+
+var iv = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+var messagePermutation = [16]int{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8}
+
+func g(state *[16]uint32, a, b, c, d int, mx, my uint32) {
+	state[a] += state[b] + mx
+	state[d] = bits.RotateLeft32(state[d]^state[a], -16)
+	state[c] += state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], -12)
+	state[a] += state[b] + my
+	state[d] = bits.RotateLeft32(state[d]^state[a], -8)
+	state[c] += state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], -7)
+}
+
+func round(state *[16]uint32, m *[16]uint32) {
+	g(state, 0, 4, 8, 12, m[0], m[1])
+	g(state, 1, 5, 9, 13, m[2], m[3])
+	g(state, 2, 6, 10, 14, m[4], m[5])
+	g(state, 3, 7, 11, 15, m[6], m[7])
+	g(state, 0, 5, 10, 15, m[8], m[9])
+	g(state, 1, 6, 11, 12, m[10], m[11])
+	g(state, 2, 7, 8, 13, m[12], m[13])
+	g(state, 3, 4, 9, 14, m[14], m[15])
+}
+
+func compress(cv *[8]uint32, block *[16]uint32, counter uint64, blockLen uint32, flags uint32) [16]uint32 {
+	state := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		iv[0], iv[1], iv[2], iv[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+
+	m := *block
+	for r := 0; r < 7; r++ {
+		round(&state, &m)
+		if r < 6 {
+			var permuted [16]uint32
+			for i, j := range messagePermutation {
+				permuted[i] = m[j]
+			}
+
+			m = permuted
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		state[i] ^= state[i+8]
+		state[i+8] ^= cv[i]
+	}
+
+	return state
+}
+
+func first8(words [16]uint32) [8]uint32 {
+	var cv [8]uint32
+	copy(cv[:], words[:8])
+	return cv
+}
+
+func blockWords(block []byte) [16]uint32 {
+	var padded [BlockSize]byte
+	copy(padded[:], block)
+
+	var words [16]uint32
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(padded[i*4:])
+	}
+
+	return words
+}
+
+// output is the last compression of a node, it is a chaining value, or root output of any
+// size if the node is the root.
+type output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o *output) chainingValue() [8]uint32 {
+	return first8(compress(&o.cv, &o.block, o.counter, o.blockLen, o.flags))
+}
+
+func (o *output) rootBytes(out []byte) {
+	var buffer [BlockSize]byte
+	for counter := uint64(0); len(out) > 0; counter++ {
+		words := compress(&o.cv, &o.block, counter, o.blockLen, o.flags|flagRoot)
+		for i, w := range words {
+			binary.LittleEndian.PutUint32(buffer[i*4:], w)
+		}
+
+		n := copy(out, buffer[:])
+		out = out[n:]
+	}
+}
+
+type chunkState struct {
+	cv               [8]uint32
+	counter          uint64
+	block            [BlockSize]byte
+	blockLen         int
+	blocksCompressed int
+}
+
+func newChunkState(key [8]uint32, counter uint64) chunkState {
+	return chunkState{cv: key, counter: counter}
+}
+
+func (c *chunkState) len() int {
+	return BlockSize*c.blocksCompressed + c.blockLen
+}
+
+func (c *chunkState) startFlag() uint32 {
+	if c.blocksCompressed == 0 {
+		return flagChunkStart
+	}
+
+	return 0
+}
+
+func (c *chunkState) update(input []byte) {
+	for len(input) > 0 {
+		// A full block is compressed only if more data follow, the last block is compressed
+		// with flagChunkEnd in output.
+		if c.blockLen == BlockSize {
+			words := blockWords(c.block[:])
+			c.cv = first8(compress(&c.cv, &words, c.counter, BlockSize, c.startFlag()))
+			c.blocksCompressed++
+			c.blockLen = 0
+		}
+
+		n := copy(c.block[c.blockLen:], input)
+		c.blockLen += n
+		input = input[n:]
+	}
+}
+
+func (c *chunkState) output() output {
+	return output{
+		cv:       c.cv,
+		block:    blockWords(c.block[:c.blockLen]),
+		counter:  c.counter,
+		blockLen: uint32(c.blockLen),
+		flags:    c.startFlag() | flagChunkEnd,
+	}
+}
+
+func parentOutput(left [8]uint32, right [8]uint32) output {
+	o := output{
+		cv:       iv,
+		blockLen: BlockSize,
+		flags:    flagParent,
+	}
+
+	copy(o.block[:8], left[:])
+	copy(o.block[8:], right[:])
+	return o
+}
+
+// digest is BLAKE3 hasher, chaining values of complete subtrees are kept in stack.
+type digest struct {
+	chunk chunkState
+	stack [][8]uint32
+	size  int
+}
+
+// New returns BLAKE3 of 32 bytes output.
+func New() hash.Hash {
+	return NewSize(Size)
+}
+
+// NewSize returns BLAKE3 of size bytes output, i.e. extendable output.
+func NewSize(size int) hash.Hash {
+	d := &digest{size: size}
+	d.Reset()
+	return d
+}
+
+func (d *digest) Size() int {
+	return d.size
+}
+
+func (d *digest) BlockSize() int {
+	return BlockSize
+}
+
+func (d *digest) Reset() {
+	d.chunk = newChunkState(iv, 0)
+	d.stack = d.stack[:0]
+}
+
+// addChunk merges chaining value of a complete chunk into the stack, total is number of
+// chunks so far. Subtrees are merged while total has trailing zero bits.
+func (d *digest) addChunk(cv [8]uint32, total uint64) {
+	for total&1 == 0 {
+		left := d.stack[len(d.stack)-1]
+		d.stack = d.stack[:len(d.stack)-1]
+		parent := parentOutput(left, cv)
+		cv = parent.chainingValue()
+		total >>= 1
+	}
+
+	d.stack = append(d.stack, cv)
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if d.chunk.len() == chunkSize {
+			o := d.chunk.output()
+			total := d.chunk.counter + 1
+			d.addChunk(o.chainingValue(), total)
+			d.chunk = newChunkState(iv, total)
+		}
+
+		take := chunkSize - d.chunk.len()
+		if take > len(p) {
+			take = len(p)
+		}
+
+		d.chunk.update(p[:take])
+		p = p[take:]
+	}
+
+	return n, nil
+}
+
+func (d *digest) Sum(b []byte) []byte {
+	o := d.chunk.output()
+	for i := len(d.stack) - 1; i >= 0; i-- {
+		o = parentOutput(d.stack[i], o.chainingValue())
+	}
+
+	out := make([]byte, d.size)
+	o.rootBytes(out)
+	return append(b, out...)
+}
+
+// Sum256 returns BLAKE3 of data in 32 bytes.
+func Sum256(data []byte) [Size]byte {
+	var sum [Size]byte
+	d := New()
+	d.Write(data)
+	d.Sum(sum[:0])
+	return sum
+}
@@ -0,0 +1,84 @@
+package blake3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// testInput returns input of official test vectors, bytes repeating 0 to 250.
+func testInput(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	return data
+}
+
+func TestSum256(t *testing.T) {
+	cases := []struct {
+		size     int
+		expected string
+	}{
+		{0, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+		{1, "2d3adedff11b61f14c886e35afa036736dcd87a74d27b5c1510225d0f592e213"},
+		{1024, "42214739f095a406f3fc83deb889744ac00df831c10daa55189b5d121c855af7"},
+		{1025, "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444"},
+		{2048, "e776b6028c7cd22a4d0ba182a8bf62205d2ef576467e838ed6f2529b85fba24a"},
+	}
+
+	for _, c := range cases {
+		sum := Sum256(testInput(c.size))
+		if got := hex.EncodeToString(sum[:]); got != c.expected {
+			t.Errorf("blake3 of %d bytes: got %s, expected %s", c.size, got, c.expected)
+		}
+	}
+
+	sum := Sum256([]byte("abc"))
+	expected := "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85"
+	if got := hex.EncodeToString(sum[:]); got != expected {
+		t.Errorf("blake3 of abc: got %s, expected %s", got, expected)
+	}
+}
+
+func TestExtendedOutput(t *testing.T) {
+	expected := "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262" +
+		"e00f03e7b69af26b7faaf09fcd333050338ddfe085b8cc869ca98b206c08243a"
+
+	d := NewSize(64)
+	if d.Size() != 64 {
+		t.Errorf("size of blake3 is %d, expected 64", d.Size())
+	}
+
+	if got := hex.EncodeToString(d.Sum(nil)); got != expected {
+		t.Errorf("blake3 of 64 bytes: got %s, expected %s", got, expected)
+	}
+}
+
+func TestWriteInPieces(t *testing.T) {
+	data := testInput(5000)
+	expected := Sum256(data)
+
+	for _, size := range []int{1, 63, 64, 1000, 1024, 1025} {
+		d := New()
+		for i := 0; i < len(data); i += size {
+			end := i + size
+			if end > len(data) {
+				end = len(data)
+			}
+
+			d.Write(data[i:end])
+		}
+
+		if got := d.Sum(nil); !bytes.Equal(got, expected[:]) {
+			t.Errorf("blake3 written in %d bytes pieces: got %x, expected %x", size, got, expected)
+		}
+
+		d.Reset()
+		d.Write(data)
+		if got := d.Sum(nil); !bytes.Equal(got, expected[:]) {
+			t.Errorf("blake3 after reset: got %x, expected %x", got, expected)
+		}
+	}
+}
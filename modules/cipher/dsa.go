@@ -0,0 +1,83 @@
+package cipher
+
+import (
+	"crypto/dsa" //nolint:all
+	"fmt"
+	"io"
+
+	"github.com/flily/go-ssl/common/encoder"
+)
+
+type DSAPrivateKey struct {
+	dsa.PrivateKey
+}
+
+var dsaParameterSizes = map[int]dsa.ParameterSizes{
+	1024: dsa.L1024N160,
+	2048: dsa.L2048N224,
+	3072: dsa.L3072N256,
+}
+
+func GenerateDSAParameters(random io.Reader, bits int) (*dsa.Parameters, error) {
+	sizes, found := dsaParameterSizes[bits]
+	if !found {
+		return nil, fmt.Errorf("unsupported DSA key size %d, must be one of 1024, 2048 and 3072", bits)
+	}
+
+	params := &dsa.Parameters{}
+	err := dsa.GenerateParameters(params, random, sizes)
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+func GenerateDSAKey(random io.Reader, params *dsa.Parameters) (*DSAPrivateKey, error) {
+	k := &DSAPrivateKey{}
+	k.Parameters = *params
+	err := dsa.GenerateKey(&k.PrivateKey, random)
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+func (k *DSAPrivateKey) DER() []byte {
+	return k.PKCS8PrivateKey()
+}
+
+func (k *DSAPrivateKey) PEM() []byte {
+	return k.PKCS8PrivateKeyPEM()
+}
+
+func (k *DSAPrivateKey) TraditionalPrivateKey() []byte {
+	content, _ := encoder.MarshalDSAPrivateKey(&k.PrivateKey)
+	return content
+}
+
+func (k *DSAPrivateKey) TraditionalPrivateKeyPEM() []byte {
+	content := k.TraditionalPrivateKey()
+	return encoder.PEMEncode("DSA PRIVATE KEY", content)
+}
+
+func (k *DSAPrivateKey) PKCS8PrivateKey() []byte {
+	content, _ := encoder.MarshalPKCS8DSAPrivateKey(&k.PrivateKey)
+	return content
+}
+
+func (k *DSAPrivateKey) PKCS8PrivateKeyPEM() []byte {
+	content := k.PKCS8PrivateKey()
+	return encoder.PEMEncode("PRIVATE KEY", content)
+}
+
+func (k *DSAPrivateKey) PKIXPublicKey() []byte {
+	content, _ := encoder.MarshalPKIXDSAPublicKey(&k.PublicKey)
+	return content
+}
+
+func (k *DSAPrivateKey) PKIXPublicKeyPEM() []byte {
+	content := k.PKIXPublicKey()
+	return encoder.PEMEncode("PUBLIC KEY", content)
+}
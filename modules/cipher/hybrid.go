@@ -0,0 +1,81 @@
+package cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"errors"
+	"hash"
+	"io"
+)
+
+// HybridKeySize is size of AES key wrapped in hybrid encryption, i.e. AES-256.
+const HybridKeySize = 32
+
+var ErrDecryption = errors.New("rsa: decryption error")
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// EncryptHybrid encrypts data of any size with a random AES-256 key in GCM, and wraps the key
+// in RSA-OAEP with hash and label. Ciphertext is the wrapped key in size of RSA key, nonce of
+// GCM, and encrypted data with tag.
+func EncryptHybrid(random io.Reader, key *rsa.PublicKey, newHash func() hash.Hash, label []byte, data []byte) ([]byte, error) {
+	secret := make([]byte, HybridKeySize)
+	if _, err := io.ReadFull(random, secret); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := rsa.EncryptOAEP(newHash(), random, key, secret, label)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(wrapped)+len(nonce)+len(data)+aead.Overhead())
+	out = append(out, wrapped...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, nil), nil
+}
+
+// DecryptHybrid decrypts ciphertext created by EncryptHybrid. All failures are
+// ErrDecryption, so that wrong keys are not distinguished from modified data.
+func DecryptHybrid(random io.Reader, key *rsa.PrivateKey, newHash func() hash.Hash, label []byte, data []byte) ([]byte, error) {
+	size := key.Size()
+	if len(data) < size+12 {
+		return nil, ErrDecryption
+	}
+
+	secret, err := rsa.DecryptOAEP(newHash(), random, key, data[:size], label)
+	if err != nil || len(secret) != HybridKeySize {
+		return nil, ErrDecryption
+	}
+
+	aead, err := newGCM(secret)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	nonce, sealed := data[size:size+aead.NonceSize()], data[size+aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return plain, nil
+}
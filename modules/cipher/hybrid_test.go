@@ -0,0 +1,76 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestHybridEncryption(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	label := []byte("label")
+	for _, size := range []int{0, 1, 200, 1 << 16} {
+		data := bytes.Repeat([]byte{0x5a}, size)
+		encrypted, err := EncryptHybrid(rand.Reader, &key.PublicKey, sha256.New, label, data)
+		if err != nil {
+			t.Fatalf("encrypt %d bytes failed: %s", size, err)
+		}
+
+		if expected := key.Size() + 12 + size + 16; len(encrypted) != expected {
+			t.Errorf("ciphertext of %d bytes is %d bytes, expected %d", size, len(encrypted), expected)
+		}
+
+		decrypted, err := DecryptHybrid(rand.Reader, key, sha256.New, label, encrypted)
+		if err != nil {
+			t.Fatalf("decrypt %d bytes failed: %s", size, err)
+		}
+
+		if !bytes.Equal(decrypted, data) {
+			t.Errorf("decrypted %d bytes are different from data", size)
+		}
+	}
+}
+
+func TestHybridDecryptionFailure(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	encrypted, err := EncryptHybrid(rand.Reader, &key.PublicKey, sha256.New, nil, []byte("message"))
+	if err != nil {
+		t.Fatalf("encrypt failed: %s", err)
+	}
+
+	modified := bytes.Clone(encrypted)
+	modified[len(modified)-1] ^= 0x01
+	cases := []struct {
+		name  string
+		data  []byte
+		label []byte
+		hash  bool
+	}{
+		{"modified data", modified, nil, false},
+		{"truncated data", encrypted[:key.Size()+4], nil, false},
+		{"wrong label", encrypted, []byte("label"), false},
+		{"wrong hash", encrypted, nil, true},
+	}
+
+	for _, c := range cases {
+		newHash := sha256.New
+		if c.hash {
+			newHash = sha1.New
+		}
+
+		if _, err := DecryptHybrid(rand.Reader, key, newHash, c.label, c.data); err != ErrDecryption {
+			t.Errorf("%s: expected ErrDecryption, got %v", c.name, err)
+		}
+	}
+}
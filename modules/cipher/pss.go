@@ -0,0 +1,286 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"io"
+	"math/big"
+)
+
+// Salt lengths of PSS other than a number of bytes, as rsa_pss_saltlen of openssl. Auto is
+// maximum length in signing, and detected from the signature in verifying.
+const (
+	PSSSaltLengthEqualsHash = -1
+	PSSSaltLengthMax        = -2
+	PSSSaltLengthAuto       = -3
+)
+
+var (
+	ErrMessageTooLong = errors.New("rsa: message too long for key size")
+	ErrVerification   = errors.New("rsa: verification error")
+)
+
+// PSSOptions are parameters of RSASSA-PSS, hash of MGF1 may differ from hash of message.
+// MGF1 uses Hash if MGFHash is nil, and signatures are then created and verified by
+// crypto/rsa if Hash is also available as StdHash.
+type PSSOptions struct {
+	SaltLength int
+	Hash       func() hash.Hash
+	MGFHash    func() hash.Hash
+	StdHash    crypto.Hash
+}
+
+func (o *PSSOptions) mgfHash() func() hash.Hash {
+	if o.MGFHash == nil {
+		return o.Hash
+	}
+
+	return o.MGFHash
+}
+
+// isStandard reports whether crypto/rsa supports the options, i.e. MGF1 uses hash of
+// message, which is known by crypto. Salt length 0 means auto in crypto/rsa, so it is
+// not supported.
+func (o *PSSOptions) isStandard() bool {
+	return o.MGFHash == nil && o.StdHash.Available() && o.SaltLength != 0
+}
+
+// stdOptions returns options of crypto/rsa for encoded message of emLen bytes.
+func (o *PSSOptions) stdOptions(emLen int, verifying bool) *rsa.PSSOptions {
+	opts := &rsa.PSSOptions{Hash: o.StdHash, SaltLength: o.SaltLength}
+	switch o.SaltLength {
+	case PSSSaltLengthEqualsHash:
+		opts.SaltLength = rsa.PSSSaltLengthEqualsHash
+
+	case PSSSaltLengthAuto:
+		opts.SaltLength = rsa.PSSSaltLengthAuto
+
+	case PSSSaltLengthMax:
+		// Auto of crypto/rsa is maximum length in signing, but any length in verifying.
+		opts.SaltLength = rsa.PSSSaltLengthAuto
+		if verifying {
+			opts.SaltLength = o.saltLength(emLen, o.StdHash.Size())
+		}
+	}
+
+	return opts
+}
+
+// mgf1XOR xors out with mask generated by MGF1 of seed, as in RFC 8017 B.2.1.
+func mgf1XOR(out []byte, newHash func() hash.Hash, seed []byte) {
+	h := newHash()
+	var counter [4]byte
+	done := 0
+	for done < len(out) {
+		h.Reset()
+		h.Write(seed)
+		h.Write(counter[:])
+		for _, b := range h.Sum(nil) {
+			if done >= len(out) {
+				break
+			}
+
+			out[done] ^= b
+			done++
+		}
+
+		for i := 3; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+	}
+}
+
+func pssHash(newHash func() hash.Hash, digest []byte, salt []byte) []byte {
+	h := newHash()
+	h.Write(make([]byte, 8))
+	h.Write(digest)
+	h.Write(salt)
+	return h.Sum(nil)
+}
+
+// saltLength returns salt length in bytes for encoded message of emLen bytes.
+func (o *PSSOptions) saltLength(emLen int, hLen int) int {
+	switch o.SaltLength {
+	case PSSSaltLengthEqualsHash:
+		return hLen
+
+	case PSSSaltLengthMax, PSSSaltLengthAuto:
+		return emLen - hLen - 2
+	}
+
+	return o.SaltLength
+}
+
+// EncodePSS encodes digest in EMSA-PSS of RFC 8017 9.1.1 with emBits bits.
+func EncodePSS(random io.Reader, digest []byte, emBits int, opts *PSSOptions) ([]byte, error) {
+	emLen := (emBits + 7) / 8
+	hLen := opts.Hash().Size()
+	if len(digest) != hLen {
+		return nil, errors.New("rsa: size of digest is different from hash")
+	}
+
+	sLen := opts.saltLength(emLen, hLen)
+	if sLen < 0 || emLen < hLen+sLen+2 {
+		return nil, ErrMessageTooLong
+	}
+
+	salt := make([]byte, sLen)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, err
+	}
+
+	em := make([]byte, emLen)
+	db := em[:emLen-hLen-1]
+	h := pssHash(opts.Hash, digest, salt)
+	db[emLen-sLen-hLen-2] = 0x01
+	copy(db[emLen-sLen-hLen-1:], salt)
+	mgf1XOR(db, opts.mgfHash(), h)
+	db[0] &= 0xff >> (8*emLen - emBits)
+	copy(em[emLen-hLen-1:], h)
+	em[emLen-1] = 0xbc
+	return em, nil
+}
+
+// VerifyPSS verifies em encoded in EMSA-PSS of RFC 8017 9.1.2 with emBits bits.
+func VerifyPSS(em []byte, digest []byte, emBits int, opts *PSSOptions) error {
+	emLen := (emBits + 7) / 8
+	hLen := opts.Hash().Size()
+	if len(em) != emLen || len(digest) != hLen || emLen < hLen+2 || em[emLen-1] != 0xbc {
+		return ErrVerification
+	}
+
+	mask := byte(0xff >> (8*emLen - emBits))
+	if em[0] & ^mask != 0 {
+		return ErrVerification
+	}
+
+	db := bytes.Clone(em[:emLen-hLen-1])
+	h := em[emLen-hLen-1 : emLen-1]
+	mgf1XOR(db, opts.mgfHash(), h)
+	db[0] &= mask
+
+	// Position of 0x01 separating padding and salt.
+	separator := bytes.IndexByte(db, 0x01)
+	if opts.SaltLength != PSSSaltLengthAuto {
+		sLen := opts.saltLength(emLen, hLen)
+		separator = emLen - hLen - sLen - 2
+		if sLen < 0 || separator < 0 || db[separator] != 0x01 {
+			return ErrVerification
+		}
+	}
+
+	if separator < 0 {
+		return ErrVerification
+	}
+
+	for _, b := range db[:separator] {
+		if b != 0 {
+			return ErrVerification
+		}
+	}
+
+	expected := pssHash(opts.Hash, digest, db[separator+1:])
+	if subtle.ConstantTimeCompare(h, expected) != 1 {
+		return ErrVerification
+	}
+
+	return nil
+}
+
+// RSAEncryptRaw returns data^e mod n, in size of key, i.e. RSA without padding.
+func RSAEncryptRaw(key *rsa.PublicKey, data []byte) ([]byte, error) {
+	m := new(big.Int).SetBytes(data)
+	if m.Cmp(key.N) >= 0 {
+		return nil, ErrMessageTooLong
+	}
+
+	c := new(big.Int).Exp(m, big.NewInt(int64(key.E)), key.N)
+	return c.FillBytes(make([]byte, key.Size())), nil
+}
+
+// RSADecryptRaw returns data^d mod n, in size of key, i.e. RSA without padding. Data is
+// blinded by a random number, and the result is checked with public key.
+func RSADecryptRaw(random io.Reader, key *rsa.PrivateKey, data []byte) ([]byte, error) {
+	c := new(big.Int).SetBytes(data)
+	if c.Cmp(key.N) >= 0 {
+		return nil, ErrMessageTooLong
+	}
+
+	e := big.NewInt(int64(key.E))
+	var r, inverse *big.Int
+	for inverse == nil {
+		buffer := make([]byte, key.Size())
+		if _, err := io.ReadFull(random, buffer); err != nil {
+			return nil, err
+		}
+
+		r = new(big.Int).Mod(new(big.Int).SetBytes(buffer), key.N)
+		if r.Sign() > 0 {
+			inverse = new(big.Int).ModInverse(r, key.N)
+		}
+	}
+
+	blinded := new(big.Int).Exp(r, e, key.N)
+	blinded.Mul(blinded, c).Mod(blinded, key.N)
+	m := new(big.Int).Exp(blinded, key.D, key.N)
+	m.Mul(m, inverse).Mod(m, key.N)
+
+	if new(big.Int).Exp(m, e, key.N).Cmp(c) != 0 {
+		return nil, errors.New("rsa: internal error of private key operation")
+	}
+
+	return m.FillBytes(make([]byte, key.Size())), nil
+}
+
+// SignPSS signs digest in RSASSA-PSS, by crypto/rsa if options are supported.
+func SignPSS(random io.Reader, key *rsa.PrivateKey, digest []byte, opts *PSSOptions) ([]byte, error) {
+	if opts.isStandard() {
+		emLen := (key.N.BitLen() + 6) / 8
+		return rsa.SignPSS(random, key, opts.StdHash, digest, opts.stdOptions(emLen, false))
+	}
+
+	em, err := EncodePSS(random, digest, key.N.BitLen()-1, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return RSADecryptRaw(random, key, em)
+}
+
+// VerifyPSSSignature verifies signature of digest in RSASSA-PSS.
+func VerifyPSSSignature(key *rsa.PublicKey, digest []byte, signature []byte, opts *PSSOptions) error {
+	if opts.isStandard() {
+		emLen := (key.N.BitLen() + 6) / 8
+		err := rsa.VerifyPSS(key, opts.StdHash, digest, signature, opts.stdOptions(emLen, true))
+		if err != nil {
+			return ErrVerification
+		}
+
+		return nil
+	}
+
+	if len(signature) != key.Size() {
+		return ErrVerification
+	}
+
+	em, err := RSAEncryptRaw(key, signature)
+	if err != nil {
+		return ErrVerification
+	}
+
+	// Encoded message is one byte shorter than key if size of key is 8n+1 bits.
+	emBits := key.N.BitLen() - 1
+	emLen := (emBits + 7) / 8
+	if em[0] != 0 && emLen < len(em) {
+		return ErrVerification
+	}
+
+	return VerifyPSS(em[len(em)-emLen:], digest, emBits, opts)
+}
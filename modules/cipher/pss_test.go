@@ -0,0 +1,135 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestPSSWithStandardLibrary(t *testing.T) {
+	// Options of StdHash are signed by crypto/rsa, the others by encoding of EMSA-PSS.
+	cases := []struct {
+		name string
+		opts PSSOptions
+	}{
+		{"crypto/rsa", PSSOptions{Hash: sha256.New, StdHash: crypto.SHA256}},
+		{"EMSA-PSS", PSSOptions{Hash: sha256.New, MGFHash: sha256.New}},
+	}
+
+	// 1025 bits key has encoded message one byte shorter than signature.
+	for _, bits := range []int{1024, 1025} {
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			t.Fatalf("generate key failed: %s", err)
+		}
+
+		digest := sha256.Sum256([]byte("message"))
+		for _, c := range cases {
+			opts := c.opts
+			for _, saltLength := range []int{PSSSaltLengthEqualsHash, PSSSaltLengthMax, 0, 20} {
+				opts.SaltLength = saltLength
+				signature, err := SignPSS(rand.Reader, key, digest[:], &opts)
+				if err != nil {
+					t.Fatalf("%s: sign failed: %s", c.name, err)
+				}
+
+				stdOpts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}
+				if err := rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, digest[:], signature, stdOpts); err != nil {
+					t.Errorf("%s: signature of %d bits key with salt length %d is rejected by standard library: %s",
+						c.name, bits, saltLength, err)
+				}
+
+				if err := VerifyPSSSignature(&key.PublicKey, digest[:], signature, &opts); err != nil {
+					t.Errorf("%s: signature of %d bits key with salt length %d is rejected: %s",
+						c.name, bits, saltLength, err)
+				}
+			}
+
+			signature, err := rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: 20})
+			if err != nil {
+				t.Fatalf("sign failed: %s", err)
+			}
+
+			for _, saltLength := range []int{20, PSSSaltLengthAuto} {
+				opts.SaltLength = saltLength
+				if err := VerifyPSSSignature(&key.PublicKey, digest[:], signature, &opts); err != nil {
+					t.Errorf("%s: signature of %d bits key with salt length %d is rejected: %s",
+						c.name, bits, saltLength, err)
+				}
+			}
+
+			for _, saltLength := range []int{PSSSaltLengthEqualsHash, PSSSaltLengthMax} {
+				opts.SaltLength = saltLength
+				if err := VerifyPSSSignature(&key.PublicKey, digest[:], signature, &opts); err == nil {
+					t.Errorf("%s: signature with wrong salt length %d is accepted", c.name, saltLength)
+				}
+			}
+		}
+	}
+}
+
+func TestPSSWithDifferentMGFHash(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	digest := sha256.Sum256([]byte("message"))
+	cases := []PSSOptions{
+		{SaltLength: 0, Hash: sha256.New, MGFHash: sha1.New},
+		{SaltLength: PSSSaltLengthMax, Hash: sha256.New, MGFHash: sha1.New},
+	}
+
+	for _, opts := range cases {
+		opts := opts
+		signature, err := SignPSS(rand.Reader, key, digest[:], &opts)
+		if err != nil {
+			t.Fatalf("sign failed: %s", err)
+		}
+
+		if err := VerifyPSSSignature(&key.PublicKey, digest[:], signature, &opts); err != nil {
+			t.Errorf("signature with salt length %d is rejected: %s", opts.SaltLength, err)
+		}
+
+		wrong := opts
+		wrong.MGFHash = sha256.New
+		if err := VerifyPSSSignature(&key.PublicKey, digest[:], signature, &wrong); err == nil {
+			t.Errorf("signature with wrong MGF hash is accepted")
+		}
+
+		signature[0] ^= 1
+		if err := VerifyPSSSignature(&key.PublicKey, digest[:], signature, &opts); err == nil {
+			t.Errorf("modified signature is accepted")
+		}
+	}
+}
+
+func TestRSARaw(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate key failed: %s", err)
+	}
+
+	message := []byte("raw message")
+	c, err := RSAEncryptRaw(&key.PublicKey, message)
+	if err != nil {
+		t.Fatalf("encrypt failed: %s", err)
+	}
+
+	m, err := RSADecryptRaw(rand.Reader, key, c)
+	if err != nil {
+		t.Fatalf("decrypt failed: %s", err)
+	}
+
+	if len(m) != key.Size() || !bytes.Equal(bytes.TrimLeft(m, "\x00"), message) {
+		t.Errorf("wrong message decrypted: %x", m)
+	}
+
+	if _, err := RSAEncryptRaw(&key.PublicKey, bytes.Repeat([]byte{0xff}, key.Size())); err == nil {
+		t.Errorf("message larger than modulus is accepted")
+	}
+}
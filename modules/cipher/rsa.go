@@ -1,9 +1,12 @@
 package cipher
 
 import (
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"fmt"
 	"io"
+	"math/big"
 
 	"github.com/flily/go-ssl/common/encoder"
 )
@@ -70,3 +73,115 @@ func (k *RSAPrivateKey) PKIXPrivateKeyPEM() []byte {
 	content := k.PKIXPrivateKey()
 	return encoder.PEMEncode("PUBLIC KEY", content)
 }
+
+// minRSAKeyBits is the minimum size of keys, the same as crypto/rsa.
+const minRSAKeyBits = 1024
+
+// maxRSAKeyRetries limits attempts of generating primes, which fail only in a small
+// probability for keys of valid size.
+const maxRSAKeyRetries = 1000
+
+// MaxRSAPrimes returns maximum number of primes of a key of bits, as limited by openssl so
+// that primes are still large enough.
+func MaxRSAPrimes(bits int) int {
+	switch {
+	case bits < 1024:
+		return 2
+
+	case bits < 4096:
+		return 3
+
+	case bits < 8192:
+		return 4
+	}
+
+	return 5
+}
+
+// CheckRSAKeyParameters checks size, number of primes and public exponent of a key to
+// generate.
+func CheckRSAKeyParameters(nprimes int, bits int, e int) error {
+	if bits < minRSAKeyBits {
+		return fmt.Errorf("rsa: %d bits key is too small, at least %d bits", bits, minRSAKeyBits)
+	}
+
+	if nprimes < 2 || nprimes > MaxRSAPrimes(bits) {
+		return fmt.Errorf("rsa: %d bits key can have 2 to %d primes, got %d", bits, MaxRSAPrimes(bits), nprimes)
+	}
+
+	if e < 3 || e%2 == 0 {
+		return fmt.Errorf("rsa: public exponent must be odd and at least 3, got %d", e)
+	}
+
+	return nil
+}
+
+// GenerateMultiPrimeRSAKey generates a RSA key of nprimes primes and public exponent e.
+// Private exponent is inverse of e modulo lambda(n), the smallest one, as openssl does.
+func GenerateMultiPrimeRSAKey(random io.Reader, nprimes int, bits int, e int) (*RSAPrivateKey, error) {
+	if err := CheckRSAKeyParameters(nprimes, bits, e); err != nil {
+		return nil, err
+	}
+
+	one := big.NewInt(1)
+	exponent := big.NewInt(int64(e))
+	primes := make([]*big.Int, nprimes)
+	for retry := 0; retry < maxRSAKeyRetries; retry++ {
+		// Top two bits of each prime are set, which makes product one bit shorter than sum
+		// of sizes for every three primes, same as crypto/rsa.
+		todo := bits
+		if nprimes >= 7 {
+			todo += (nprimes - 2) / 5
+		}
+
+		for i := range primes {
+			var err error
+			primes[i], err = rand.Prime(random, todo/(nprimes-i))
+			if err != nil {
+				return nil, err
+			}
+
+			todo -= primes[i].BitLen()
+		}
+
+		n := big.NewInt(1)
+		lambda := big.NewInt(1)
+		valid := true
+		for i, prime := range primes {
+			for _, other := range primes[:i] {
+				if prime.Cmp(other) == 0 {
+					valid = false
+				}
+			}
+
+			pm1 := new(big.Int).Sub(prime, one)
+			gcd := new(big.Int).GCD(nil, nil, exponent, pm1)
+			if gcd.Cmp(one) != 0 {
+				valid = false
+			}
+
+			n.Mul(n, prime)
+			gcd.GCD(nil, nil, lambda, pm1)
+			lambda.Mul(lambda, pm1).Div(lambda, gcd)
+		}
+
+		if !valid || n.BitLen() != bits {
+			continue
+		}
+
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: e},
+			D:         new(big.Int).ModInverse(exponent, lambda),
+			Primes:    primes,
+		}
+
+		key.Precompute()
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+
+		return &RSAPrivateKey{PrivateKey: *key}, nil
+	}
+
+	return nil, fmt.Errorf("rsa: no valid key generated in %d attempts", maxRSAKeyRetries)
+}
@@ -0,0 +1,70 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestGenerateMultiPrimeRSAKey(t *testing.T) {
+	cases := []struct {
+		primes int
+		bits   int
+		e      int
+	}{
+		{2, 1024, 65537},
+		{2, 1024, 3},
+		{3, 2048, 65537},
+		{3, 1536, 17},
+	}
+
+	for _, c := range cases {
+		key, err := GenerateMultiPrimeRSAKey(rand.Reader, c.primes, c.bits, c.e)
+		if err != nil {
+			t.Fatalf("generate %d primes %d bits key failed: %s", c.primes, c.bits, err)
+		}
+
+		if len(key.Primes) != c.primes || key.N.BitLen() != c.bits || key.E != c.e {
+			t.Errorf("got key of %d primes, %d bits and e %d, expected %+v",
+				len(key.Primes), key.N.BitLen(), key.E, c)
+		}
+
+		message := []byte("message")
+		encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, message)
+		if err != nil {
+			t.Fatalf("encrypt failed: %s", err)
+		}
+
+		decrypted, err := rsa.DecryptPKCS1v15(rand.Reader, &key.PrivateKey, encrypted)
+		if err != nil || !bytes.Equal(decrypted, message) {
+			t.Errorf("decrypt with key of %+v failed: %v", c, err)
+		}
+	}
+}
+
+func TestGenerateMultiPrimeRSAKeyErrors(t *testing.T) {
+	cases := []struct {
+		primes int
+		bits   int
+		e      int
+	}{
+		{1, 2048, 65537},
+		{2, 8, 3},
+		{2, 1000, 65537},
+		{3, 1023, 65537},
+		{4, 2048, 65537},
+		{2, 2048, 1},
+		{2, 2048, 65536},
+	}
+
+	for _, c := range cases {
+		if err := CheckRSAKeyParameters(c.primes, c.bits, c.e); err == nil {
+			t.Errorf("parameters of %+v are accepted", c)
+		}
+
+		if _, err := GenerateMultiPrimeRSAKey(rand.Reader, c.primes, c.bits, c.e); err == nil {
+			t.Errorf("key of %+v is generated", c)
+		}
+	}
+}
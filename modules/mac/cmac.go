@@ -0,0 +1,112 @@
+package mac
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+)
+
+// cmac is CMAC of RFC 4493 and NIST SP 800-38B on a block cipher of 64 or 128 bits.
+type cmac struct {
+	block  cipher.Block
+	k1, k2 []byte
+
+	// x is chaining value, pending is the last block written, which is processed in Sum.
+	x       []byte
+	pending []byte
+}
+
+// shiftSubkey returns in shifted left by one bit, xored with rb if the highest bit is set.
+func shiftSubkey(in []byte, rb byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+
+	if carry != 0 {
+		out[len(out)-1] ^= rb
+	}
+
+	return out
+}
+
+// NewCMAC returns CMAC of block, e.g. a cipher of aes.NewCipher.
+func NewCMAC(block cipher.Block) (hash.Hash, error) {
+	var rb byte
+	switch block.BlockSize() {
+	case 8:
+		rb = 0x1b
+	case 16:
+		rb = 0x87
+	default:
+		return nil, fmt.Errorf("cmac: unsupported block size %d", block.BlockSize())
+	}
+
+	l := make([]byte, block.BlockSize())
+	block.Encrypt(l, l)
+
+	m := &cmac{
+		block:   block,
+		k1:      shiftSubkey(l, rb),
+		x:       make([]byte, block.BlockSize()),
+		pending: make([]byte, 0, block.BlockSize()),
+	}
+
+	m.k2 = shiftSubkey(m.k1, rb)
+	return m, nil
+}
+
+func (m *cmac) Size() int {
+	return m.block.BlockSize()
+}
+
+func (m *cmac) BlockSize() int {
+	return m.block.BlockSize()
+}
+
+func (m *cmac) Reset() {
+	for i := range m.x {
+		m.x[i] = 0
+	}
+
+	m.pending = m.pending[:0]
+}
+
+func (m *cmac) Write(p []byte) (int, error) {
+	n := len(p)
+	size := m.block.BlockSize()
+	for len(p) > 0 {
+		// A full pending block is processed only if more data follow, the last block is
+		// processed with subkeys in Sum.
+		if len(m.pending) == size {
+			subtle.XORBytes(m.x, m.x, m.pending)
+			m.block.Encrypt(m.x, m.x)
+			m.pending = m.pending[:0]
+		}
+
+		c := copy(m.pending[len(m.pending):size], p)
+		m.pending = m.pending[:len(m.pending)+c]
+		p = p[c:]
+	}
+
+	return n, nil
+}
+
+func (m *cmac) Sum(b []byte) []byte {
+	size := m.block.BlockSize()
+	last := make([]byte, size)
+	copy(last, m.pending)
+	if len(m.pending) == size {
+		subtle.XORBytes(last, last, m.k1)
+	} else {
+		last[len(m.pending)] = 0x80
+		subtle.XORBytes(last, last, m.k2)
+	}
+
+	subtle.XORBytes(last, last, m.x)
+	m.block.Encrypt(last, last)
+	return append(b, last...)
+}
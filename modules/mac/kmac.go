@@ -0,0 +1,92 @@
+package mac
+
+import (
+	"encoding/binary"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Rates of cSHAKE in bytes.
+const (
+	rate128 = 168
+	rate256 = 136
+)
+
+// kmac is KMAC of NIST SP 800-185, cSHAKE with function name "KMAC" and the key absorbed
+// first.
+type kmac struct {
+	sha3.ShakeHash
+	key  []byte
+	size int
+}
+
+// encode returns left_encode or right_encode of x of NIST SP 800-185.
+func encode(x uint64, left bool) []byte {
+	var buffer [9]byte
+	binary.BigEndian.PutUint64(buffer[1:], x)
+	i := 1
+	for i < 8 && buffer[i] == 0 {
+		i++
+	}
+
+	n := byte(9 - i)
+	if left {
+		buffer[i-1] = n
+		return buffer[i-1:]
+	}
+
+	return append(buffer[i:], n)
+}
+
+// bytepad returns encoded key padded to multiple of rate, i.e. bytepad(encode_string(K), w).
+func bytepad(key []byte, rate int) []byte {
+	padded := encode(uint64(rate), true)
+	padded = append(padded, encode(uint64(len(key))*8, true)...)
+	padded = append(padded, key...)
+	if rest := len(padded) % rate; rest > 0 {
+		padded = append(padded, make([]byte, rate-rest)...)
+	}
+
+	return padded
+}
+
+func newKMAC(shake sha3.ShakeHash, rate int, key []byte, size int) hash.Hash {
+	m := &kmac{
+		ShakeHash: shake,
+		key:       bytepad(key, rate),
+		size:      size,
+	}
+
+	m.ShakeHash.Write(m.key)
+	return m
+}
+
+// NewKMAC128 returns KMAC128 of key and customization string, size is length of output in
+// bytes.
+func NewKMAC128(key []byte, size int, customization []byte) hash.Hash {
+	return newKMAC(sha3.NewCShake128([]byte("KMAC"), customization), rate128, key, size)
+}
+
+// NewKMAC256 returns KMAC256 of key and customization string, size is length of output in
+// bytes.
+func NewKMAC256(key []byte, size int, customization []byte) hash.Hash {
+	return newKMAC(sha3.NewCShake256([]byte("KMAC"), customization), rate256, key, size)
+}
+
+func (m *kmac) Size() int {
+	return m.size
+}
+
+func (m *kmac) Reset() {
+	m.ShakeHash.Reset()
+	m.ShakeHash.Write(m.key)
+}
+
+func (m *kmac) Sum(b []byte) []byte {
+	shake := m.ShakeHash.Clone()
+	shake.Write(encode(uint64(m.size)*8, false))
+	out := make([]byte, m.size)
+	shake.Read(out)
+	return append(b, out...)
+}
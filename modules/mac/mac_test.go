@@ -0,0 +1,134 @@
+package mac
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %s: %s", s, err)
+	}
+
+	return data
+}
+
+const testKey = "404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f"
+
+func newTestCMAC(t *testing.T, key string) hash.Hash {
+	block, err := aes.NewCipher(decodeHex(t, key))
+	if err != nil {
+		t.Fatalf("new cipher failed: %s", err)
+	}
+
+	m, err := NewCMAC(block)
+	if err != nil {
+		t.Fatalf("new cmac failed: %s", err)
+	}
+
+	return m
+}
+
+func newTestPoly1305(t *testing.T) hash.Hash {
+	m, err := NewPoly1305(decodeHex(t, testKey))
+	if err != nil {
+		t.Fatalf("new poly1305 failed: %s", err)
+	}
+
+	return m
+}
+
+func TestMACs(t *testing.T) {
+	rfc4493 := "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411"
+	cases := []struct {
+		name     string
+		mac      hash.Hash
+		message  []byte
+		expected string
+	}{
+		{
+			"cmac empty",
+			newTestCMAC(t, "2b7e151628aed2a6abf7158809cf4f3c"),
+			nil,
+			"bb1d6929e95937287fa37d129b756746",
+		},
+		{
+			"cmac partial block",
+			newTestCMAC(t, "2b7e151628aed2a6abf7158809cf4f3c"),
+			decodeHex(t, rfc4493),
+			"dfa66747de9ae63030ca32611497c827",
+		},
+		{
+			"cmac aes-256",
+			newTestCMAC(t, testKey),
+			bytes.Repeat([]byte("a"), 200),
+			"878a00fd6a6891209ed578b39c56e40c",
+		},
+		{
+			"kmac128",
+			NewKMAC128(decodeHex(t, testKey), 32, nil),
+			[]byte{0x00, 0x01, 0x02, 0x03},
+			"e5780b0d3ea6f7d3a429c5706aa43a00fadbd7d49628839e3187243f456ee14e",
+		},
+		{
+			"kmac128 customization",
+			NewKMAC128(decodeHex(t, testKey), 32, []byte("My Tagged Application")),
+			[]byte{0x00, 0x01, 0x02, 0x03},
+			"3b1fba963cd8b0b59e8c1a6d71888b7143651af8ba0a7070c0979e2811324aa5",
+		},
+		{
+			"kmac256 customization",
+			NewKMAC256(decodeHex(t, testKey), 64, []byte("My Tagged Application")),
+			[]byte{0x00, 0x01, 0x02, 0x03},
+			"20c570c31346f703c9ac36c61c03cb64c3970d0cfc787e9b79599d273a68d2f7" +
+				"f69d4cc3de9d104a351689f27cf6f5951f0103f33f4f24871024d9c27773a8dd",
+		},
+		{
+			"poly1305",
+			newTestPoly1305(t),
+			bytes.Repeat([]byte("a"), 200),
+			"1fe50f17712567c7309ef44d5f44a933",
+		},
+	}
+
+	for _, c := range cases {
+		// Write in pieces of odd size to cover partial and full blocks.
+		for i := 0; i < len(c.message); i += 7 {
+			end := i + 7
+			if end > len(c.message) {
+				end = len(c.message)
+			}
+
+			c.mac.Write(c.message[i:end])
+		}
+
+		sum := hex.EncodeToString(c.mac.Sum(nil))
+		if sum != c.expected {
+			t.Errorf("%s: got %s, expected %s", c.name, sum, c.expected)
+		}
+
+		if again := hex.EncodeToString(c.mac.Sum(nil)); again != sum {
+			t.Errorf("%s: Sum changed state: %s", c.name, again)
+		}
+
+		c.mac.Reset()
+		c.mac.Write(c.message)
+		if sum := hex.EncodeToString(c.mac.Sum(nil)); sum != c.expected {
+			t.Errorf("%s: got %s after reset, expected %s", c.name, sum, c.expected)
+		}
+
+		if c.mac.Size()*2 != len(c.expected) {
+			t.Errorf("%s: wrong size %d", c.name, c.mac.Size())
+		}
+	}
+}
+
+func TestPoly1305KeySize(t *testing.T) {
+	if _, err := NewPoly1305(make([]byte, 16)); err == nil {
+		t.Errorf("16 bytes key should be rejected")
+	}
+}
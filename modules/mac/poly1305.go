@@ -0,0 +1,48 @@
+package mac
+
+import (
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/poly1305" //nolint:all
+)
+
+// poly1305MAC is Poly1305 as a hash.Hash. Poly1305 keys must be used only once.
+type poly1305MAC struct {
+	key [32]byte
+	mac *poly1305.MAC
+}
+
+// NewPoly1305 returns Poly1305 of a 32 bytes one-time key.
+func NewPoly1305(key []byte) (hash.Hash, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("poly1305: key must be 32 bytes, got %d bytes", len(key))
+	}
+
+	m := &poly1305MAC{}
+	copy(m.key[:], key)
+	m.Reset()
+	return m, nil
+}
+
+func (m *poly1305MAC) Size() int {
+	return poly1305.TagSize
+}
+
+func (m *poly1305MAC) BlockSize() int {
+	return 16
+}
+
+func (m *poly1305MAC) Reset() {
+	m.mac = poly1305.New(&m.key)
+}
+
+func (m *poly1305MAC) Write(p []byte) (int, error) {
+	return m.mac.Write(p)
+}
+
+// Sum returns MAC of a copy of the state, as poly1305.MAC can not be written after Sum.
+func (m *poly1305MAC) Sum(b []byte) []byte {
+	mac := *m.mac
+	return mac.Sum(b)
+}
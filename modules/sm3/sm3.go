@@ -0,0 +1,155 @@
+// Package sm3 implements the SM3 hash function of GB/T 32905-2016.
+package sm3
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	// Size is size of SM3 output in bytes.
+	Size = 32
+
+	// BlockSize is size of SM3 blocks in bytes.
+	BlockSize = 64
+)
+
+var iv = [8]uint32{
+	0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600,
+	0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e,
+}
+
+type digest struct {
+	v      [8]uint32
+	block  [BlockSize]byte
+	n      int
+	length uint64
+}
+
+// New returns a SM3 hash.
+func New() hash.Hash {
+	d := &digest{}
+	d.Reset()
+	return d
+}
+
+func (d *digest) Size() int {
+	return Size
+}
+
+func (d *digest) BlockSize() int {
+	return BlockSize
+}
+
+func (d *digest) Reset() {
+	d.v = iv
+	d.n = 0
+	d.length = 0
+}
+
+func p0(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17)
+}
+
+func p1(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23)
+}
+
+func compress(v *[8]uint32, block []byte) {
+	var w [68]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(block[i*4:])
+	}
+
+	for j := 16; j < 68; j++ {
+		w[j] = p1(w[j-16]^w[j-9]^bits.RotateLeft32(w[j-3], 15)) ^ bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+	}
+
+	a, b, c, d, e, f, g, h := v[0], v[1], v[2], v[3], v[4], v[5], v[6], v[7]
+	for j := 0; j < 64; j++ {
+		var t, ff, gg uint32
+		if j < 16 {
+			t = 0x79cc4519
+			ff = a ^ b ^ c
+			gg = e ^ f ^ g
+		} else {
+			t = 0x7a879d8a
+			ff = (a & b) | (a & c) | (b & c)
+			gg = (e & f) | (^e & g)
+		}
+
+		a12 := bits.RotateLeft32(a, 12)
+		ss1 := bits.RotateLeft32(a12+e+bits.RotateLeft32(t, j%32), 7)
+		ss2 := ss1 ^ a12
+		tt1 := ff + d + ss2 + (w[j] ^ w[j+4])
+		tt2 := gg + h + ss1 + w[j]
+		d = c
+		c = bits.RotateLeft32(b, 9)
+		b = a
+		a = tt1
+		h = g
+		g = bits.RotateLeft32(f, 19)
+		f = e
+		e = p0(tt2)
+	}
+
+	v[0] ^= a
+	v[1] ^= b
+	v[2] ^= c
+	v[3] ^= d
+	v[4] ^= e
+	v[5] ^= f
+	v[6] ^= g
+	v[7] ^= h
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.length += uint64(n)
+	if d.n > 0 {
+		c := copy(d.block[d.n:], p)
+		d.n += c
+		p = p[c:]
+		if d.n < BlockSize {
+			return n, nil
+		}
+
+		compress(&d.v, d.block[:])
+		d.n = 0
+	}
+
+	for len(p) >= BlockSize {
+		compress(&d.v, p[:BlockSize])
+		p = p[BlockSize:]
+	}
+
+	d.n = copy(d.block[:], p)
+	return n, nil
+}
+
+func (d *digest) Sum(b []byte) []byte {
+	// Padding is written to a copy, so that more data can be written after Sum.
+	c := *d
+	var padding [BlockSize + 8]byte
+	padding[0] = 0x80
+	size := BlockSize - (int(c.length)+8)%BlockSize
+	binary.BigEndian.PutUint64(padding[size:], c.length*8)
+	c.Write(padding[:size+8])
+
+	var out [Size]byte
+	for i, x := range c.v {
+		binary.BigEndian.PutUint32(out[i*4:], x)
+	}
+
+	return append(b, out[:]...)
+}
+
+// Sum returns SM3 of data.
+func Sum(data []byte) [Size]byte {
+	var sum [Size]byte
+	d := New()
+	d.Write(data)
+	d.Sum(sum[:0])
+	return sum
+}
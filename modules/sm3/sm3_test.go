@@ -0,0 +1,47 @@
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	cases := []struct {
+		data     []byte
+		expected string
+	}{
+		{nil, "1ab21d8355cfa17f8e61194831e81a8f22bec8c728fefb747ed035eb5082aa2b"},
+		{[]byte("abc"), "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		{bytes.Repeat([]byte("abcd"), 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+		{bytes.Repeat([]byte("a"), 1000), "f4bedca973227d45c5b822551d2e762d4cfb0e9af70b241452545727b5fb046f"},
+	}
+
+	for _, c := range cases {
+		sum := Sum(c.data)
+		if got := hex.EncodeToString(sum[:]); got != c.expected {
+			t.Errorf("sm3 of %d bytes: got %s, expected %s", len(c.data), got, c.expected)
+		}
+	}
+}
+
+func TestWriteInPieces(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	expected := Sum(data)
+
+	for _, size := range []int{1, 7, 63, 64, 65, 200} {
+		d := New()
+		for i := 0; i < len(data); i += size {
+			end := i + size
+			if end > len(data) {
+				end = len(data)
+			}
+
+			d.Write(data[i:end])
+		}
+
+		if got := d.Sum(nil); !bytes.Equal(got, expected[:]) {
+			t.Errorf("sm3 written in %d bytes pieces: got %x, expected %x", size, got, expected)
+		}
+	}
+}